@@ -0,0 +1,63 @@
+package request
+
+import "net/http"
+
+//KeyLocation is where SetAPIKey places the API key
+type KeyLocation int
+
+const (
+	//KeyInHeader sends the API key in a header, X-Api-Key by default
+	KeyInHeader KeyLocation = iota
+	//KeyInQuery sends the API key as a query param, api_key by default
+	KeyInQuery
+)
+
+type apiKey struct {
+	key  string
+	in   KeyLocation
+	name string
+}
+
+//SetAPIKey sends key in the header or query param called name, or the default name of the location when empty.
+//The key is applied last when the request is built so it replaces any header or query param of the same name
+//set otherwise. Call it once per location to send the key in both
+func (r *Request) SetAPIKey(key string, in KeyLocation, name string) *Request {
+	if r == nil {
+		return nil
+	}
+	if name == "" {
+		name = "X-Api-Key"
+		if in == KeyInQuery {
+			name = "api_key"
+		}
+	}
+
+	keys := make([]apiKey, 0, len(r.apiKeys)+1)
+	for _, k := range r.apiKeys {
+		if k.in != in || k.name != name {
+			keys = append(keys, k)
+		}
+	}
+	r.apiKeys = append(keys, apiKey{key: key, in: in, name: name})
+	return r
+}
+
+//setAPIKeyHeaders sets the API keys placed in headers on header
+func (r *Request) setAPIKeyHeaders(header http.Header) {
+	for _, k := range r.apiKeys {
+		if k.in == KeyInHeader {
+			header.Set(k.name, k.key)
+		}
+	}
+}
+
+//withoutParam returns params without those called key
+func withoutParam(params []queryParam, key string) []queryParam {
+	filtered := make([]queryParam, 0, len(params))
+	for _, param := range params {
+		if param.key != key {
+			filtered = append(filtered, param)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,62 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAPIKeyQuery struct {
+	APIKey string `url:"api_key"`
+	Page   int    `url:"page"`
+}
+
+func TestSetAPIKey(t *testing.T) {
+	t.Run("header", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetHeader("X-Api-Key", "stale").SetAPIKey("secret", KeyInHeader, "").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"secret"}, req.Header.Values("X-Api-Key"))
+		assert.Empty(t, req.URL.RawQuery)
+	})
+
+	t.Run("custom header", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetAPIKey("secret", KeyInHeader, "Api-Token").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "secret", req.Header.Get("Api-Token"))
+	})
+
+	t.Run("query", func(t *testing.T) {
+		req, err := New().Get("http://example.com?page=2").SetAPIKey("secret", KeyInQuery, "").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "api_key=secret&page=2", req.URL.RawQuery)
+		assert.Empty(t, req.Header.Get("X-Api-Key"))
+	})
+
+	t.Run("both", func(t *testing.T) {
+		req, err := New().Get("http://example.com").
+			SetAPIKey("secret", KeyInHeader, "").
+			SetAPIKey("secret", KeyInQuery, "key").
+			Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "secret", req.Header.Get("X-Api-Key"))
+		assert.Equal(t, "key=secret", req.URL.RawQuery)
+	})
+
+	t.Run("not clobbered by query struct", func(t *testing.T) {
+		r := New().Get("http://example.com?api_key=fromurl").SetAPIKey("secret", KeyInQuery, "")
+
+		req, err := r.SetQuery(&fakeAPIKeyQuery{APIKey: "fromstruct", Page: 3}).AddQueryParam("api_key", "added").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "api_key=secret&page=3", req.URL.RawQuery)
+
+		req, err = r.SetQueryOrdering(QueryOrderInsertion).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "api_key=secret&page=3", req.URL.RawQuery)
+	})
+
+	t.Run("replaced", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetAPIKey("old", KeyInQuery, "").SetAPIKey("new", KeyInQuery, "").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "api_key=new", req.URL.RawQuery)
+	})
+}
@@ -0,0 +1,25 @@
+package request
+
+import "context"
+
+//Result bundles the outcome of an asynchronous execution
+type Result struct {
+	Response *Response
+	Err      error
+}
+
+//ExecuteAsync executes a copy of the request under ctx in a new goroutine and returns a channel
+//receiving its Result once. The channel is buffered and closed after the result is sent,
+//so the goroutine finishes even if the result is never read
+func (r *Request) ExecuteAsync(ctx context.Context) <-chan Result {
+	req := r.New().SetContext(ctx)
+	results := make(chan Result, 1)
+
+	go func() {
+		defer close(results)
+		resp, err := req.Execute()
+		results <- Result{Response: resp, Err: err}
+	}()
+
+	return results
+}
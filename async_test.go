@@ -0,0 +1,80 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//waitForGoroutines waits until at most n goroutines are running
+func waitForGoroutines(t *testing.T, n int) {
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > n {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines running, expected at most %d", runtime.NumGoroutine(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestExecuteAsync(t *testing.T) {
+	r := newMockRequest(fakeHandler(200, `{"id":200, "name":"John"}`, nil))
+
+	result := <-r.Get("http://example.com").SetSuccess(&fakeSuccess{}).ExecuteAsync(context.Background())
+
+	assert.Nil(t, result.Err)
+	assert.Equal(t, 200, result.Response.StatusCode)
+	assert.Equal(t, &fakeSuccess{ID: 200, Name: "John"}, result.Response.Success)
+}
+
+func TestExecuteAsyncCancel(t *testing.T) {
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+		w.WriteHeader(http.StatusOK)
+	})
+	r.client = &contextClient{r.client}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results := r.Get("http://example.com").ExecuteAsync(ctx)
+
+	select {
+	case <-results:
+		t.Fatal("request completed before cancellation")
+	case <-time.After(10 * time.Millisecond):
+	}
+	cancel()
+
+	result, ok := <-results
+	assert.True(t, ok)
+	assert.ErrorIs(t, result.Err, context.Canceled)
+	_, ok = <-results
+	assert.False(t, ok)
+}
+
+func TestExecuteAsyncAbandoned(t *testing.T) {
+	before := runtime.NumGoroutine()
+	r := newMockRequest(fakeHandler(200, `{}`, nil))
+
+	for i := 0; i < 10; i++ {
+		r.Get("http://example.com").ExecuteAsync(context.Background())
+	}
+
+	waitForGoroutines(t, before)
+}
+
+//contextClient fails requests whose context is done once the wrapped client returns, like http.Client does
+type contextClient struct {
+	client Doer
+}
+
+func (c *contextClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if ctxErr := req.Context().Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	return resp, err
+}
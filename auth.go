@@ -0,0 +1,109 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+//OnUnauthorized sets a hook refreshing the bearer token when a 401 Unauthorized response is received.
+//The request is then sent once more with the new token in the Authorization header. If the refresh fails,
+//the 401 response is returned along with the refresh error. Concurrent 401s share a single refresh and the
+//refreshed token is shared with every request derived from this one via New
+func (r *Request) OnUnauthorized(refresh func(ctx context.Context) (newToken string, err error)) *Request {
+	if r == nil {
+		return nil
+	}
+	r.auth = &tokenRefresher{refresh: refresh}
+	return r
+}
+
+//SetTokenRefresher refreshes the bearer token with refresh when a 401 Unauthorized response is received
+//and sends the request once more with the new token. It is OnUnauthorized for refreshers which do not take a context
+func (r *Request) SetTokenRefresher(refresh func() (newToken string, err error)) *Request {
+	return r.OnUnauthorized(func(context.Context) (string, error) {
+		return refresh()
+	})
+}
+
+//tokenRefresher holds the latest refreshed token and coalesces concurrent refreshes
+type tokenRefresher struct {
+	mu      sync.Mutex
+	refresh func(ctx context.Context) (string, error)
+	token   string
+	call    *refreshCall
+}
+
+type refreshCall struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+//current returns the latest refreshed token, empty if none
+func (t *tokenRefresher) current() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.token
+}
+
+//refreshAfter returns a token to replace the rejected authorization header value.
+//It only calls refresh when no newer token is available and no refresh is in flight
+func (t *tokenRefresher) refreshAfter(ctx context.Context, rejected string) (string, error) {
+	t.mu.Lock()
+	if t.token != "" && bearer(t.token) != rejected {
+		token := t.token
+		t.mu.Unlock()
+		return token, nil
+	}
+	if c := t.call; c != nil {
+		t.mu.Unlock()
+		<-c.done
+		return c.token, c.err
+	}
+
+	c := &refreshCall{done: make(chan struct{})}
+	t.call = c
+	t.mu.Unlock()
+
+	c.token, c.err = t.refresh(ctx)
+
+	t.mu.Lock()
+	if c.err == nil {
+		t.token = c.token
+	}
+	t.call = nil
+	t.mu.Unlock()
+	close(c.done)
+
+	return c.token, c.err
+}
+
+//reauthorize refreshes the token after a 401 response and sends the request once more,
+//to the base URL which answered with the 401 when failing over between base URLs
+func (r *Request) reauthorize(req *http.Request, resp *Response, attempt int) (*Response, error) {
+	token, err := r.auth.refreshAfter(req.Context(), req.Header.Get("Authorization"))
+	if err != nil {
+		return resp, err
+	}
+
+	address := req.URL.String()
+	if resp.Endpoint != "" {
+		address = joinURL(resp.Endpoint, r.url)
+	}
+	retry, err := endpointRequest(req, address)
+	if err != nil {
+		return resp, err
+	}
+	retry.Header.Set("Authorization", bearer(token))
+
+	retried, err := r.exchange(retry, attempt)
+	if retried != nil {
+		retried.Endpoint = resp.Endpoint
+	}
+	return retried, err
+}
+
+func bearer(token string) string {
+	return "Bearer " + token
+}
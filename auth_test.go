@@ -0,0 +1,160 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func bearerHandler(valid string, calls *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(calls, 1)
+		if req.Header.Get("Authorization") != "Bearer "+valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestOnUnauthorized(t *testing.T) {
+	var calls, refreshes int32
+	r := newMockRequest(bearerHandler("fresh", &calls)).OnUnauthorized(func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&refreshes, 1)
+		return "fresh", nil
+	})
+	r.AddHeader("Authorization", "Bearer expired")
+
+	result, err := r.New().Get("http://example.com").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, int32(2), calls)
+	assert.Equal(t, int32(1), refreshes)
+
+	//derived requests reuse the refreshed token
+	result, err = r.New().Get("http://example.com").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, int32(3), calls)
+	assert.Equal(t, int32(1), refreshes)
+}
+
+func TestOnUnauthorizedFailover(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer primary.Close()
+	var calls int32
+	secondary := httptest.NewServer(bearerHandler("fresh", &calls))
+	defer secondary.Close()
+
+	r := New().SetBaseURLs(primary.URL, secondary.URL).OnUnauthorized(func(ctx context.Context) (string, error) {
+		return "fresh", nil
+	})
+	r.AddHeader("Authorization", "Bearer expired")
+
+	result, err := r.New().Get("/items?page=2").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, secondary.URL, result.Endpoint)
+	assert.Equal(t, int32(2), calls)
+}
+
+func TestSetTokenRefresher(t *testing.T) {
+	var calls, refreshes int32
+	r := newMockRequest(bearerHandler("fresh", &calls)).SetTokenRefresher(func() (string, error) {
+		atomic.AddInt32(&refreshes, 1)
+		return "fresh", nil
+	})
+
+	result, err := r.Get("http://example.com").SetHeader("Authorization", "Bearer expired").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, int32(2), calls)
+	assert.Equal(t, int32(1), refreshes)
+
+	t.Run("retries once", func(t *testing.T) {
+		var calls, refreshes int32
+		r := newMockRequest(bearerHandler("never", &calls)).SetTokenRefresher(func() (string, error) {
+			atomic.AddInt32(&refreshes, 1)
+			return "wrong", nil
+		})
+
+		result, err := r.Get("http://example.com").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 401, result.StatusCode)
+		assert.Equal(t, int32(2), calls)
+		assert.Equal(t, int32(1), refreshes)
+	})
+}
+
+func TestOnUnauthorizedRefreshFailure(t *testing.T) {
+	var calls int32
+	refreshErr := errors.New("refresh token revoked")
+	r := newMockRequest(bearerHandler("fresh", &calls)).OnUnauthorized(func(ctx context.Context) (string, error) {
+		return "", refreshErr
+	})
+
+	result, err := r.Get("http://example.com").Execute()
+	assert.ErrorIs(t, err, refreshErr)
+	assert.Equal(t, 401, result.StatusCode)
+	assert.Equal(t, int32(1), calls)
+}
+
+func TestOnUnauthorizedRetriesOnce(t *testing.T) {
+	var calls, refreshes int32
+	r := newMockRequest(bearerHandler("never", &calls)).OnUnauthorized(func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&refreshes, 1)
+		return "still-invalid", nil
+	})
+
+	result, err := r.Get("http://example.com").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 401, result.StatusCode)
+	assert.Equal(t, int32(2), calls)
+	assert.Equal(t, int32(1), refreshes)
+}
+
+func TestOnUnauthorizedConcurrent(t *testing.T) {
+	var calls, refreshes int32
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	handler := bearerHandler("fresh", &calls)
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer fresh" {
+			//hold both expired requests so their 401s arrive together
+			arrived.Done()
+			arrived.Wait()
+		}
+		handler(w, req)
+	}).OnUnauthorized(func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&refreshes, 1)
+		time.Sleep(10 * time.Millisecond)
+		return "fresh", nil
+	})
+	r.AddHeader("Authorization", "Bearer expired")
+
+	var wg sync.WaitGroup
+	results := make([]*Response, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = r.New().Get("http://example.com").Execute()
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), refreshes)
+	assert.Equal(t, int32(4), calls)
+	for _, result := range results {
+		assert.Equal(t, 200, result.StatusCode)
+	}
+}
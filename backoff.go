@@ -0,0 +1,188 @@
+package request
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+//Jitter randomizes retry delays so many clients do not retry in lockstep
+type Jitter int
+
+const (
+	//NoJitter keeps delays as computed by the backoff strategy. This is the default
+	NoJitter Jitter = iota
+	//FullJitter picks a random delay between zero and the computed delay
+	FullJitter
+	//EqualJitter keeps half of the computed delay and picks the other half at random
+	EqualJitter
+)
+
+//Backoff computes how long to wait before a retry.
+//attempt is the number of attempts made so far, starting at 1
+type Backoff interface {
+	Next(attempt int) time.Duration
+}
+
+//ConstantBackoff waits the same delay before every retry
+type ConstantBackoff struct {
+	Delay time.Duration
+}
+
+//Next returns the constant delay
+func (b ConstantBackoff) Next(attempt int) time.Duration {
+	return b.Delay
+}
+
+//ExponentialBackoff doubles the delay on every retry, starting at Base
+type ExponentialBackoff struct {
+	Base time.Duration
+}
+
+//Next returns Base * 2^(attempt-1)
+func (b ExponentialBackoff) Next(attempt int) time.Duration {
+	return exponential(b.Base, attempt)
+}
+
+//FullJitterBackoff picks a random delay between zero and the exponential backoff, capped at Max.
+//Rand may be set for deterministic delays, otherwise the default source is used
+type FullJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+	Rand *rand.Rand
+}
+
+//Next returns a random delay in [0, min(Max, Base * 2^(attempt-1))]
+func (b FullJitterBackoff) Next(attempt int) time.Duration {
+	ceiling := exponential(b.Base, attempt)
+	if b.Max > 0 && ceiling > b.Max {
+		ceiling = b.Max
+	}
+
+	return randDuration(b.Rand, 0, ceiling)
+}
+
+//EqualJitterBackoff waits half of the exponential backoff, capped at Max, plus a random delay up to the other half.
+//Rand may be set for deterministic delays, otherwise the default source is used
+type EqualJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+	Rand *rand.Rand
+}
+
+//Next returns a random delay in [d/2, d] where d is min(Max, Base * 2^(attempt-1))
+func (b EqualJitterBackoff) Next(attempt int) time.Duration {
+	ceiling := exponential(b.Base, attempt)
+	if b.Max > 0 && ceiling > b.Max {
+		ceiling = b.Max
+	}
+
+	return applyJitter(EqualJitter, b.Rand, ceiling)
+}
+
+//DecorrelatedJitterBackoff picks a random delay between Base and three times the previous delay, capped at Max.
+//Rand may be set for deterministic delays, otherwise the default source is used
+type DecorrelatedJitterBackoff struct {
+	Base time.Duration
+	Max  time.Duration
+	Rand *rand.Rand
+
+	mu   sync.Mutex
+	prev time.Duration
+}
+
+//Next returns a random delay in [Base, min(Max, previous delay * 3)]. The first retry starts over from Base
+func (b *DecorrelatedJitterBackoff) Next(attempt int) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if attempt <= 1 || b.prev < b.Base {
+		b.prev = b.Base
+	}
+
+	ceiling := time.Duration(math.MaxInt64)
+	if b.prev <= math.MaxInt64/3 {
+		ceiling = b.prev * 3
+	}
+	if b.Max > 0 && ceiling > b.Max {
+		ceiling = b.Max
+	}
+
+	b.prev = randDuration(b.Rand, b.Base, ceiling)
+	return b.prev
+}
+
+//SetBackoff sets the strategy used to compute the delay between retries
+func (r *Request) SetBackoff(b Backoff) *Request {
+	if r == nil {
+		return nil
+	}
+	r.backoff = b
+	return r
+}
+
+//SetRetryJitter randomizes the delay computed by the backoff strategy, after SetMaxBackoff is applied.
+//Delays taken from a Retry-After header are not randomized. rnd may be set for deterministic delays,
+//otherwise the default source is used. A *rand.Rand is not safe for concurrent use,
+//so it should not be shared by requests executed concurrently
+func (r *Request) SetRetryJitter(jitter Jitter, rnd *rand.Rand) *Request {
+	if r == nil {
+		return nil
+	}
+	r.jitter = jitter
+	r.jitterRand = rnd
+	return r
+}
+
+//SetMaxBackoff caps the delay between retries computed by the backoff strategy
+func (r *Request) SetMaxBackoff(max time.Duration) *Request {
+	if r == nil {
+		return nil
+	}
+	r.maxBackoff = max
+	return r
+}
+
+//exponential returns base * 2^(attempt-1), saturating instead of overflowing
+func exponential(base time.Duration, attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if base <= 0 {
+		return 0
+	}
+	if attempt > 63 || base > math.MaxInt64>>(attempt-1) {
+		return math.MaxInt64
+	}
+
+	return base << (attempt - 1)
+}
+
+//applyJitter randomizes delay according to jitter
+func applyJitter(jitter Jitter, rnd *rand.Rand, delay time.Duration) time.Duration {
+	switch jitter {
+	case FullJitter:
+		return randDuration(rnd, 0, delay)
+	case EqualJitter:
+		half := delay / 2
+		return half + randDuration(rnd, 0, delay-half)
+	}
+	return delay
+}
+
+//randDuration returns a random duration in [min, max]
+func randDuration(rnd *rand.Rand, min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+
+	n := int64(max-min) + 1
+	if n <= 0 {
+		n = math.MaxInt64
+	}
+	if rnd == nil {
+		return min + time.Duration(rand.Int63n(n))
+	}
+	return min + time.Duration(rnd.Int63n(n))
+}
@@ -0,0 +1,152 @@
+package request
+
+import (
+	"math"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstantBackoff(t *testing.T) {
+	b := ConstantBackoff{Delay: time.Second}
+	for attempt := 1; attempt <= 5; attempt++ {
+		assert.Equal(t, time.Second, b.Next(attempt))
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{Base: 100 * time.Millisecond}
+
+	assert.Equal(t, 100*time.Millisecond, b.Next(1))
+	assert.Equal(t, 200*time.Millisecond, b.Next(2))
+	assert.Equal(t, 800*time.Millisecond, b.Next(4))
+	assert.Equal(t, time.Duration(math.MaxInt64), b.Next(100))
+}
+
+func TestFullJitterBackoff(t *testing.T) {
+	b := FullJitterBackoff{Base: 100 * time.Millisecond, Max: time.Second, Rand: rand.New(rand.NewSource(1))}
+	expected := rand.New(rand.NewSource(1))
+
+	assert.Equal(t, time.Duration(expected.Int63n(int64(100*time.Millisecond)+1)), b.Next(1))
+	assert.Equal(t, time.Duration(expected.Int63n(int64(400*time.Millisecond)+1)), b.Next(3))
+	assert.Equal(t, time.Duration(expected.Int63n(int64(time.Second)+1)), b.Next(10))
+
+	for attempt := 1; attempt <= 20; attempt++ {
+		delay := b.Next(attempt)
+		assert.GreaterOrEqual(t, int64(delay), int64(0))
+		assert.LessOrEqual(t, int64(delay), int64(time.Second))
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	b := &DecorrelatedJitterBackoff{Base: 100 * time.Millisecond, Max: time.Second, Rand: rand.New(rand.NewSource(1))}
+	expected := rand.New(rand.NewSource(1))
+
+	first := 100*time.Millisecond + time.Duration(expected.Int63n(int64(200*time.Millisecond)+1))
+	assert.Equal(t, first, b.Next(1))
+
+	//first is at most 300ms so the next ceiling stays under Max
+	second := 100*time.Millisecond + time.Duration(expected.Int63n(int64(first*3-100*time.Millisecond)+1))
+	assert.Equal(t, second, b.Next(2))
+
+	for attempt := 1; attempt <= 20; attempt++ {
+		delay := b.Next(attempt)
+		assert.GreaterOrEqual(t, int64(delay), int64(100*time.Millisecond))
+		assert.LessOrEqual(t, int64(delay), int64(time.Second))
+	}
+}
+
+type recordingBackoff struct {
+	attempts []int
+	delay    time.Duration
+}
+
+func (b *recordingBackoff) Next(attempt int) time.Duration {
+	b.attempts = append(b.attempts, attempt)
+	return b.delay
+}
+
+func TestSetBackoff(t *testing.T) {
+	t.Run("consulted for every sleep", func(t *testing.T) {
+		calls := 0
+		b := &recordingBackoff{}
+		r := newMockRequest(countingHandler(&calls, []int{500}, `{}`))
+
+		_, err := r.Get("http://example.com").SetRetry(3, time.Hour).SetBackoff(b).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 4, calls)
+		assert.Equal(t, []int{1, 2, 3}, b.attempts)
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		r := New().SetBackoff(&recordingBackoff{delay: time.Hour}).SetMaxBackoff(time.Second)
+		assert.Equal(t, time.Second, r.retryDelay(nil, 1))
+	})
+
+	t.Run("default exponential", func(t *testing.T) {
+		r := New().SetRetry(3, time.Second)
+		assert.Equal(t, 4*time.Second, r.retryDelay(nil, 3))
+	})
+}
+
+func TestEqualJitterBackoff(t *testing.T) {
+	b := EqualJitterBackoff{Base: 100 * time.Millisecond, Max: time.Second, Rand: rand.New(rand.NewSource(1))}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		ceiling := exponential(100*time.Millisecond, attempt)
+		if ceiling > time.Second {
+			ceiling = time.Second
+		}
+		delay := b.Next(attempt)
+		assert.GreaterOrEqual(t, int64(delay), int64(ceiling/2))
+		assert.LessOrEqual(t, int64(delay), int64(ceiling))
+	}
+}
+
+func TestSetRetryJitter(t *testing.T) {
+	t.Run("none by default", func(t *testing.T) {
+		r := New().SetRetry(3, time.Second)
+		assert.Equal(t, 4*time.Second, r.retryDelay(nil, 3))
+	})
+
+	t.Run("full", func(t *testing.T) {
+		r := New().SetRetry(3, time.Second).SetRetryJitter(FullJitter, rand.New(rand.NewSource(1)))
+		for i := 0; i < 50; i++ {
+			delay := r.retryDelay(nil, 3)
+			assert.GreaterOrEqual(t, int64(delay), int64(0))
+			assert.LessOrEqual(t, int64(delay), int64(4*time.Second))
+		}
+	})
+
+	t.Run("equal", func(t *testing.T) {
+		r := New().SetRetry(3, time.Second).SetRetryJitter(EqualJitter, rand.New(rand.NewSource(1)))
+		for i := 0; i < 50; i++ {
+			delay := r.retryDelay(nil, 3)
+			assert.GreaterOrEqual(t, int64(delay), int64(2*time.Second))
+			assert.LessOrEqual(t, int64(delay), int64(4*time.Second))
+		}
+	})
+
+	t.Run("applied after cap", func(t *testing.T) {
+		r := New().SetBackoff(&recordingBackoff{delay: time.Hour}).SetMaxBackoff(time.Second).
+			SetRetryJitter(EqualJitter, rand.New(rand.NewSource(1)))
+		delay := r.retryDelay(nil, 1)
+		assert.GreaterOrEqual(t, int64(delay), int64(500*time.Millisecond))
+		assert.LessOrEqual(t, int64(delay), int64(time.Second))
+	})
+
+	t.Run("deterministic with seeded source", func(t *testing.T) {
+		a := New().SetRetry(3, time.Second).SetRetryJitter(FullJitter, rand.New(rand.NewSource(7)))
+		b := New().SetRetry(3, time.Second).SetRetryJitter(FullJitter, rand.New(rand.NewSource(7)))
+		assert.Equal(t, a.retryDelay(nil, 2), b.retryDelay(nil, 2))
+	})
+
+	t.Run("retry after not jittered", func(t *testing.T) {
+		r := New().SetRetry(3, time.Second).SetRetryJitter(FullJitter, rand.New(rand.NewSource(1)))
+		resp := &Response{StatusCode: 429, Header: http.Header{"Retry-After": []string{"5"}}}
+		assert.Equal(t, 5*time.Second, r.retryDelay(resp, 1))
+	})
+}
@@ -0,0 +1,98 @@
+package request
+
+import (
+	"context"
+	"reflect"
+	"sync"
+)
+
+//BatchOption configures ExecuteAll
+type BatchOption func(*batchConfig)
+
+type batchConfig struct {
+	concurrency int
+	failFast    bool
+}
+
+//WithBatchConcurrency limits how many requests ExecuteAll runs at once. By default all requests run at once
+func WithBatchConcurrency(n int) BatchOption {
+	return func(c *batchConfig) {
+		c.concurrency = n
+	}
+}
+
+//StopOnFirstError makes ExecuteAll skip the remaining requests once one of them returns an error
+func StopOnFirstError() BatchOption {
+	return func(c *batchConfig) {
+		c.failFast = true
+	}
+}
+
+//ExecuteAll executes reqs concurrently and returns their responses and errors in the same order as reqs.
+//Every request is executed as a copy under ctx, with its own Success and Failure values allocated from the
+//types of the original ones so results never collide. Requests not started when ctx is done, or after the first
+//error when StopOnFirstError is set, are skipped and their error is the context error. Nil requests are not
+//executed and their error is ErrNilRequest
+func ExecuteAll(ctx context.Context, reqs []*Request, opts ...BatchOption) ([]*Response, []error) {
+	config := batchConfig{concurrency: len(reqs)}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.concurrency < 1 {
+		config.concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make([]*Response, len(reqs))
+	errs := make([]error, len(reqs))
+	slots := make(chan struct{}, config.concurrency)
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		if req == nil {
+			errs[i] = ErrNilRequest
+			if config.failFast {
+				cancel()
+			}
+			continue
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, req *Request) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			batched := req.New().SetContext(ctx)
+			batched.Success = newTarget(req.Success)
+			batched.Failure = newTarget(req.Failure)
+
+			responses[i], errs[i] = batched.Execute()
+			if errs[i] != nil && config.failFast {
+				cancel()
+			}
+		}(i, req)
+	}
+	wg.Wait()
+
+	return responses, errs
+}
+
+//newTarget allocates a new zero value of the type pointed to by v, nil if v is not a pointer
+func newTarget(v interface{}) interface{} {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return nil
+	}
+	return reflect.New(t.Elem()).Interface()
+}
@@ -0,0 +1,123 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//itemHandler serves {"ID":n} for /items/n, invalid JSON for ids divisible by 3,
+//and records the highest number of requests handled at once
+func itemHandler(inFlight, peak *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		current := atomic.AddInt32(inFlight, 1)
+		defer atomic.AddInt32(inFlight, -1)
+		for {
+			max := atomic.LoadInt32(peak)
+			if current <= max || atomic.CompareAndSwapInt32(peak, max, current) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		id, _ := strconv.Atoi(strings.TrimPrefix(req.URL.Path, "/items/"))
+		if id%3 == 0 {
+			w.Write([]byte(`{`))
+			return
+		}
+		fmt.Fprintf(w, `{"ID":%d}`, id)
+	}
+}
+
+func TestExecuteAll(t *testing.T) {
+	var inFlight, peak int32
+	base := newMockRequest(itemHandler(&inFlight, &peak)).SetSuccess(&fakeSuccess{})
+
+	var reqs []*Request
+	for i := 1; i <= 10; i++ {
+		reqs = append(reqs, base.New().Get(fmt.Sprintf("http://example.com/items/%d", i)))
+	}
+
+	responses, errs := ExecuteAll(context.Background(), reqs, WithBatchConcurrency(3))
+
+	assert.Equal(t, int32(3), peak)
+	for i, resp := range responses {
+		id := i + 1
+		if id%3 == 0 {
+			assert.True(t, IsDecodeError(errs[i]), "item %d", id)
+			continue
+		}
+		assert.Nil(t, errs[i], "item %d", id)
+		assert.Equal(t, id, resp.Success.(*fakeSuccess).ID)
+	}
+	assert.Equal(t, &fakeSuccess{}, base.Success)
+}
+
+func TestExecuteAllNilRequest(t *testing.T) {
+	var inFlight, peak int32
+	base := newMockRequest(itemHandler(&inFlight, &peak)).SetSuccess(&fakeSuccess{})
+	reqs := []*Request{base.New().Get("http://example.com/items/1"), nil, base.New().Get("http://example.com/items/2")}
+
+	responses, errs := ExecuteAll(context.Background(), reqs)
+	assert.Nil(t, responses[1])
+	assert.Equal(t, ErrNilRequest, errs[1])
+	assert.Nil(t, errs[0])
+	assert.Nil(t, errs[2])
+	assert.Equal(t, 2, responses[2].Success.(*fakeSuccess).ID)
+
+	_, errs = ExecuteAll(context.Background(), []*Request{nil, base.New().Get("http://example.com/items/1")}, StopOnFirstError())
+	assert.Equal(t, ErrNilRequest, errs[0])
+	assert.Equal(t, context.Canceled, errs[1])
+}
+
+func TestExecuteAllStopOnFirstError(t *testing.T) {
+	var inFlight, peak int32
+	base := newMockRequest(itemHandler(&inFlight, &peak))
+
+	var reqs []*Request
+	for i := 1; i <= 6; i++ {
+		reqs = append(reqs, base.New().Get(fmt.Sprintf("http://example.com/items/%d", i)).SetSuccess(&fakeSuccess{}))
+	}
+
+	responses, errs := ExecuteAll(context.Background(), reqs, WithBatchConcurrency(1), StopOnFirstError())
+
+	assert.Nil(t, errs[0])
+	assert.Nil(t, errs[1])
+	assert.True(t, IsDecodeError(errs[2]))
+	for i := 3; i < 6; i++ {
+		assert.Nil(t, responses[i])
+		assert.ErrorIs(t, errs[i], context.Canceled)
+	}
+}
+
+func TestExecuteAllCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var once sync.Once
+	base := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		once.Do(cancel)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	reqs := []*Request{
+		base.New().Get("http://example.com/1"),
+		base.New().Get("http://example.com/2"),
+		base.New().Get("http://example.com/3"),
+	}
+
+	responses, errs := ExecuteAll(ctx, reqs, WithBatchConcurrency(1))
+
+	assert.Nil(t, errs[0])
+	assert.Equal(t, 200, responses[0].StatusCode)
+	for i := 1; i < 3; i++ {
+		assert.Nil(t, responses[i])
+		assert.ErrorIs(t, errs[i], context.Canceled)
+	}
+}
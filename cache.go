@@ -0,0 +1,288 @@
+package request
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+//EnableCache caches successful GET and HEAD responses in memory, keeping at most maxEntries of them.
+//Responses stay fresh as long as their Cache-Control, Expires and Age headers allow, or for ttl when they
+//have no explicit lifetime. no-store responses and responses varying on * are not stored and no-cache ones
+//are revalidated before every use, see ResponseCache.SetShared for caches serving several users. Responses
+//are keyed by method, URL, the Accept and Authorization headers and the headers named by their Vary header.
+//Once expired, responses carrying an ETag or a Last-Modified date are revalidated with If-None-Match or
+//If-Modified-Since, so a zero ttl revalidates them on every request. The cache is shared with every request
+//derived from this one via New. See NoCache to bypass it
+func (r *Request) EnableCache(ttl time.Duration, maxEntries int) *Request {
+	return r.SetCache(NewResponseCache(ttl, maxEntries))
+}
+
+//SetCache caches responses in cache, as EnableCache does, so independently built requests can share cached
+//responses by sharing a cache. A nil cache disables caching
+func (r *Request) SetCache(cache *ResponseCache) *Request {
+	if r == nil {
+		return nil
+	}
+	r.cache = cache
+	return r
+}
+
+//NoCache makes the request skip cached responses and always go to the network.
+//A successful response still replaces the cached one
+func (r *Request) NoCache() *Request {
+	if r == nil {
+		return nil
+	}
+	r.noCache = true
+	return r
+}
+
+//ResponseCache is an in-memory cache of 2xx GET and HEAD responses. Entries expire as their Cache-Control
+//and Expires headers direct, or after the ttl, and the least recently used entry is evicted once the cache is
+//full. Expired entries carrying an ETag or a Last-Modified date are kept to revalidate them: a 304 Not Modified
+//response serves the cached response again. Concurrent misses for the same key share a single network call.
+//It is safe for concurrent use
+type ResponseCache struct {
+	ttl        time.Duration
+	maxEntries int
+	keyHeaders []string
+	shared     bool
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	flight  FlightGroup
+	//vary holds the request headers the responses cached for a primary key vary on
+	vary map[string]*variants
+}
+
+//cacheEntry is a cached response and the time it expires
+type cacheEntry struct {
+	key     string
+	primary string
+	resp    *Response
+	expires time.Time
+}
+
+//variants are the request headers named by the Vary header of the responses cached for a primary key,
+//and the number of those responses
+type variants struct {
+	headers []string
+	entries int
+}
+
+//NewResponseCache returns a cache keeping at most maxEntries responses, fresh for ttl each unless their headers
+//direct otherwise, see EnableCache. Responses are keyed by
+//method, URL and the values of keyHeaders, which default to Accept and Authorization. Header values are hashed
+//so credentials are not kept in the keys. A maxEntries of zero or less does not bound the number of entries
+func NewResponseCache(ttl time.Duration, maxEntries int, keyHeaders ...string) *ResponseCache {
+	if len(keyHeaders) == 0 {
+		keyHeaders = []string{"Accept", "Authorization"}
+	}
+	return &ResponseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		keyHeaders: keyHeaders,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		vary:       make(map[string]*variants),
+	}
+}
+
+//SetShared makes the cache behave as a shared cache, as RFC 9111 defines it, for caches serving several users:
+//responses marked private are not stored, s-maxage takes precedence over max-age and responses to requests
+//with an Authorization header are only stored when marked public, must-revalidate or s-maxage. Caches are
+//private by default. It must be called before the cache is used
+func (c *ResponseCache) SetShared(shared bool) *ResponseCache {
+	c.shared = shared
+	return c
+}
+
+//Len returns the number of cached responses, including expired ones not yet evicted or kept for revalidation
+func (c *ResponseCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+//Clear removes every cached response
+func (c *ResponseCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*list.Element)
+	c.vary = make(map[string]*variants)
+	c.lru.Init()
+}
+
+//key identifies a request by its primary key and the headers its cached responses vary on
+func (c *ResponseCache) key(req *http.Request) string {
+	primary := c.primaryKey(req)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if v, ok := c.vary[primary]; ok {
+		return primary + headerHashes(req, v.headers)
+	}
+	return primary
+}
+
+//primaryKey identifies a request by its method, URL and the key headers
+func (c *ResponseCache) primaryKey(req *http.Request) string {
+	return req.Method + " " + req.URL.String() + headerHashes(req, c.keyHeaders)
+}
+
+//headerHashes returns a line with the hashed values of each of the named headers of req
+func headerHashes(req *http.Request, names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		values := req.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+		sum := sha256.Sum256([]byte(strings.Join(values, ", ")))
+		b.WriteString("\n")
+		b.WriteString(http.CanonicalHeaderKey(name))
+		b.WriteString(": ")
+		b.WriteString(hex.EncodeToString(sum[:]))
+	}
+	return b.String()
+}
+
+//get returns a copy of the response cached under key, if any, and whether it is still fresh. Fresh responses
+//are marked as recently used. Expired responses are evicted unless they carry a validator to revalidate them with
+func (c *ResponseCache) get(key string, now time.Time) (*Response, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if now.Before(entry.expires) {
+		c.lru.MoveToFront(elem)
+		return copyResponse(entry.resp), true
+	}
+	if !hasValidator(entry.resp) {
+		c.remove(elem)
+		return nil, false
+	}
+	return copyResponse(entry.resp), false
+}
+
+//put caches the response to req for as long as it is fresh, under a key including the headers it varies on,
+//and evicts the least recently used entry when the cache is full. The response looked up under key is evicted
+//when the new one is stored under another key, because its Vary header changed, or when the new one may not be
+//stored or is already stale and cannot be revalidated
+func (c *ResponseCache) put(key string, req *http.Request, resp *Response, now time.Time) {
+	primary := c.primaryKey(req)
+	vary := varyHeaders(resp.Header)
+	variant := primary + headerHashes(req, vary)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok && key != variant {
+		c.remove(elem)
+	}
+	ttl, storable := c.freshness(req, resp.Header, now)
+	if !storable || (ttl <= 0 && !hasValidator(resp)) {
+		if elem, ok := c.entries[variant]; ok {
+			c.remove(elem)
+		}
+		return
+	}
+
+	entry := &cacheEntry{key: variant, primary: primary, resp: copyResponse(resp), expires: now.Add(ttl)}
+	entry.resp.FromCache, entry.resp.Revalidated = false, false
+	if elem, ok := c.entries[variant]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+	} else {
+		c.entries[variant] = c.lru.PushFront(entry)
+		c.addVariant(primary)
+	}
+	c.vary[primary].headers = vary
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *ResponseCache) remove(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	c.lru.Remove(elem)
+	delete(c.entries, entry.key)
+	if v := c.vary[entry.primary]; v != nil {
+		if v.entries--; v.entries <= 0 {
+			delete(c.vary, entry.primary)
+		}
+	}
+}
+
+func (c *ResponseCache) addVariant(primary string) {
+	if v, ok := c.vary[primary]; ok {
+		v.entries++
+		return
+	}
+	c.vary[primary] = &variants{entries: 1}
+}
+
+//canCache reports whether the response to req may be served from and stored in the cache
+func (r *Request) canCache(req *http.Request) bool {
+	return r.cache != nil && (req.Method == http.MethodGet || req.Method == http.MethodHead)
+}
+
+//cached serves req from the cache, unless bypassed, and otherwise calls fetch once for all concurrent misses,
+//caching a complete 2xx response. An expired response is revalidated with its validators and served again on a 304
+func (r *Request) cached(req *http.Request, fetch func() (*Response, error)) (*Response, error) {
+	c := r.cache
+	key := c.key(req)
+	var stale *Response
+	if !r.noCache {
+		resp, fresh := c.get(key, r.now())
+		if fresh {
+			resp.FromCache = true
+			return resp, nil
+		}
+		stale = resp
+	}
+
+	resp, err := c.flight.do(key, func() (*Response, error) {
+		notModified := addConditions(req, stale)
+
+		resp, err := fetch()
+		if err != nil {
+			return resp, err
+		}
+		if notModified != nil && notModified(resp) {
+			resp = revalidated(stale, resp)
+			c.put(key, req, resp, r.now())
+		} else if !resp.Truncated && 200 <= resp.StatusCode && resp.StatusCode <= 299 {
+			c.put(key, req, resp, r.now())
+		}
+		return resp, nil
+	})
+	if err != nil {
+		return resp, err
+	}
+	return copyResponse(resp), nil
+}
+
+//revalidated returns the stored response confirmed by a 304, with its header updated from the 304 as
+//RFC 9111 section 4.3.4 requires, apart from the Content-Length describing the empty 304 body
+func revalidated(stored, notModified *Response) *Response {
+	resp := copyResponse(stored)
+	for key, values := range notModified.Header {
+		if key != "Content-Length" {
+			resp.Header[key] = values
+		}
+	}
+	resp.Endpoint = notModified.Endpoint
+	resp.Hedged = notModified.Hedged
+	resp.Revalidated = true
+	return resp
+}
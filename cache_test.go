@@ -0,0 +1,164 @@
+package request
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableCache(t *testing.T) {
+	var calls int32
+	newTemplate := func() *Request {
+		atomic.StoreInt32(&calls, 0)
+		return newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			if req.URL.Path == "/missing" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("X-Path", req.URL.Path)
+			w.Write([]byte(`{"ID":1,"Name":"John"}`))
+		})
+	}
+
+	t.Run("hit", func(t *testing.T) {
+		template := newTemplate().EnableCache(time.Minute, 10)
+
+		first, err := template.New().Get("http://example.com/config").Execute()
+		assert.Nil(t, err)
+		assert.False(t, first.FromCache)
+
+		success := &fakeSuccess{}
+		second, err := template.New().Get("http://example.com/config").SetSuccess(success).Execute()
+		assert.Nil(t, err)
+		assert.True(t, second.FromCache)
+		assert.Equal(t, "/config", second.Header.Get("X-Path"))
+		assert.Same(t, success, second.Success)
+		assert.Equal(t, &fakeSuccess{ID: 1, Name: "John"}, success)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("miss", func(t *testing.T) {
+		template := newTemplate().EnableCache(time.Minute, 10)
+
+		template.New().Get("http://example.com/config").Execute()
+		template.New().Get("http://example.com/config?v=2").Execute()
+		template.New().Get("http://example.com/config").SetHeader("Accept", "text/plain").Execute()
+		template.New().Get("http://example.com/config").SetHeader("Authorization", "Bearer a").Execute()
+		template.New().Get("http://example.com/config").SetHeader("Authorization", "Bearer b").Execute()
+		template.New().Head("http://example.com/config").Execute()
+		assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+		assert.Equal(t, 6, template.cache.Len())
+		for key := range template.cache.entries {
+			assert.NotContains(t, key, "Bearer")
+		}
+	})
+
+	t.Run("only 2xx GET and HEAD", func(t *testing.T) {
+		template := newTemplate().EnableCache(time.Minute, 10)
+
+		for i := 0; i < 2; i++ {
+			resp, err := template.New().Get("http://example.com/missing").Execute()
+			assert.Nil(t, err)
+			assert.False(t, resp.FromCache)
+			resp, err = template.New().Put("http://example.com/config").Execute()
+			assert.Nil(t, err)
+			assert.False(t, resp.FromCache)
+		}
+		assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+		assert.Equal(t, 0, template.cache.Len())
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		clock := newFakeClock()
+		template := newTemplate().EnableCache(time.Minute, 10).SetClock(clock)
+
+		template.New().Get("http://example.com/config").Execute()
+		clock.Advance(59 * time.Second)
+		resp, _ := template.New().Get("http://example.com/config").Execute()
+		assert.True(t, resp.FromCache)
+
+		clock.Advance(time.Second)
+		resp, _ = template.New().Get("http://example.com/config").Execute()
+		assert.False(t, resp.FromCache)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("eviction", func(t *testing.T) {
+		template := newTemplate().EnableCache(time.Minute, 2)
+
+		template.New().Get("http://example.com/a").Execute()
+		template.New().Get("http://example.com/b").Execute()
+		//a is now the most recently used, so b is evicted for c
+		resp, _ := template.New().Get("http://example.com/a").Execute()
+		assert.True(t, resp.FromCache)
+		template.New().Get("http://example.com/c").Execute()
+		assert.Equal(t, 2, template.cache.Len())
+
+		resp, _ = template.New().Get("http://example.com/a").Execute()
+		assert.True(t, resp.FromCache)
+		resp, _ = template.New().Get("http://example.com/b").Execute()
+		assert.False(t, resp.FromCache)
+		assert.Equal(t, int32(4), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("bypass", func(t *testing.T) {
+		template := newTemplate().EnableCache(time.Minute, 10)
+
+		template.New().Get("http://example.com/config").Execute()
+		resp, err := template.New().Get("http://example.com/config").NoCache().Execute()
+		assert.Nil(t, err)
+		assert.False(t, resp.FromCache)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+		resp, _ = template.New().Get("http://example.com/config").Execute()
+		assert.True(t, resp.FromCache)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("shared through SetCache", func(t *testing.T) {
+		cache := NewResponseCache(time.Minute, 10)
+		newTemplate().SetCache(cache).Get("http://example.com/config").Execute()
+		resp, _ := newTemplate().SetCache(cache).Get("http://example.com/config").Execute()
+		assert.True(t, resp.FromCache)
+
+		cache.Clear()
+		assert.Equal(t, 0, cache.Len())
+	})
+}
+
+func TestCacheStampede(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	template := newMockRequest(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte(`{"ID":1,"Name":"John"}`))
+	})
+	template.Get("http://example.com/config").EnableCache(time.Minute, 10)
+
+	results := make([]*fakeSuccess, 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = &fakeSuccess{}
+			_, err := template.New().SetSuccess(results[i]).Execute()
+			assert.Nil(t, err)
+		}(i)
+	}
+
+	waitForDups(t, &template.cache.flight, 9)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, result := range results {
+		assert.Equal(t, &fakeSuccess{ID: 1, Name: "John"}, result)
+	}
+}
@@ -0,0 +1,135 @@
+package request
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//parseCacheControl returns the directives of Cache-Control field values by lowercase name, with their
+//unquoted argument if any
+func parseCacheControl(values []string) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range values {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			if name = strings.ToLower(strings.TrimSpace(name)); name == "" {
+				continue
+			}
+			if _, ok := directives[name]; !ok {
+				directives[name] = strings.Trim(strings.TrimSpace(arg), `"`)
+			}
+		}
+	}
+	return directives
+}
+
+//varyHeaders returns the canonical, sorted and deduplicated request header names listed in the Vary headers of
+//a response. A Vary of * is left to freshness, which does not store such responses
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" && name != "*" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	sort.Strings(names)
+
+	unique := names[:0]
+	for i, name := range names {
+		if i == 0 || name != names[i-1] {
+			unique = append(unique, name)
+		}
+	}
+	if len(unique) == 0 {
+		return nil
+	}
+	return unique
+}
+
+//freshness returns how long the response to req with header stays fresh from now, the time it was received,
+//and whether it may be stored at all, following RFC 9111.
+//
+//no-store responses and responses varying on * may not be stored. Neither may, in a shared cache, private
+//responses and responses to requests with an Authorization header not marked public, must-revalidate or s-maxage.
+//Qualified no-cache and private directives are treated as unqualified.
+//no-cache responses are stale right away so they are revalidated before every use, like max-age=0 ones.
+//
+//The freshness lifetime is s-maxage in a shared cache, then max-age, then Expires relative to the Date header,
+//then the ttl of the cache. Invalid values make the response stale, as section 5.3 requires for Expires.
+//The age of the response when received, from its Age header or the time elapsed since its Date, is deducted
+func (c *ResponseCache) freshness(req *http.Request, header http.Header, now time.Time) (time.Duration, bool) {
+	directives := parseCacheControl(header.Values("Cache-Control"))
+	has := func(name string) bool {
+		_, ok := directives[name]
+		return ok
+	}
+
+	if has("no-store") {
+		return 0, false
+	}
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if strings.TrimSpace(name) == "*" {
+				return 0, false
+			}
+		}
+	}
+	if c.shared && has("private") {
+		return 0, false
+	}
+	if c.shared && req.Header.Get("Authorization") != "" && !has("public") && !has("must-revalidate") && !has("s-maxage") {
+		return 0, false
+	}
+	if has("no-cache") {
+		return 0, true
+	}
+
+	date, dateErr := http.ParseTime(header.Get("Date"))
+	lifetime, ok := c.ttl, true
+	if arg, shared := directives["s-maxage"]; shared && c.shared {
+		lifetime, ok = deltaSeconds(arg)
+	} else if arg, found := directives["max-age"]; found {
+		lifetime, ok = deltaSeconds(arg)
+	} else if values := header.Values("Expires"); len(values) > 0 {
+		expires, err := http.ParseTime(values[0])
+		base := now
+		if dateErr == nil {
+			base = date
+		}
+		lifetime, ok = expires.Sub(base), err == nil
+	}
+	if !ok {
+		return 0, true
+	}
+
+	//section 4.2.3, without the response delay which is not known here
+	var age time.Duration
+	if dateErr == nil && now.After(date) {
+		age = now.Sub(date)
+	}
+	if values := header.Values("Age"); len(values) > 0 {
+		//a list is invalid but its first member is used, section 5.1
+		first, _, _ := strings.Cut(values[0], ",")
+		if corrected, ok := deltaSeconds(strings.TrimSpace(first)); ok && corrected > age {
+			age = corrected
+		}
+	}
+	return lifetime - age, true
+}
+
+//deltaSeconds parses a delta-seconds value of RFC 9111 section 1.2.2
+func deltaSeconds(arg string) (time.Duration, bool) {
+	seconds, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	if seconds > 1<<31 {
+		seconds = 1 << 31
+	}
+	return time.Duration(seconds) * time.Second, true
+}
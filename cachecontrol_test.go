@@ -0,0 +1,222 @@
+package request
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AidenHadisi/go-simple-request/requesttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreshness(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		header        http.Header
+		shared        bool
+		authorization bool
+		ttl           time.Duration
+		storable      bool
+	}{
+		{name: "default ttl", header: http.Header{}, ttl: time.Minute, storable: true},
+		{name: "max-age", header: http.Header{"Cache-Control": {"public, max-age=300"}}, ttl: 5 * time.Minute, storable: true},
+		{name: "quoted max-age", header: http.Header{"Cache-Control": {`max-age="60"`}}, ttl: time.Minute, storable: true},
+		{name: "directives are case insensitive", header: http.Header{"Cache-Control": {"Max-Age=60"}}, ttl: time.Minute, storable: true},
+		{name: "first duplicate wins", header: http.Header{"Cache-Control": {"max-age=60, max-age=300"}}, ttl: time.Minute, storable: true},
+		{name: "max-age over Expires", header: http.Header{"Cache-Control": {"max-age=30"}, "Expires": {"Tue, 01 Jun 2021 13:00:00 GMT"}}, ttl: 30 * time.Second, storable: true},
+		{name: "invalid max-age", header: http.Header{"Cache-Control": {"max-age=soon"}}, storable: true},
+		{name: "negative max-age", header: http.Header{"Cache-Control": {"max-age=-1"}}, storable: true},
+		{name: "Expires", header: http.Header{"Expires": {"Tue, 01 Jun 2021 12:10:00 GMT"}}, ttl: 10 * time.Minute, storable: true},
+		{name: "Expires relative to Date", header: http.Header{"Expires": {"Tue, 01 Jun 2021 12:10:00 GMT"}, "Date": {"Tue, 01 Jun 2021 12:05:00 GMT"}}, ttl: 5 * time.Minute, storable: true},
+		{name: "past Expires", header: http.Header{"Expires": {"Tue, 01 Jun 2021 11:00:00 GMT"}}, ttl: -time.Hour, storable: true},
+		{name: "invalid Expires", header: http.Header{"Expires": {"0"}}, storable: true},
+
+		{name: "max-age=0 is stored stale", header: http.Header{"Cache-Control": {"max-age=0"}}, storable: true},
+		{name: "no-cache is stored stale", header: http.Header{"Cache-Control": {"no-cache"}}, storable: true},
+		{name: "no-cache wins over max-age", header: http.Header{"Cache-Control": {"no-cache, max-age=300"}}, storable: true},
+		{name: "qualified no-cache", header: http.Header{"Cache-Control": {`no-cache="Set-Cookie", max-age=300`}}, storable: true},
+		{name: "no-store", header: http.Header{"Cache-Control": {"max-age=300", "NO-STORE"}}},
+		{name: "no-store wins over public", header: http.Header{"Cache-Control": {"public, no-store"}}, shared: true},
+
+		{name: "private in a private cache", header: http.Header{"Cache-Control": {"private, max-age=300"}}, ttl: 5 * time.Minute, storable: true},
+		{name: "private in a shared cache", header: http.Header{"Cache-Control": {"private, max-age=300"}}, shared: true},
+		{name: "qualified private in a shared cache", header: http.Header{"Cache-Control": {`private="Set-Cookie"`}}, shared: true},
+		{name: "s-maxage in a shared cache", header: http.Header{"Cache-Control": {"max-age=60, s-maxage=600"}}, shared: true, ttl: 10 * time.Minute, storable: true},
+		{name: "s-maxage in a private cache", header: http.Header{"Cache-Control": {"max-age=60, s-maxage=600"}}, ttl: time.Minute, storable: true},
+		{name: "s-maxage=0 in a shared cache", header: http.Header{"Cache-Control": {"max-age=60, s-maxage=0"}}, shared: true, storable: true},
+
+		{name: "authorized in a private cache", header: http.Header{}, authorization: true, ttl: time.Minute, storable: true},
+		{name: "authorized in a shared cache", header: http.Header{"Cache-Control": {"max-age=60"}}, shared: true, authorization: true},
+		{name: "authorized and public in a shared cache", header: http.Header{"Cache-Control": {"public, max-age=60"}}, shared: true, authorization: true, ttl: time.Minute, storable: true},
+		{name: "authorized and must-revalidate in a shared cache", header: http.Header{"Cache-Control": {"must-revalidate, max-age=60"}}, shared: true, authorization: true, ttl: time.Minute, storable: true},
+		{name: "authorized and s-maxage in a shared cache", header: http.Header{"Cache-Control": {"s-maxage=60"}}, shared: true, authorization: true, ttl: time.Minute, storable: true},
+
+		{name: "Vary", header: http.Header{"Vary": {"Accept-Encoding"}}, ttl: time.Minute, storable: true},
+		{name: "Vary *", header: http.Header{"Vary": {"Accept-Encoding, *"}, "Cache-Control": {"max-age=300"}}},
+
+		{name: "Age", header: http.Header{"Cache-Control": {"max-age=300"}, "Age": {"100"}}, ttl: 200 * time.Second, storable: true},
+		{name: "Age beyond max-age", header: http.Header{"Cache-Control": {"max-age=300"}, "Age": {"400"}}, ttl: -100 * time.Second, storable: true},
+		{name: "Age list", header: http.Header{"Cache-Control": {"max-age=300"}, "Age": {"100, 200"}}, ttl: 200 * time.Second, storable: true},
+		{name: "invalid Age", header: http.Header{"Cache-Control": {"max-age=300"}, "Age": {"old"}}, ttl: 5 * time.Minute, storable: true},
+		{name: "age from Date", header: http.Header{"Cache-Control": {"max-age=300"}, "Date": {"Tue, 01 Jun 2021 11:59:00 GMT"}}, ttl: 4 * time.Minute, storable: true},
+		{name: "larger of Age and Date", header: http.Header{"Cache-Control": {"max-age=300"}, "Date": {"Tue, 01 Jun 2021 11:59:00 GMT"}, "Age": {"120"}}, ttl: 3 * time.Minute, storable: true},
+		{name: "Date in the future", header: http.Header{"Cache-Control": {"max-age=300"}, "Date": {"Tue, 01 Jun 2021 12:01:00 GMT"}}, ttl: 5 * time.Minute, storable: true},
+		{name: "Age with the default ttl", header: http.Header{"Age": {"20"}}, ttl: 40 * time.Second, storable: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+			if test.authorization {
+				req.Header.Set("Authorization", "Bearer token")
+			}
+			cache := NewResponseCache(time.Minute, 10).SetShared(test.shared)
+
+			ttl, storable := cache.freshness(req, test.header, now)
+			assert.Equal(t, test.storable, storable)
+			if storable {
+				assert.Equal(t, test.ttl, ttl)
+			}
+		})
+	}
+}
+
+func TestVaryHeaders(t *testing.T) {
+	assert.Nil(t, varyHeaders(http.Header{}))
+	assert.Nil(t, varyHeaders(http.Header{"Vary": {"*"}}))
+	assert.Equal(t, []string{"Accept-Encoding", "Accept-Language"},
+		varyHeaders(http.Header{"Vary": {"accept-language, Accept-Encoding", "ACCEPT-LANGUAGE"}}))
+}
+
+func TestCacheControl(t *testing.T) {
+	var calls int32
+	newTemplate := func(header http.Header) (*Request, *requesttest.FakeClock) {
+		atomic.StoreInt32(&calls, 0)
+		clock := newFakeClock()
+		r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.Write([]byte(`{"ID":1}`))
+		})
+		return r.EnableCache(time.Hour, 10).SetClock(clock), clock
+	}
+	get := func(template *Request) *Response {
+		resp, err := template.New().Get("http://example.com/config").Execute()
+		assert.Nil(t, err)
+		return resp
+	}
+
+	t.Run("max-age", func(t *testing.T) {
+		template, clock := newTemplate(http.Header{"Cache-Control": {"max-age=60"}})
+
+		get(template)
+		clock.Advance(59 * time.Second)
+		assert.True(t, get(template).FromCache)
+		clock.Advance(time.Second)
+		assert.False(t, get(template).FromCache)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("Expires", func(t *testing.T) {
+		expires := newFakeClock().Now().Add(time.Minute).Format(http.TimeFormat)
+		template, clock := newTemplate(http.Header{"Expires": {expires}})
+
+		get(template)
+		clock.Advance(30 * time.Second)
+		assert.True(t, get(template).FromCache)
+		clock.Advance(30 * time.Second)
+		assert.False(t, get(template).FromCache)
+	})
+
+	t.Run("expired entry", func(t *testing.T) {
+		expires := newFakeClock().Now().Add(-time.Minute).Format(http.TimeFormat)
+		template, _ := newTemplate(http.Header{"Expires": {expires}})
+
+		get(template)
+		assert.False(t, get(template).FromCache)
+		assert.Equal(t, 0, template.cache.Len())
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("no-store", func(t *testing.T) {
+		template, _ := newTemplate(http.Header{"Cache-Control": {"no-store"}})
+
+		get(template)
+		assert.False(t, get(template).FromCache)
+		assert.Equal(t, 0, template.cache.Len())
+	})
+
+	t.Run("no-store evicts the cached response", func(t *testing.T) {
+		noStore := false
+		template := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			if noStore {
+				w.Header().Set("Cache-Control", "no-store")
+			}
+		}).EnableCache(time.Hour, 10)
+
+		get(template)
+		assert.Equal(t, 1, template.cache.Len())
+		noStore = true
+		template.New().Get("http://example.com/config").NoCache().Execute()
+		assert.Equal(t, 0, template.cache.Len())
+	})
+
+	t.Run("private", func(t *testing.T) {
+		template, _ := newTemplate(http.Header{"Cache-Control": {"private"}})
+
+		get(template)
+		assert.True(t, get(template).FromCache)
+
+		template.SetCache(NewResponseCache(time.Hour, 10).SetShared(true))
+		get(template)
+		assert.False(t, get(template).FromCache)
+	})
+
+	t.Run("no-cache is revalidated", func(t *testing.T) {
+		template, _ := newTemplate(http.Header{"Cache-Control": {"no-cache"}, "Etag": {`"v1"`}})
+
+		get(template)
+		assert.Equal(t, 1, template.cache.Len())
+		resp := get(template)
+		assert.False(t, resp.FromCache)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestCacheVary(t *testing.T) {
+	var calls int32
+	template := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Vary", "Accept-Language")
+		if req.URL.Path == "/any" {
+			w.Header().Set("Vary", "*")
+		}
+		w.Write([]byte(req.Header.Get("Accept-Language")))
+	}).EnableCache(time.Hour, 10)
+	get := func(path, lang string) *Response {
+		resp, err := template.New().Get("http://example.com" + path).SetAcceptLanguage(lang).Execute()
+		assert.Nil(t, err)
+		return resp
+	}
+
+	assert.False(t, get("/greeting", "en").FromCache)
+	assert.False(t, get("/greeting", "fr").FromCache)
+	en, fr := get("/greeting", "en"), get("/greeting", "fr")
+	assert.True(t, en.FromCache)
+	assert.Equal(t, "en", string(en.Body))
+	assert.True(t, fr.FromCache)
+	assert.Equal(t, "fr", string(fr.Body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	assert.Equal(t, 2, template.cache.Len())
+
+	get("/any", "en")
+	assert.False(t, get("/any", "en").FromCache)
+	assert.Equal(t, 2, template.cache.Len())
+
+	template.cache.Clear()
+	assert.Empty(t, template.cache.vary)
+}
@@ -0,0 +1,61 @@
+package request
+
+import (
+	"context"
+	"time"
+)
+
+//Clock abstracts time so retries, backoff, Retry-After handling, hedging, failover and rate limiting
+//can be tested deterministically. See the requesttest package for a fake implementation
+type Clock interface {
+	//Now returns the current time
+	Now() time.Time
+	//After returns a channel receiving the current time once d has elapsed
+	After(d time.Duration) <-chan time.Time
+}
+
+//SetClock sets the clock used for every time dependent behavior of the request.
+//Rate limits set with SetRateLimit use the clock set when they are created
+func (r *Request) SetClock(c Clock) *Request {
+	if r == nil {
+		return nil
+	}
+	r.clock = c
+	return r
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (r *Request) now() time.Time {
+	return r.getClock().Now()
+}
+
+func (r *Request) getClock() Clock {
+	if r.clock == nil {
+		return realClock{}
+	}
+	return r.clock
+}
+
+type clockKey struct{}
+
+//withClock returns ctx carrying the clock of the request, so middlewares time requests with it
+func (r *Request) withClock(ctx context.Context) context.Context {
+	return context.WithValue(ctx, clockKey{}, r.getClock())
+}
+
+//clockOf returns the clock carried by ctx, or the real clock for requests not built by this package
+func clockOf(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockKey{}).(Clock); ok {
+		return c
+	}
+	return realClock{}
+}
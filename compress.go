@@ -0,0 +1,57 @@
+package request
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+//SetCompressionThreshold gzips request bodies larger than n bytes and sets the Content-Encoding header,
+//sending smaller bodies uncompressed without it. Only bodies whose size is known up front, such as marshaled
+//JSON and byte or string readers, are compressed; streamed bodies, including files set with SetBodyFromFile,
+//are sent as is. A negative n disables compression
+func (r *Request) SetCompressionThreshold(n int) *Request {
+	if r == nil {
+		return nil
+	}
+	r.compress = n >= 0
+	r.compressAbove = int64(n)
+	return r
+}
+
+//compressBody replaces the body of req with its gzipped bytes when it exceeds the compression threshold
+func (r *Request) compressBody(req *http.Request) error {
+	if !r.compress || req.GetBody == nil || req.ContentLength <= r.compressAbove {
+		return nil
+	}
+	if _, ok := r.body.(fileBody); ok {
+		return nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.Copy(zw, body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	compressed := buf.Bytes()
+	req.Body.Close()
+	req.Body = ioutil.NopCloser(bytes.NewReader(compressed))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(compressed)), nil
+	}
+	req.ContentLength = int64(len(compressed))
+	req.Header.Set("Content-Encoding", "gzip")
+	return nil
+}
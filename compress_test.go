@@ -0,0 +1,123 @@
+package request
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetCompressionThreshold(t *testing.T) {
+	var encoding string
+	var received string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		body := r.Body
+		if encoding == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = zr
+		}
+		data, _ := ioutil.ReadAll(body)
+		received = string(data)
+	}
+
+	t.Run("small body", func(t *testing.T) {
+		_, err := newMockRequest(handler).Post("http://example.com").
+			SetBody(&fakeSuccess{Name: "John"}).
+			SetCompressionThreshold(1024).
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "", encoding)
+		assert.Equal(t, `{"ID":0,"Name":"John"}`, received)
+	})
+
+	t.Run("large body", func(t *testing.T) {
+		name := strings.Repeat("a", 2048)
+		req, err := New().Post("http://example.com").
+			SetBody(&fakeSuccess{Name: name}).
+			SetCompressionThreshold(1024).
+			Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "gzip", req.Header.Get("Content-Encoding"))
+		assert.Less(t, req.ContentLength, int64(1024))
+
+		_, err = newMockRequest(handler).Post("http://example.com").
+			SetBody(&fakeSuccess{Name: name}).
+			SetCompressionThreshold(1024).
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "gzip", encoding)
+		assert.Equal(t, `{"ID":0,"Name":"`+name+`"}`, received)
+	})
+
+	t.Run("retried", func(t *testing.T) {
+		var bodies []string
+		calls := 0
+		statuses := countingHandler(&calls, []int{503, 200}, `{}`)
+		r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			zr, err := gzip.NewReader(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ := ioutil.ReadAll(zr)
+			bodies = append(bodies, string(data))
+			statuses(w, req)
+		})
+		_, err := r.Put("http://example.com").SetBody(&fakeSuccess{Name: "John"}).
+			SetCompressionThreshold(0).SetRetry(1, 0).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{`{"ID":0,"Name":"John"}`, `{"ID":0,"Name":"John"}`}, bodies)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		req, err := New().Post("http://example.com").SetBody(&fakeSuccess{}).
+			SetCompressionThreshold(0).SetCompressionThreshold(-1).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "", req.Header.Get("Content-Encoding"))
+	})
+}
+
+func TestCompressionSkipsFiles(t *testing.T) {
+	var encoding string
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding = r.Header.Get("Content-Encoding")
+		data, _ := ioutil.ReadAll(r.Body)
+		received = len(data)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "body.txt")
+	if err := os.WriteFile(path, []byte(strings.Repeat("a", 4096)), 0600); err != nil {
+		t.Fatal(err)
+	}
+	openFiles := func() int {
+		entries, err := os.ReadDir("/proc/self/fd")
+		if err != nil {
+			t.Skipf("open files cannot be counted: %v", err)
+		}
+		return len(entries)
+	}
+
+	//leaked files are only closed by finalizers, so keep the garbage collector from running them
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+	template := New().Post(server.URL).SetBodyFromFile(path, "text/plain").SetCompressionThreshold(10)
+	before := openFiles()
+	for i := 0; i < 50; i++ {
+		_, err := template.New().Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "", encoding)
+		assert.Equal(t, 4096, received)
+	}
+	assert.LessOrEqual(t, openFiles(), before+5)
+}
@@ -0,0 +1,54 @@
+package request
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+//SetMaxConcurrency limits how many attempts may be in flight at once.
+//The limit is shared with every request derived from this one via New
+func (r *Request) SetMaxConcurrency(n int) *Request {
+	if r == nil {
+		return nil
+	}
+	r.sem = newSemaphore(n)
+	return r
+}
+
+//InFlight returns the number of attempts currently in flight under the concurrency limit.
+//It is always zero when no limit is set
+func (r *Request) InFlight() int64 {
+	if r.sem == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&r.sem.inFlight)
+}
+
+//semaphore bounds concurrent attempts and tracks how many are in flight
+type semaphore struct {
+	slots    chan struct{}
+	inFlight int64
+}
+
+func newSemaphore(n int) *semaphore {
+	if n < 1 {
+		n = 1
+	}
+	return &semaphore{slots: make(chan struct{}, n)}
+}
+
+//acquire blocks until a slot is free or ctx is done
+func (s *semaphore) acquire(ctx context.Context) error {
+	select {
+	case s.slots <- struct{}{}:
+		atomic.AddInt64(&s.inFlight, 1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *semaphore) release() {
+	atomic.AddInt64(&s.inFlight, -1)
+	<-s.slots
+}
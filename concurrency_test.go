@@ -0,0 +1,100 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//slowHandler tracks the highest number of concurrent invocations
+func slowHandler(delay time.Duration, current, max *int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(current, 1)
+		for {
+			m := atomic.LoadInt64(max)
+			if n <= m || atomic.CompareAndSwapInt64(max, m, n) {
+				break
+			}
+		}
+		time.Sleep(delay)
+		atomic.AddInt64(current, -1)
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestSetMaxConcurrency(t *testing.T) {
+	var current, max int64
+	template := newMockRequest(slowHandler(5*time.Millisecond, &current, &max))
+	template.Get("http://example.com").SetMaxConcurrency(3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := template.New().Execute()
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, max, int64(3))
+	assert.Equal(t, int64(0), template.InFlight())
+}
+
+func TestInFlight(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+	r.Get("http://example.com").SetMaxConcurrency(2)
+
+	assert.Equal(t, int64(0), r.InFlight())
+	assert.Equal(t, int64(0), New().InFlight())
+
+	done := make(chan struct{})
+	go func() {
+		r.Execute()
+		close(done)
+	}()
+	<-started
+	assert.Equal(t, int64(1), r.InFlight())
+
+	close(release)
+	<-done
+	assert.Equal(t, int64(0), r.InFlight())
+}
+
+func TestMaxConcurrencyContextCancel(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	template := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+	template.Get("http://example.com").SetMaxConcurrency(1)
+
+	done := make(chan struct{})
+	go func() {
+		template.New().Execute()
+		close(done)
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := template.New().SetContext(ctx).Execute()
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	close(release)
+	<-done
+}
@@ -0,0 +1,87 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const defaultCSRFHeader = "X-CSRF-Token"
+
+//CSRFOption configures EnableCSRF
+type CSRFOption func(*csrfConfig)
+
+type csrfConfig struct {
+	cookie       string
+	header       string
+	allowMissing bool
+}
+
+//AllowMissingCSRF sends requests without the CSRF header when the cookie is not found instead of failing them
+func AllowMissingCSRF() CSRFOption {
+	return func(c *csrfConfig) {
+		c.allowMissing = true
+	}
+}
+
+//SetCookieJar sets the cookie jar which stores cookies from responses and sends them with requests.
+//The client is copied so requests sharing it are not affected. It has no effect when the client is not an *http.Client
+func (r *Request) SetCookieJar(jar http.CookieJar) *Request {
+	if r == nil {
+		return nil
+	}
+	client, ok := r.client.(*http.Client)
+	if !ok {
+		return r
+	}
+
+	copied := *client
+	copied.Jar = jar
+	r.client = &copied
+	return r
+}
+
+//EnableCSRF copies the value of the cookie called cookieName, looked up in the cookie jar for the target URL,
+//into the header called headerName, or X-CSRF-Token when empty, on POST, PUT, PATCH and DELETE requests.
+//Other methods are left untouched. When the cookie is not found the request fails with an error matching
+//ErrCSRFTokenMissing, unless AllowMissingCSRF is given
+func (r *Request) EnableCSRF(cookieName, headerName string, opts ...CSRFOption) *Request {
+	if r == nil {
+		return nil
+	}
+	if headerName == "" {
+		headerName = defaultCSRFHeader
+	}
+
+	c := &csrfConfig{cookie: cookieName, header: headerName}
+	for _, opt := range opts {
+		opt(c)
+	}
+	r.csrf = c
+	return r
+}
+
+//setCSRFHeader copies the CSRF cookie from the cookie jar into the header of mutating requests
+func (r *Request) setCSRFHeader(req *http.Request) error {
+	if r.csrf == nil {
+		return nil
+	}
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return nil
+	}
+
+	if client, ok := r.client.(*http.Client); ok && client.Jar != nil {
+		for _, cookie := range client.Jar.Cookies(req.URL) {
+			if cookie.Name == r.csrf.cookie {
+				req.Header.Set(r.csrf.header, cookie.Value)
+				return nil
+			}
+		}
+	}
+
+	if r.csrf.allowMissing {
+		return nil
+	}
+	return fmt.Errorf("%w: no %q cookie for %s", ErrCSRFTokenMissing, r.csrf.cookie, req.URL.Host)
+}
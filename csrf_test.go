@@ -0,0 +1,85 @@
+package request
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableCSRF(t *testing.T) {
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "csrftoken", Value: "abc123", Path: "/"})
+		}
+		token = r.Header.Get("X-CSRF-Token")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	session := func() *Request {
+		jar, _ := cookiejar.New(nil)
+		base := New().SetCookieJar(jar).EnableCSRF("csrftoken", "")
+		_, err := base.New().Get(server.URL + "/login").Execute()
+		assert.Nil(t, err)
+		return base
+	}
+
+	t.Run("mutating requests", func(t *testing.T) {
+		base := session()
+		for _, r := range []*Request{
+			base.New().Post(server.URL + "/items"),
+			base.New().Put(server.URL + "/items/1"),
+			base.New().Patch(server.URL + "/items/1"),
+			base.New().Delete(server.URL + "/items/1"),
+		} {
+			token = ""
+			_, err := r.Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, "abc123", token)
+		}
+	})
+
+	t.Run("safe requests untouched", func(t *testing.T) {
+		base := session()
+		token = ""
+		_, err := base.New().Get(server.URL + "/items").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "", token)
+	})
+
+	t.Run("custom header", func(t *testing.T) {
+		req, err := session().New().Post(server.URL+"/items").EnableCSRF("csrftoken", "X-XSRF-TOKEN").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "abc123", req.Header.Get("X-XSRF-TOKEN"))
+	})
+
+	t.Run("missing cookie", func(t *testing.T) {
+		jar, _ := cookiejar.New(nil)
+		_, err := New().SetCookieJar(jar).EnableCSRF("csrftoken", "").Post(server.URL + "/items").Execute()
+		assert.ErrorIs(t, err, ErrCSRFTokenMissing)
+
+		_, err = New().EnableCSRF("csrftoken", "").Post(server.URL + "/items").Execute()
+		assert.ErrorIs(t, err, ErrCSRFTokenMissing)
+	})
+
+	t.Run("allow missing cookie", func(t *testing.T) {
+		jar, _ := cookiejar.New(nil)
+		token = "unset"
+		_, err := New().SetCookieJar(jar).EnableCSRF("csrftoken", "", AllowMissingCSRF()).Post(server.URL + "/items").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "", token)
+	})
+}
+
+func TestSetCookieJar(t *testing.T) {
+	jar, _ := cookiejar.New(nil)
+	base := New()
+	r := base.New().SetCookieJar(jar)
+
+	assert.Equal(t, jar, r.client.(*http.Client).Jar)
+	assert.Nil(t, base.client.(*http.Client).Jar)
+}
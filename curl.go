@@ -0,0 +1,108 @@
+package request
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+//AsCurl builds the request and renders it as an equivalent curl command, with sensitive headers redacted
+//as set with RedactHeaders. Cookies from the client's cookie jar are included. Headers added when the request
+//is sent, such as signatures and idempotency keys, are not. File bodies are referenced by path and streamed
+//bodies, which cannot be read without consuming them, are replaced by stdin with a note
+func (r *Request) AsCurl() (string, error) {
+	return r.asCurl(true)
+}
+
+//AsCurlUnsafe renders the request as a curl command like AsCurl but without redacting any header.
+//The command may contain credentials and must not be logged or shared
+func (r *Request) AsCurlUnsafe() (string, error) {
+	return r.asCurl(false)
+}
+
+func (r *Request) asCurl(redact bool) (string, error) {
+	if r != nil && r.url == "" {
+		return "", ErrNoURL
+	}
+	req, err := r.Request()
+	if err != nil {
+		return "", err
+	}
+	if req.Body != nil {
+		defer req.Body.Close()
+	}
+
+	header := req.Header.Clone()
+	if client, ok := r.client.(*http.Client); ok && client.Jar != nil {
+		for _, cookie := range client.Jar.Cookies(req.URL) {
+			header.Add("Cookie", cookie.String())
+		}
+	}
+	if redact {
+		header = r.RedactHeader(header)
+	}
+
+	var note string
+	command := []string{"curl"}
+	switch req.Method {
+	case http.MethodGet:
+	case http.MethodHead:
+		command[0] += " --head"
+	default:
+		command[0] += " -X " + shellWord(req.Method)
+	}
+	command[0] += " " + shellQuote(req.URL.String())
+
+	order, _ := req.Context().Value(headerOrderKey{}).([]string)
+	for _, name := range orderHeaderNames(header, order) {
+		if name == "Cookie" {
+			command = append(command, "-b "+shellQuote(strings.Join(header[name], "; ")))
+			continue
+		}
+		for _, value := range header[name] {
+			command = append(command, "-H "+shellQuote(name+": "+value))
+		}
+	}
+
+	switch {
+	case req.Body == nil || req.Body == http.NoBody:
+	case isFileBody(r.body):
+		command = append(command, "--data-binary @"+shellQuote(r.body.(fileBody).path))
+	case req.GetBody != nil:
+		body, err := req.GetBody()
+		if err != nil {
+			return "", err
+		}
+		data, err := ioutil.ReadAll(body)
+		body.Close()
+		if err != nil {
+			return "", err
+		}
+		command = append(command, "--data-raw "+shellQuote(string(data)))
+	default:
+		note = "# the request body is a stream which is not included, pipe it to curl\n"
+		command = append(command, "--data-binary @-")
+	}
+
+	return note + strings.Join(command, " \\\n  "), nil
+}
+
+func isFileBody(body interface{}) bool {
+	_, ok := body.(fileBody)
+	return ok
+}
+
+//shellWord returns s unquoted when it only contains letters, digits, dashes and underscores, otherwise quoted
+func shellWord(s string) string {
+	for _, c := range s {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return shellQuote(s)
+		}
+	}
+	return s
+}
+
+//shellQuote quotes s in single quotes for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
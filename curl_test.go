@@ -0,0 +1,114 @@
+package request
+
+import (
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+//assertGolden compares actual with the contents of testdata/name.golden, rewriting it when -update is set
+func assertGolden(t *testing.T, name, actual string) {
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		assert.Nil(t, os.MkdirAll("testdata", 0755))
+		assert.Nil(t, ioutil.WriteFile(path, []byte(actual), 0644))
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, string(expected), actual)
+}
+
+type fakeCurlQuery struct {
+	Page   int    `url:"page"`
+	Search string `url:"q"`
+}
+
+func TestAsCurl(t *testing.T) {
+	t.Run("get with query", func(t *testing.T) {
+		command, err := New().Get("https://example.com/users?sort=name").
+			SetQuery(&fakeCurlQuery{Page: 2, Search: "o'brien"}).
+			SetHeader("Accept", "application/json").
+			AsCurl()
+		assert.Nil(t, err)
+		assertGolden(t, "curl_get_query", command)
+	})
+
+	t.Run("post json", func(t *testing.T) {
+		command, err := New().Post("https://example.com/users").
+			SetHeader("Authorization", "Bearer secret").
+			SetHeader("Content-Type", "application/json").
+			SetBody(map[string]string{"name": "John", "note": "it's"}).
+			AsCurl()
+		assert.Nil(t, err)
+		assertGolden(t, "curl_post_json", command)
+	})
+
+	t.Run("cookies", func(t *testing.T) {
+		jar, _ := cookiejar.New(nil)
+		u, _ := url.Parse("https://example.com")
+		jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "abc"}})
+
+		command, err := New().Delete("https://example.com/users/1").
+			SetCookieJar(jar).
+			SetHeader("Cookie", "theme=dark").
+			AsCurlUnsafe()
+		assert.Nil(t, err)
+		assertGolden(t, "curl_cookies", command)
+
+		redacted, err := New().Delete("https://example.com/users/1").SetCookieJar(jar).AsCurl()
+		assert.Nil(t, err)
+		assert.Contains(t, redacted, "-b '[REDACTED]'")
+		assert.NotContains(t, redacted, "abc")
+	})
+
+	t.Run("unsafe", func(t *testing.T) {
+		command, err := New().Get("https://example.com").SetHeader("Authorization", "Bearer secret").AsCurlUnsafe()
+		assert.Nil(t, err)
+		assert.Equal(t, "curl 'https://example.com' \\\n  -H 'Authorization: Bearer secret'", command)
+	})
+
+	t.Run("head", func(t *testing.T) {
+		command, err := New().Head("https://example.com").AsCurl()
+		assert.Nil(t, err)
+		assert.Equal(t, "curl --head 'https://example.com'", command)
+	})
+
+	t.Run("streamed body", func(t *testing.T) {
+		body := strings.NewReader("data")
+		command, err := New().Post("https://example.com").SetBody(ioutil.NopCloser(body)).AsCurl()
+		assert.Nil(t, err)
+		assert.Equal(t, "# the request body is a stream which is not included, pipe it to curl\ncurl -X POST 'https://example.com' \\\n  --data-binary @-", command)
+
+		//the body is left unread
+		assert.Equal(t, 4, body.Len())
+	})
+
+	t.Run("file body", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "upload.json")
+		assert.Nil(t, ioutil.WriteFile(path, []byte(`{}`), 0644))
+
+		command, err := New().Put("https://example.com").SetBodyFromFile(path, "application/json").AsCurl()
+		assert.Nil(t, err)
+		assert.Equal(t, "curl -X PUT 'https://example.com' \\\n  -H 'Content-Type: application/json' \\\n  --data-binary @'"+path+"'", command)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var r *Request
+		_, err := r.AsCurl()
+		assert.ErrorIs(t, err, ErrNilRequest)
+
+		_, err = New().AsCurl()
+		assert.ErrorIs(t, err, ErrNoURL)
+	})
+}
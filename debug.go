@@ -0,0 +1,161 @@
+package request
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const defaultDebugBodyLimit = 1024
+
+type debugger struct {
+	w     io.Writer
+	limit int
+}
+
+//DebugOption configures the output of Debug
+type DebugOption func(*debugger)
+
+//DebugWriter sets where debug output is written, defaults to os.Stderr
+func DebugWriter(w io.Writer) DebugOption {
+	return func(d *debugger) {
+		d.w = w
+	}
+}
+
+//DebugBodyLimit sets how many bytes of each body are previewed, defaults to 1024. Zero disables body previews
+func DebugBodyLimit(n int) DebugOption {
+	return func(d *debugger) {
+		d.limit = n
+	}
+}
+
+//Debug prints every exchange with the server in a human readable form: the method and resolved URL, the
+//headers as sent, redacted as set with RedactHeaders, and a preview of the body, followed by the response
+//status, time to the response headers, headers and a preview of the body. Unlike EnableDump the output is not
+//wire accurate. Streamed request bodies and event or NDJSON stream responses are never read for a preview,
+//other response bodies are peeked without being consumed
+func (r *Request) Debug(enabled bool, opts ...DebugOption) *Request {
+	if r == nil {
+		return nil
+	}
+	r.debug = nil
+	if enabled {
+		r.debug = &debugger{w: os.Stderr, limit: defaultDebugBodyLimit}
+		for _, opt := range opts {
+			opt(r.debug)
+		}
+	}
+	return r
+}
+
+//debugExchange sends req with send, printing the exchange to the debug writer
+func (r *Request) debugExchange(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "> %s %s\n", req.Method, req.URL.String())
+	r.writeDebugHeaders(&buf, "> ", req.Header, HeaderOrder(req))
+	if err := r.writeDebugRequestBody(&buf, req); err != nil {
+		return nil, err
+	}
+
+	start := r.now()
+	resp, err := send(req)
+	elapsed := r.now().Sub(start)
+	if err != nil {
+		fmt.Fprintf(&buf, "! %s after %s\n", err, elapsed)
+	} else {
+		fmt.Fprintf(&buf, "< %s (%s)\n", resp.Status, elapsed)
+		r.writeDebugHeaders(&buf, "< ", resp.Header, nil)
+		if err := r.writeDebugResponseBody(&buf, resp); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteString("\n")
+
+	dumpMu.Lock()
+	defer dumpMu.Unlock()
+	if _, debugErr := r.debug.w.Write(buf.Bytes()); debugErr != nil && r.logger != nil {
+		r.logger.Warn("failed to write debug output", slog.String("error", debugErr.Error()))
+	}
+	return resp, err
+}
+
+func (r *Request) writeDebugHeaders(buf *bytes.Buffer, prefix string, header http.Header, order []string) {
+	redacted := r.RedactHeader(header)
+	for _, name := range orderHeaderNames(redacted, order) {
+		for _, value := range redacted[name] {
+			fmt.Fprintf(buf, "%s%s: %s\n", prefix, name, value)
+		}
+	}
+}
+
+//writeDebugRequestBody previews the request body when it can be read again
+func (r *Request) writeDebugRequestBody(buf *bytes.Buffer, req *http.Request) error {
+	if req.Body == nil || req.Body == http.NoBody || r.debug.limit <= 0 {
+		return nil
+	}
+	if req.GetBody == nil {
+		buf.WriteString("> (streamed body not shown)\n")
+		return nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	preview, err := ioutil.ReadAll(io.LimitReader(body, int64(r.debug.limit)+1))
+	if err != nil {
+		return err
+	}
+	writeDebugBody(buf, "> ", preview, r.debug.limit)
+	return nil
+}
+
+//writeDebugResponseBody previews the response body, putting the bytes read back in front of the rest
+func (r *Request) writeDebugResponseBody(buf *bytes.Buffer, resp *http.Response) error {
+	if resp.Body == nil || resp.ContentLength == 0 || r.debug.limit <= 0 {
+		return nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" || mediaType == "application/x-ndjson" {
+		buf.WriteString("< (streamed body not shown)\n")
+		return nil
+	}
+
+	preview := make([]byte, r.debug.limit+1)
+	n, err := io.ReadFull(resp.Body, preview)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	preview = preview[:n]
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(preview), resp.Body), resp.Body}
+	writeDebugBody(buf, "< ", preview, r.debug.limit)
+	return nil
+}
+
+func writeDebugBody(buf *bytes.Buffer, prefix string, preview []byte, limit int) {
+	if len(preview) == 0 {
+		return
+	}
+	truncated := len(preview) > limit
+	if truncated {
+		preview = preview[:limit]
+	}
+	buf.WriteString(prefix + "\n")
+	for _, line := range strings.Split(string(preview), "\n") {
+		buf.WriteString(prefix + line + "\n")
+	}
+	if truncated {
+		buf.WriteString(prefix + "(body truncated)\n")
+	}
+}
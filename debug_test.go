@@ -0,0 +1,106 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebug(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			ioutil.ReadAll(r.Body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"ID":1,"Name":"` + strings.Repeat("a", 40) + `"}`))
+	}
+
+	t.Run("exchange", func(t *testing.T) {
+		var out bytes.Buffer
+		var success fakeSuccess
+		_, err := newMockRequest(handler).Post("http://example.com/users?page=1").
+			SetHeader("Authorization", "Bearer secret").
+			SetBody(&fakeSuccess{Name: "John"}).
+			SetSuccess(&success).
+			Debug(true, DebugWriter(&out)).
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, success.ID)
+		assert.Len(t, success.Name, 40)
+
+		output := out.String()
+		assert.Contains(t, output, "POST http://example.com/users?page=1")
+		assert.Contains(t, output, "Authorization: Bearer "+Redacted)
+		assert.NotContains(t, output, "secret")
+		assert.Contains(t, output, `"Name":"John"`)
+		assert.Contains(t, output, "201 Created")
+		assert.Contains(t, output, "Content-Type: application/json")
+		assert.Contains(t, output, `{"ID":1,"Name":"aaa`)
+	})
+
+	t.Run("body limit", func(t *testing.T) {
+		var out bytes.Buffer
+		var success fakeSuccess
+		_, err := newMockRequest(handler).Get("http://example.com").
+			SetSuccess(&success).
+			Debug(true, DebugWriter(&out), DebugBodyLimit(8)).
+			Execute()
+		assert.Nil(t, err)
+		assert.Len(t, success.Name, 40)
+		assert.Contains(t, out.String(), `{"ID":1,`)
+		assert.NotContains(t, out.String(), `"Name"`)
+		assert.Contains(t, out.String(), "truncated")
+	})
+
+	t.Run("streamed request body", func(t *testing.T) {
+		var out bytes.Buffer
+		var received []byte
+		r := newMockRequest(func(w http.ResponseWriter, r *http.Request) {
+			received, _ = ioutil.ReadAll(r.Body)
+		})
+		_, err := r.Post("http://example.com").
+			SetBody(ioutil.NopCloser(strings.NewReader("streamed"))).
+			Debug(true, DebugWriter(&out)).
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "streamed", string(received))
+		assert.Contains(t, out.String(), "not shown")
+		assert.NotContains(t, out.String(), "streamed\n")
+	})
+
+	t.Run("event stream response", func(t *testing.T) {
+		var out bytes.Buffer
+		r := newMockRequest(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte("data: event\n\n"))
+		})
+		result, err := r.Get("http://example.com").Debug(true, DebugWriter(&out)).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "data: event\n\n", string(result.Body))
+		assert.Contains(t, out.String(), "not shown")
+		assert.NotContains(t, out.String(), "data: event")
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		var out bytes.Buffer
+		r := &Request{client: &errClient{err: errors.New("connection refused")}, header: make(http.Header)}
+		_, err := r.Get("http://example.com").Debug(true, DebugWriter(&out)).Execute()
+		assert.NotNil(t, err)
+		assert.Contains(t, out.String(), "GET http://example.com")
+		assert.Contains(t, out.String(), "connection refused")
+	})
+
+	t.Run("defaults and disabling", func(t *testing.T) {
+		r := New().Debug(true)
+		assert.Equal(t, os.Stderr, r.debug.w)
+		assert.Equal(t, defaultDebugBodyLimit, r.debug.limit)
+		assert.Nil(t, r.Debug(false).debug)
+	})
+}
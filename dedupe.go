@@ -0,0 +1,117 @@
+package request
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//EnableDeduplication makes identical concurrent GET and HEAD requests share a single network call.
+//Requests are identical when their method, URL and headers match, apart from the Idempotency-Key and request ID
+//headers which differ on every Execute. Each caller decodes its own copy of the response.
+//Deduplication is shared with every request derived from this one via New
+func (r *Request) EnableDeduplication() *Request {
+	return r.SetSingleFlight(&FlightGroup{})
+}
+
+//SetSingleFlight deduplicates identical concurrent GET and HEAD requests through group, as EnableDeduplication does,
+//so independently built requests can share one network call by sharing a group. A nil group disables deduplication
+func (r *Request) SetSingleFlight(group *FlightGroup) *Request {
+	if r == nil {
+		return nil
+	}
+	r.flight = group
+	return r
+}
+
+//flightCall is an in-flight or completed call shared by identical requests
+type flightCall struct {
+	wg   sync.WaitGroup
+	resp *Response
+	err  error
+	dups int
+}
+
+//FlightGroup coalesces identical concurrent requests into a single network call.
+//The zero value is ready to use and it is safe for concurrent use
+type FlightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall
+}
+
+//do runs fn once for all concurrent callers with the same key and hands each of them the result
+func (g *FlightGroup) do(key string, fn func() (*Response, error)) (*Response, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*flightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		c.dups++
+		g.mu.Unlock()
+		c.wg.Wait()
+		return c.resp, c.err
+	}
+
+	c := &flightCall{}
+	c.wg.Add(1)
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.resp, c.err = fn()
+	c.wg.Done()
+
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+
+	return c.resp, c.err
+}
+
+//canDeduplicate reports whether req may share its network call with identical requests
+func (r *Request) canDeduplicate(req *http.Request) bool {
+	return r.flight != nil && (req.Method == http.MethodGet || req.Method == http.MethodHead)
+}
+
+//perExecutionHeaders returns the headers which differ between executions of otherwise identical requests
+func (r *Request) perExecutionHeaders() []string {
+	headers := []string{idempotencyKeyHeader}
+	if r.reqID != nil {
+		headers = append(headers, http.CanonicalHeaderKey(r.reqID.header))
+	}
+	return headers
+}
+
+//flightKey identifies a request by its method, URL and headers, apart from the skipped ones
+func flightKey(req *http.Request, skip ...string) string {
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		if !slices.Contains(skip, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteString(" ")
+	b.WriteString(req.URL.String())
+	for _, key := range keys {
+		b.WriteString("\n")
+		b.WriteString(key)
+		b.WriteString(": ")
+		b.WriteString(strings.Join(req.Header[key], ", "))
+	}
+
+	return b.String()
+}
+
+//copyResponse returns a copy of a shared response which can be decoded without affecting other callers
+func copyResponse(resp *Response) *Response {
+	c := *resp
+	c.Header = resp.Header.Clone()
+	c.Success = nil
+	c.Failure = nil
+	return &c
+}
@@ -0,0 +1,151 @@
+package request
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//waitForDups blocks until n callers are waiting on an in-flight call
+func waitForDups(t *testing.T, g *FlightGroup, n int) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		g.mu.Lock()
+		dups := 0
+		for _, c := range g.calls {
+			dups += c.dups
+		}
+		g.mu.Unlock()
+
+		if dups >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d duplicate callers", n)
+}
+
+func TestDeduplication(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	template := newMockRequest(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte(`{"id":200, "name":"John"}`))
+	})
+	template.Get("http://example.com/users/200").EnableDeduplication()
+
+	results := make([]*fakeSuccess, 20)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = &fakeSuccess{}
+			resp, err := template.New().SetSuccess(results[i]).Execute()
+			assert.Nil(t, err)
+			assert.Same(t, results[i], resp.Success)
+		}(i)
+	}
+
+	waitForDups(t, template.flight, 19)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	for _, result := range results {
+		assert.Equal(t, &fakeSuccess{ID: 200, Name: "John"}, result)
+	}
+}
+
+func TestDeduplicationKeys(t *testing.T) {
+	get := func(url string) *http.Request {
+		req, _ := http.NewRequest("GET", url, nil)
+		return req
+	}
+
+	a := get("http://example.com/a")
+	b := get("http://example.com/a")
+	assert.Equal(t, flightKey(a), flightKey(b))
+
+	b.Header.Set("Authorization", "Bearer other")
+	assert.NotEqual(t, flightKey(a), flightKey(b))
+	assert.NotEqual(t, flightKey(a), flightKey(get("http://example.com/a?page=2")))
+
+	r := New().EnableRequestID("x-trace-id", nil)
+	a, b = get("http://example.com/a"), get("http://example.com/a")
+	a.Header.Set("X-Trace-Id", "1")
+	a.Header.Set("Idempotency-Key", "1")
+	b.Header.Set("X-Trace-Id", "2")
+	b.Header.Set("Idempotency-Key", "2")
+	assert.Equal(t, flightKey(a, r.perExecutionHeaders()...), flightKey(b, r.perExecutionHeaders()...))
+	assert.NotEqual(t, flightKey(a), flightKey(b))
+}
+
+func TestDeduplicationNonIdempotent(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	template := newMockRequest(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+	})
+	template.Post("http://example.com/orders").EnableDeduplication()
+	assert.False(t, template.canDeduplicate(&http.Request{Method: http.MethodPost}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			template.New().Execute()
+		}()
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(&calls) < 5 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(5), atomic.LoadInt32(&calls))
+}
+
+func TestSetSingleFlight(t *testing.T) {
+	var calls int32
+	release := make(chan struct{})
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		w.Write([]byte(`{"id":200, "name":"John"}`))
+	}
+
+	//independently built requests share the network call through the group
+	group := &FlightGroup{}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := &fakeSuccess{}
+			_, err := newMockRequest(handler).Get("http://example.com/users/200").
+				SetSingleFlight(group).SetSuccess(result).Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, &fakeSuccess{ID: 200, Name: "John"}, result)
+		}()
+	}
+
+	waitForDups(t, group, 19)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	t.Run("disabled", func(t *testing.T) {
+		r := New().EnableDeduplication().SetSingleFlight(nil)
+		assert.Nil(t, r.flight)
+	})
+}
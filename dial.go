@@ -0,0 +1,59 @@
+package request
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+//SetDialTimeout limits how long establishing a connection may take, so unreachable hosts fail fast
+//while the total timeout set with SetTimeout still bounds the whole request. It also applies to the socket set
+//with SetUnixSocket, whichever is called first. The client and its transport are copied so requests sharing
+//them are not affected. It is ignored for clients which are not an *http.Client or whose transport is not an
+//*http.Transport
+func (r *Request) SetDialTimeout(d time.Duration) *Request {
+	if r == nil {
+		return nil
+	}
+	r.dialTimeout = d
+	r.configureTransport(r.configureDialer)
+	return r
+}
+
+//SetUnixSocket sends requests over the Unix domain socket at path, such as a local daemon's, instead of TCP.
+//The URL host is then only used for the Host header, for example "http://localhost/v1.41/info".
+//Proxies are not used and the timeout set with SetDialTimeout still applies. The client and its transport are
+//copied so requests sharing them are not affected. It is ignored for clients which are not an *http.Client
+//or whose transport is not an *http.Transport
+func (r *Request) SetUnixSocket(path string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.unixSocket = path
+	r.configureTransport(r.configureDialer)
+	return r
+}
+
+//configureDialer sets the transport's dialer from the dial timeout and the Unix socket.
+//Without a dial timeout connections time out after 30 seconds like with http.DefaultTransport
+func (r *Request) configureDialer(transport *http.Transport) {
+	timeout := r.dialTimeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	dial := (&net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+
+	if r.unixSocket == "" {
+		transport.DialContext = dial
+		return
+	}
+	path := r.unixSocket
+	transport.Proxy = nil
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dial(ctx, "unix", path)
+	}
+}
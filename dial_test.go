@@ -0,0 +1,108 @@
+package request
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetDialTimeout(t *testing.T) {
+	t.Run("blackholed address", func(t *testing.T) {
+		start := time.Now()
+		_, err := New().Get("http://10.255.255.1:81").SetTimeout(10 * time.Second).SetDialTimeout(100 * time.Millisecond).Execute()
+		if err == nil || !IsTimeout(err) {
+			t.Skipf("address is not blackholed in this environment: %v", err)
+		}
+
+		assert.Less(t, int64(time.Since(start)), int64(2*time.Second))
+		assert.Contains(t, err.Error(), "dial")
+	})
+
+	t.Run("copies the transport", func(t *testing.T) {
+		base := New()
+		r := base.New().SetDialTimeout(time.Second)
+
+		_, ok := r.client.(*http.Client).Transport.(*http.Transport)
+		assert.True(t, ok)
+		assert.Nil(t, base.client.(*http.Client).Transport)
+	})
+
+	t.Run("ignored for custom clients", func(t *testing.T) {
+		client := &mockClient{}
+		r := &Request{client: client, header: make(http.Header)}
+		assert.Same(t, client, r.SetDialTimeout(time.Second).client)
+	})
+}
+
+func TestSetUnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "sock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "api.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("unix sockets are not supported: %v", err)
+	}
+	var host string
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		fakeHandler(200, `{"ID":1,"Name":"socket"}`, nil)(w, r)
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	t.Run("dials the socket", func(t *testing.T) {
+		var success fakeSuccess
+		result, err := New().Get("http://docker/v1.41/info").SetUnixSocket(path).SetSuccess(&success).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, fakeSuccess{ID: 1, Name: "socket"}, success)
+		assert.Equal(t, "docker", host)
+	})
+
+	t.Run("with dial timeout", func(t *testing.T) {
+		for _, r := range []*Request{
+			New().SetUnixSocket(path).SetDialTimeout(time.Second),
+			New().SetDialTimeout(time.Second).SetUnixSocket(path),
+		} {
+			result, err := r.Get("http://docker/v1.41/info").Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, 200, result.StatusCode)
+		}
+	})
+
+	t.Run("missing socket", func(t *testing.T) {
+		_, err := New().Get("http://docker/info").SetUnixSocket(filepath.Join(dir, "missing.sock")).Execute()
+		assert.NotNil(t, err)
+	})
+
+	t.Run("ignored for custom clients", func(t *testing.T) {
+		client := &mockClient{}
+		r := &Request{client: client, header: make(http.Header)}
+		assert.Same(t, client, r.SetUnixSocket(path).client)
+	})
+}
+
+func TestConfigureTransportReusesCopy(t *testing.T) {
+	base := New().SetDialTimeout(time.Second)
+	transport := base.client.(*http.Client).Transport
+	base.SetProxy(nil).SetInsecureSkipVerify(true).SetTimeout(time.Second)
+	assert.Same(t, transport, base.client.(*http.Client).Transport)
+	assert.True(t, transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
+
+	derived := base.New().SetInsecureSkipVerify(false)
+	assert.NotSame(t, transport, derived.client.(*http.Client).Transport)
+	assert.True(t, transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
+
+	base.SetInsecureSkipVerify(false)
+	assert.NotSame(t, transport, base.client.(*http.Client).Transport)
+	assert.True(t, transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify)
+}
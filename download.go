@@ -0,0 +1,127 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+)
+
+//DownloadTo runs the request once and streams a 2xx response body to w without buffering it in memory.
+//onProgress, if not nil, is called after every write with the bytes written so far and the total size
+//taken from Content-Length, or 0 when unknown. Non-2xx bodies are not written to w but read and decoded
+//into the response like Execute does. Canceling the request context aborts the download promptly
+//and returns the context error
+func (r *Request) DownloadTo(w io.Writer, onProgress func(bytesWritten, total int64)) (*Response, error) {
+	if r == nil {
+		return nil, ErrNilRequest
+	}
+	if r.url == "" {
+		return nil, ErrNoURL
+	}
+
+	return r.execute(func(exec *execution) (*Response, error) {
+		resp, err := r.sendOnce(exec, func(req *http.Request) (*Response, error) {
+			return r.download(req, w, onProgress)
+		})
+		if err != nil || resp.Body == nil {
+			return resp, err
+		}
+		return resp, r.decode(resp)
+	})
+}
+
+func (r *Request) download(req *http.Request, w io.Writer, onProgress func(bytesWritten, total int64)) (*Response, error) {
+	resp, err := r.roundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}
+	setRedirects(response, resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, err := r.readBody(resp.Body)
+		response.Body = body
+		if err != nil {
+			return response, err
+		}
+		return response, r.verifyResponse(response)
+	}
+
+	total := resp.ContentLength
+	if total < 0 {
+		total = 0
+	}
+
+	body := newContextReader(req.Context(), resp.Body)
+	defer body.stop()
+
+	var reader io.Reader = body
+	if r.verify != nil {
+		//the body is written only once verified
+		buffered, err := io.ReadAll(body)
+		if err != nil {
+			return response, err
+		}
+		response.Body = buffered
+		if err := r.verifyResponse(response); err != nil {
+			return response, err
+		}
+		response.Body = nil
+		reader = bytes.NewReader(buffered)
+	}
+
+	_, err = io.Copy(&progressWriter{w: w, total: total, onProgress: onProgress}, reader)
+	return response, err
+}
+
+//contextReader aborts reads once ctx is done, closing the body to unblock a pending read,
+//and reports the context error instead of the read error
+type contextReader struct {
+	ctx  context.Context
+	r    io.ReadCloser
+	stop func() bool
+	//release frees the concurrency slot held while the body is read, if any
+	release func()
+}
+
+func newContextReader(ctx context.Context, r io.ReadCloser) *contextReader {
+	return &contextReader{
+		ctx:  ctx,
+		r:    r,
+		stop: context.AfterFunc(ctx, func() { r.Close() }),
+	}
+}
+
+func (c *contextReader) Read(b []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(b)
+	if err != nil && c.ctx.Err() != nil {
+		return n, c.ctx.Err()
+	}
+	return n, err
+}
+
+//progressWriter reports the number of bytes written after every write
+type progressWriter struct {
+	w          io.Writer
+	written    int64
+	total      int64
+	onProgress func(bytesWritten, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written += int64(n)
+	if p.onProgress != nil {
+		p.onProgress(p.written, p.total)
+	}
+	return n, err
+}
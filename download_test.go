@@ -0,0 +1,114 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadTo(t *testing.T) {
+	payload := bytes.Repeat([]byte("0123456789"), 10000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("chunked") == "" {
+			w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+		}
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	t.Run("known size", func(t *testing.T) {
+		var buf bytes.Buffer
+		var calls int
+		var written, total int64
+
+		result, err := New().Get(server.URL).DownloadTo(&buf, func(bytesWritten, size int64) {
+			calls++
+			assert.GreaterOrEqual(t, bytesWritten, written)
+			written, total = bytesWritten, size
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Nil(t, result.Body)
+		assert.Equal(t, payload, buf.Bytes())
+		assert.Greater(t, calls, 1)
+		assert.Equal(t, int64(len(payload)), written)
+		assert.Equal(t, int64(len(payload)), total)
+	})
+
+	t.Run("unknown size", func(t *testing.T) {
+		var buf bytes.Buffer
+		var written, total int64 = 0, -1
+
+		_, err := New().Get(server.URL).SetQuery(&struct {
+			Chunked bool `url:"chunked"`
+		}{true}).DownloadTo(&buf, func(bytesWritten, size int64) {
+			written, total = bytesWritten, size
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, int64(len(payload)), written)
+		assert.Equal(t, int64(0), total)
+	})
+
+	t.Run("no progress callback", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := New().Get(server.URL).DownloadTo(&buf, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, len(payload), buf.Len())
+	})
+}
+
+func TestDownloadToFailure(t *testing.T) {
+	r := newMockRequest(fakeHandler(404, `{"id":404, "name":"missing"}`, nil))
+
+	var buf bytes.Buffer
+	result, err := r.Get("http://example.com/file").SetFailure(&fakeSuccess{}).DownloadTo(&buf, func(int64, int64) {
+		t.Error("progress must not be reported for failures")
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 404, result.StatusCode)
+	assert.Equal(t, 0, buf.Len())
+	assert.Equal(t, &fakeSuccess{ID: 404, Name: "missing"}, result.Failure)
+}
+
+//pipeClient responds with a body fed through a pipe that never completes on its own
+type pipeClient struct{}
+
+func (c *pipeClient) Do(req *http.Request) (*http.Response, error) {
+	reader, writer := io.Pipe()
+	go writer.Write([]byte("first chunk"))
+	return &http.Response{StatusCode: 200, Header: make(http.Header), ContentLength: -1, Body: reader}, nil
+}
+
+func TestDownloadToCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r := &Request{client: &pipeClient{}, header: make(http.Header)}
+
+	var buf bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		_, err := r.Get("http://example.com/file").SetContext(ctx).DownloadTo(&buf, func(written, total int64) {
+			//the next read blocks until the download is canceled
+			cancel()
+		})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, "first chunk", buf.String())
+	case <-time.After(time.Second):
+		t.Fatal("download was not aborted by cancellation")
+	}
+}
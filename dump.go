@@ -0,0 +1,109 @@
+package request
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+//dumpMu serializes dumps so exchanges of concurrent requests sharing a writer do not interleave
+var dumpMu sync.Mutex
+
+type dumper struct {
+	w           io.Writer
+	includeBody bool
+}
+
+//EnableDump writes every exchange with the server to w: the request line followed by the decoded query params
+//sorted by name one per line for readability, the headers and body as sent,
+//followed by the response status line, headers and body. Bodies are only written when includeBody is true,
+//in which case they are buffered in memory. Headers are redacted as set with RedactHeaders.
+//Each exchange is written at once, so w may be shared by concurrent requests. A nil w disables dumping
+func (r *Request) EnableDump(w io.Writer, includeBody bool) *Request {
+	if r == nil {
+		return nil
+	}
+	r.dump = nil
+	if w != nil {
+		r.dump = &dumper{w: w, includeBody: includeBody}
+	}
+	return r
+}
+
+//dumpExchange sends req with send, writing the exchange to the dump writer
+func (r *Request) dumpExchange(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "---- request ----\n%s %s %s\n", req.Method, req.URL.String(), req.Proto)
+	writeDumpQuery(&buf, req.URL.Query())
+	fmt.Fprintf(&buf, "Host: %s\n", req.Host)
+	if r.dump.includeBody {
+		body, err := bodyBytes(req)
+		if err != nil {
+			return nil, err
+		}
+		r.writeDumpMessage(&buf, req.Header, HeaderOrder(req), body)
+	} else {
+		r.writeDumpMessage(&buf, req.Header, HeaderOrder(req), nil)
+	}
+
+	resp, err := send(req)
+	if err != nil {
+		fmt.Fprintf(&buf, "---- error ----\n%s\n", err)
+	} else {
+		fmt.Fprintf(&buf, "---- response ----\n%s %s\n", resp.Proto, resp.Status)
+		var body []byte
+		if r.dump.includeBody {
+			body, err = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+			if err != nil {
+				return nil, err
+			}
+		}
+		r.writeDumpMessage(&buf, resp.Header, nil, body)
+	}
+	buf.WriteString("---- end ----\n")
+
+	dumpMu.Lock()
+	defer dumpMu.Unlock()
+	if _, dumpErr := r.dump.w.Write(buf.Bytes()); dumpErr != nil && r.logger != nil {
+		r.logger.Warn("failed to write dump", slog.String("error", dumpErr.Error()))
+	}
+	return resp, err
+}
+
+//writeDumpQuery writes the decoded query params sorted by name, one per line and indented below the request line
+func writeDumpQuery(buf *bytes.Buffer, query url.Values) {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range query[key] {
+			fmt.Fprintf(buf, "  %s=%s\n", key, value)
+		}
+	}
+}
+
+//writeDumpMessage writes the redacted headers in order, the rest sorted by name, followed by the body if any
+func (r *Request) writeDumpMessage(buf *bytes.Buffer, header http.Header, order []string, body []byte) {
+	redacted := r.RedactHeader(header)
+	for _, name := range orderHeaderNames(redacted, order) {
+		for _, value := range redacted[name] {
+			fmt.Fprintf(buf, "%s: %s\n", name, value)
+		}
+	}
+	buf.WriteString("\n")
+	if len(body) > 0 {
+		buf.Write(body)
+		buf.WriteString("\n")
+	}
+}
@@ -0,0 +1,114 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableDump(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		body := []byte("null")
+		if r.Body != nil {
+			body, _ = ioutil.ReadAll(r.Body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Add("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1,"echo":` + string(body) + `}`))
+	}
+
+	t.Run("with body", func(t *testing.T) {
+		var dump bytes.Buffer
+		success := &struct {
+			ID int `json:"id"`
+		}{}
+		_, err := newMockRequest(handler).Post("http://example.com/users?page=1&filter=a%20b&filter=c").
+			SetHeader("Authorization", "Bearer secret").
+			SetBody(&fakeSuccess{Name: "John"}).
+			SetSuccess(success).
+			EnableDump(&dump, true).
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, success.ID)
+
+		assert.Equal(t, `---- request ----
+POST http://example.com/users?page=1&filter=a%20b&filter=c HTTP/1.1
+  filter=a b
+  filter=c
+  page=1
+Host: example.com
+Authorization: Bearer [REDACTED]
+
+{"ID":0,"Name":"John"}
+---- response ----
+HTTP/1.1 201 Created
+Content-Type: application/json
+Set-Cookie: [REDACTED]
+
+{"id":1,"echo":{"ID":0,"Name":"John"}}
+---- end ----
+`, dump.String())
+	})
+
+	t.Run("without body", func(t *testing.T) {
+		var dump bytes.Buffer
+		_, err := newMockRequest(handler).Post("http://example.com/users").
+			SetBody(&fakeSuccess{Name: "John"}).
+			EnableDump(&dump, false).
+			Execute()
+		assert.Nil(t, err)
+		assert.NotContains(t, dump.String(), "John")
+		assert.Contains(t, dump.String(), "HTTP/1.1 201 Created")
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		var dump bytes.Buffer
+		r := &Request{client: &errClient{err: errors.New("connection refused")}, header: make(http.Header)}
+
+		_, err := r.Get("http://example.com").EnableDump(&dump, true).Execute()
+		assert.NotNil(t, err)
+		assert.Contains(t, dump.String(), "---- error ----\n")
+		assert.Contains(t, dump.String(), "connection refused")
+	})
+
+	t.Run("shared writer", func(t *testing.T) {
+		var dump bytes.Buffer
+		var mu sync.Mutex
+		w := writerFunc(func(p []byte) (int, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			return dump.Write(p)
+		})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := newMockRequest(handler).Get("http://example.com").EnableDump(w, true).Execute()
+				assert.Nil(t, err)
+			}()
+		}
+		wg.Wait()
+
+		exchanges := strings.Split(strings.TrimSuffix(dump.String(), "---- end ----\n"), "---- end ----\n")
+		assert.Len(t, exchanges, 10)
+		for _, exchange := range exchanges {
+			assert.True(t, strings.HasPrefix(exchange, "---- request ----\nGET "))
+			assert.Equal(t, 1, strings.Count(exchange, "---- response ----"))
+		}
+	})
+}
+
+type writerFunc func(p []byte) (int, error)
+
+func (f writerFunc) Write(p []byte) (int, error) {
+	return f(p)
+}
@@ -0,0 +1,249 @@
+package request
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+var (
+	//ErrNilRequest is returned by Execute and Request when called on a nil *Request
+	ErrNilRequest = errors.New("request: nil request")
+	//ErrNilResponse is returned when an interceptor or middleware returns neither a response nor an error
+	ErrNilResponse = errors.New("request: nil response")
+	//ErrNoURL is returned by Execute when no URL is set
+	ErrNoURL = errors.New("request: no URL set")
+	//ErrInvalidURL is wrapped by errors returned by Validate when the last URL set could not be parsed
+	ErrInvalidURL = errors.New("request: invalid URL")
+	//ErrInvalidMethod is wrapped by errors returned by Validate when the method is not a valid HTTP method token
+	ErrInvalidMethod = errors.New("request: invalid method")
+	//ErrInvalidBody is wrapped by errors returned by Validate when the body cannot be serialized
+	ErrInvalidBody = errors.New("request: invalid body")
+	//ErrInvalidQuery is wrapped by errors returned when the query cannot be encoded
+	ErrInvalidQuery = errors.New("request: invalid query")
+	//ErrResponseTooLarge is wrapped by errors returned when the response body exceeds SetMaxBodySize
+	ErrResponseTooLarge = errors.New("request: response body too large")
+	//ErrRetryBudgetExceeded is matched by the *RetryBudgetError returned when the retry budget would be exceeded
+	ErrRetryBudgetExceeded = errors.New("request: retry budget exceeded")
+	//ErrHookPanic is wrapped by errors returned when a hook panics
+	ErrHookPanic = errors.New("request: hook panicked")
+	//ErrPathNotFound is matched by the *JSONPathError returned by Response.JSONPath when the path does not exist
+	ErrPathNotFound = errors.New("request: json path not found")
+	//ErrResponseVerification is matched by the *ResponseVerificationError returned when OnVerifyResponse rejects a response
+	ErrResponseVerification = errors.New("request: response verification failed")
+	//ErrNotProblem is wrapped by errors returned by Response.Problem when the response is not application/problem+json
+	ErrNotProblem = errors.New("request: response is not a problem details object")
+	//ErrCertificatePinMismatch is matched by the *CertificatePinError returned when no certificate matches a pin set with PinCertificates
+	ErrCertificatePinMismatch = errors.New("request: certificate pin mismatch")
+	//ErrCSRFTokenMissing is wrapped by errors returned when EnableCSRF is set and the CSRF cookie is not found
+	ErrCSRFTokenMissing = errors.New("request: CSRF cookie not found")
+	//ErrQueueFull is returned by Queue.Enqueue when the queue is full and RejectWhenFull is set
+	ErrQueueFull = errors.New("request: queue full")
+	//ErrQueueClosed is returned by Queue.Enqueue after Shutdown
+	ErrQueueClosed = errors.New("request: queue closed")
+)
+
+//DecodeError is returned when a response body cannot be decoded
+type DecodeError struct {
+	Err error
+}
+
+func (e *DecodeError) Error() string {
+	return "request: failed to decode API response: " + e.Err.Error()
+}
+
+//Unwrap returns the underlying decoding error
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+//IsTimeout reports whether err is caused by a timeout or an expired context deadline
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+//IsConnectionError reports whether err is caused by a connection that could not be established or was dropped.
+//DNS failures are reported by IsDNSError instead
+func IsConnectionError(err error) bool {
+	if err == nil || IsDNSError(err) {
+		return false
+	}
+
+	for _, errno := range []syscall.Errno{syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.ECONNABORTED, syscall.EPIPE} {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial" && !opErr.Timeout()
+}
+
+//IsDNSError reports whether err is caused by a failed host name lookup
+func IsDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
+
+//IsTLSError reports whether err is caused by a failed TLS handshake or certificate verification
+func IsTLSError(err error) bool {
+	var (
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		verifyErr    *tls.CertificateVerificationError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		pinErr       *CertificatePinError
+	)
+
+	return errors.As(err, &recordErr) ||
+		errors.As(err, &alertErr) ||
+		errors.As(err, &verifyErr) ||
+		errors.As(err, &authorityErr) ||
+		errors.As(err, &hostnameErr) ||
+		errors.As(err, &invalidErr) ||
+		errors.As(err, &pinErr)
+}
+
+//IsDecodeError reports whether err is caused by a response body that could not be decoded
+func IsDecodeError(err error) bool {
+	var decodeErr *DecodeError
+	return errors.As(err, &decodeErr)
+}
+
+//RetryBudgetError is returned when retrying stops because the next delay would exceed the retry budget
+//or the context deadline. It matches ErrRetryBudgetExceeded or context.DeadlineExceeded respectively
+//and wraps the error of the last attempt, if any
+type RetryBudgetError struct {
+	Attempts int
+	Elapsed  time.Duration
+	//Delay is the delay before the next attempt which would have exceeded the budget
+	Delay time.Duration
+	//Err is the error of the last attempt, nil if it received a response
+	Err error
+
+	deadline bool
+}
+
+func (e *RetryBudgetError) Error() string {
+	limit := "retry budget"
+	if e.deadline {
+		limit = "context deadline"
+	}
+
+	message := fmt.Sprintf("request: retry delay of %s exceeds %s after %d attempts in %s", e.Delay, limit, e.Attempts, e.Elapsed)
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+//Unwrap returns the error of the last attempt and the limit that was exceeded
+func (e *RetryBudgetError) Unwrap() []error {
+	limit := ErrRetryBudgetExceeded
+	if e.deadline {
+		limit = context.DeadlineExceeded
+	}
+
+	if e.Err == nil {
+		return []error{limit}
+	}
+	return []error{e.Err, limit}
+}
+
+//ResponseVerificationError is returned when the OnVerifyResponse hook rejects a response
+type ResponseVerificationError struct {
+	Err error
+}
+
+func (e *ResponseVerificationError) Error() string {
+	return "request: response verification failed: " + e.Err.Error()
+}
+
+//Unwrap returns the error of the verification hook
+func (e *ResponseVerificationError) Unwrap() error {
+	return e.Err
+}
+
+//Is makes ResponseVerificationError match ErrResponseVerification
+func (e *ResponseVerificationError) Is(target error) bool {
+	return target == ErrResponseVerification
+}
+
+//CertificatePinError is returned when none of the certificates presented by the server match a pin set with PinCertificates
+type CertificatePinError struct {
+	//Observed holds the base64 encoded SHA-256 SPKI hashes of the presented certificates
+	Observed []string
+}
+
+func (e *CertificatePinError) Error() string {
+	return "request: certificate pin mismatch, observed pins: " + strings.Join(e.Observed, ", ")
+}
+
+//Is makes CertificatePinError match ErrCertificatePinMismatch
+func (e *CertificatePinError) Is(target error) bool {
+	return target == ErrCertificatePinMismatch
+}
+
+//JSONPathError is returned by Response.JSONPath when a path does not exist
+type JSONPathError struct {
+	//Path is the part of the path that could not be found
+	Path   string
+	Reason string
+}
+
+func (e *JSONPathError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("request: json path %q not found", e.Path)
+	}
+	return fmt.Sprintf("request: json path %q: %s", e.Path, e.Reason)
+}
+
+//Is makes JSONPathError match ErrPathNotFound
+func (e *JSONPathError) Is(target error) bool {
+	return target == ErrPathNotFound
+}
+
+//maxErrorBodyLen is how much of the body HTTPError.Error includes
+const maxErrorBodyLen = 512
+
+//HTTPError describes a response with a non-2xx status code. Its message includes at most the first 512 bytes
+//of the body, Body holds all of it
+type HTTPError struct {
+	StatusCode int
+	//Failure is the decoded failure body when a Failure struct was set
+	Failure interface{}
+	//Body is the raw response body
+	Body []byte
+}
+
+func (e *HTTPError) Error() string {
+	message := fmt.Sprintf("request: failed with status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if len(e.Body) == 0 {
+		return message
+	}
+	if len(e.Body) <= maxErrorBodyLen {
+		return message + ": " + string(e.Body)
+	}
+
+	//do not cut a multi-byte character in half
+	cut := maxErrorBodyLen
+	for cut > maxErrorBodyLen-utf8.UTFMax && !utf8.RuneStart(e.Body[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s: %s... (%d bytes truncated)", message, e.Body[:cut], len(e.Body)-cut)
+}
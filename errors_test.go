@@ -0,0 +1,170 @@
+package request
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestErrorClassification(t *testing.T) {
+	wrap := func(err error) error {
+		return &url.Error{Op: "Get", URL: "http://example.com", Err: err}
+	}
+
+	refused := wrap(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)})
+	reset := wrap(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)})
+	dialTimeout := wrap(&net.OpError{Op: "dial", Net: "tcp", Err: timeoutError{}})
+	dns := wrap(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}})
+	deadline := wrap(context.DeadlineExceeded)
+	unknownAuthority := wrap(&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}})
+	hostname := wrap(x509.HostnameError{Certificate: &x509.Certificate{}, Host: "example.com"})
+	alert := wrap(tls.AlertError(40))
+	record := wrap(tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"})
+	decode := &DecodeError{Err: &json.SyntaxError{}}
+	wrappedDecode := fmt.Errorf("page 2: %w", decode)
+	other := errors.New("something else")
+
+	cases := []struct {
+		name       string
+		err        error
+		timeout    bool
+		connection bool
+		dns        bool
+		tls        bool
+		decode     bool
+	}{
+		{"connection refused", refused, false, true, false, false, false},
+		{"connection reset", reset, false, true, false, false, false},
+		{"dial timeout", dialTimeout, true, false, false, false, false},
+		{"dns", dns, false, false, true, false, false},
+		{"context deadline", deadline, true, false, false, false, false},
+		{"unknown authority", unknownAuthority, false, false, false, true, false},
+		{"hostname mismatch", hostname, false, false, false, true, false},
+		{"tls alert", alert, false, false, false, true, false},
+		{"not tls", record, false, false, false, true, false},
+		{"decode", decode, false, false, false, false, true},
+		{"wrapped decode", wrappedDecode, false, false, false, false, true},
+		{"other", other, false, false, false, false, false},
+		{"nil", nil, false, false, false, false, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.timeout, IsTimeout(c.err), "IsTimeout")
+			assert.Equal(t, c.connection, IsConnectionError(c.err), "IsConnectionError")
+			assert.Equal(t, c.dns, IsDNSError(c.err), "IsDNSError")
+			assert.Equal(t, c.tls, IsTLSError(c.err), "IsTLSError")
+			assert.Equal(t, c.decode, IsDecodeError(c.err), "IsDecodeError")
+		})
+	}
+}
+
+func TestExecuteErrorsAreClassified(t *testing.T) {
+	t.Run("decode", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(200, `not json`, nil))
+
+		result, err := r.Get("http://example.com").SetSuccess(&fakeSuccess{}).Execute()
+		assert.True(t, IsDecodeError(err))
+		assert.Equal(t, 200, result.StatusCode)
+
+		var syntaxErr *json.SyntaxError
+		assert.True(t, errors.As(err, &syntaxErr))
+	})
+
+	t.Run("connection", func(t *testing.T) {
+		refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+		r := &Request{client: &errClient{err: &url.Error{Op: "Get", URL: "http://example.com", Err: refused}}}
+
+		_, err := r.Get("http://example.com").Execute()
+		assert.True(t, IsConnectionError(err))
+	})
+}
+
+func TestSentinelErrors(t *testing.T) {
+	t.Run("no url", func(t *testing.T) {
+		_, err := New().Execute()
+		assert.True(t, errors.Is(err, ErrNoURL))
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		_, err := New().Get("http://example.com").SetQuery(map[string]string{"id": "1"}).Execute()
+		assert.True(t, errors.Is(err, ErrInvalidQuery))
+		assert.Contains(t, err.Error(), "expects struct input")
+
+		_, err = New().Get("http://example.com").SetQuery([]string{"id"}).Request()
+		assert.True(t, errors.Is(err, ErrInvalidQuery))
+	})
+
+	t.Run("response too large", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(200, `{"id":200, "name":"John"}`, nil))
+
+		result, err := r.Get("http://example.com").SetMaxBodySize(10).Execute()
+		assert.True(t, errors.Is(err, ErrResponseTooLarge))
+		assert.EqualError(t, err, "request: response body too large: limit is 10 bytes")
+		assert.Equal(t, 200, result.StatusCode)
+
+		result, err = r.SetMaxBodySize(100).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, `{"id":200, "name":"John"}`, string(result.Body))
+	})
+
+	t.Run("path not found", func(t *testing.T) {
+		_, err := (&Response{Body: []byte(`{"a": []}`)}).JSONPath("a.0")
+
+		var pathErr *JSONPathError
+		assert.True(t, errors.Is(err, ErrPathNotFound))
+		assert.True(t, errors.As(err, &pathErr))
+		assert.Equal(t, "a.0", pathErr.Path)
+	})
+
+	t.Run("decode", func(t *testing.T) {
+		_, err := (&Response{Body: []byte(`{`)}).JSONPath("a")
+
+		var decodeErr *DecodeError
+		assert.True(t, errors.As(err, &decodeErr))
+		assert.NotNil(t, errors.Unwrap(decodeErr))
+	})
+
+	t.Run("http", func(t *testing.T) {
+		err := (&Response{StatusCode: 500}).Err()
+
+		var httpErr *HTTPError
+		assert.True(t, errors.As(err, &httpErr))
+		assert.Equal(t, 500, httpErr.StatusCode)
+	})
+
+	t.Run("http body truncated", func(t *testing.T) {
+		body := strings.Repeat("a", maxErrorBodyLen-1) + "é" + strings.Repeat("b", 100)
+		httpErr := &HTTPError{StatusCode: 502, Body: []byte(body)}
+
+		message := httpErr.Error()
+		assert.True(t, strings.HasPrefix(message, "request: failed with status 502 Bad Gateway: aaa"))
+		assert.True(t, strings.HasSuffix(message, strings.Repeat("a", maxErrorBodyLen-1)+"... (102 bytes truncated)"))
+		assert.Len(t, httpErr.Body, len(body))
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		for _, err := range []error{ErrRetryBudgetExceeded, ErrPathNotFound, ErrResponseVerification, ErrNotProblem, ErrCertificatePinMismatch,
+			&RetryBudgetError{}, &ResponseVerificationError{Err: errors.New("bad")}, &CertificatePinError{}, &JSONPathError{Path: "a"},
+			&HTTPError{StatusCode: 500}, &DecodeError{Err: errors.New("bad")}} {
+			assert.True(t, strings.HasPrefix(err.Error(), "request: "), err.Error())
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package request
+
+import (
+	"net/http"
+	"strings"
+)
+
+//parseETags splits an If-None-Match or ETag field value into its entity tags, keeping the weak prefix.
+//Commas are allowed inside entity tags so only commas outside quotes separate them. A * is returned as is
+func parseETags(value string) []string {
+	var tags []string
+	for value != "" {
+		value = strings.TrimLeft(value, " \t,")
+		if value == "" {
+			break
+		}
+		if value[0] == '*' {
+			tags = append(tags, "*")
+			value = value[1:]
+			continue
+		}
+
+		start := 0
+		if strings.HasPrefix(value, "W/") {
+			start = 2
+		}
+		if len(value) <= start || value[start] != '"' {
+			//not an entity tag, skip to the next one
+			if i := strings.IndexByte(value, ','); i >= 0 {
+				value = value[i:]
+				continue
+			}
+			break
+		}
+		end := strings.IndexByte(value[start+1:], '"')
+		if end < 0 {
+			break
+		}
+		end += start + 2
+		tags = append(tags, value[:end])
+		value = value[end:]
+	}
+	return tags
+}
+
+//weakMatch reports whether two entity tags match using the weak comparison of RFC 9110 section 8.8.3.2,
+//which If-None-Match uses: their opaque tags are equal whether or not either is weak
+func weakMatch(a, b string) bool {
+	a, b = strings.TrimPrefix(a, "W/"), strings.TrimPrefix(b, "W/")
+	return a != "" && a == b && strings.HasPrefix(a, `"`)
+}
+
+//addIfNoneMatch adds etag to the If-None-Match header of req, keeping the entity tags set by the caller,
+//and reports whether it was added. Nothing is added when the caller's header already matches any tag
+func addIfNoneMatch(req *http.Request, etag string) bool {
+	existing := parseETags(strings.Join(req.Header.Values("If-None-Match"), ", "))
+	for _, tag := range existing {
+		if tag == "*" || weakMatch(tag, etag) {
+			return false
+		}
+	}
+
+	req.Header.Set("If-None-Match", strings.Join(append(existing, etag), ", "))
+	return true
+}
+
+//notModifiedFor reports whether a 304 response confirms etag. A 304 without an ETag can only be attributed
+//to etag when it was the only tag sent, otherwise it is left to the caller who sent the other tags
+func notModifiedFor(resp *Response, req *http.Request, etag string) bool {
+	if resp.StatusCode != http.StatusNotModified {
+		return false
+	}
+	if tag := resp.Header.Get("ETag"); tag != "" {
+		return weakMatch(tag, etag)
+	}
+	return len(parseETags(req.Header.Get("If-None-Match"))) == 1
+}
+
+//hasValidator reports whether resp carries an ETag or a Last-Modified date it can be revalidated with
+func hasValidator(resp *Response) bool {
+	return resp.Header.Get("ETag") != "" || lastModified(resp) != ""
+}
+
+//addConditions makes req conditional on the validators of the stale response, if any, and returns a func
+//reporting whether a response confirms the stale one. It returns nil when the conditions set by the caller
+//already decide the outcome, so their 304 is passed through. If-Modified-Since is ignored by servers when
+//If-None-Match is present, see RFC 9110 section 13.1.3, so the ETag decides whenever there is one
+func addConditions(req *http.Request, stale *Response) func(resp *Response) bool {
+	if stale == nil {
+		return nil
+	}
+
+	date := lastModified(stale)
+	if etag := stale.Header.Get("ETag"); etag != "" {
+		if !addIfNoneMatch(req, etag) {
+			return nil
+		}
+		if date != "" && req.Header.Get("If-Modified-Since") == "" {
+			req.Header.Set("If-Modified-Since", date)
+		}
+		return func(resp *Response) bool {
+			return notModifiedFor(resp, req, etag)
+		}
+	}
+
+	if date == "" || req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return nil
+	}
+	req.Header.Set("If-Modified-Since", date)
+	return func(resp *Response) bool {
+		return resp.StatusCode == http.StatusNotModified
+	}
+}
@@ -0,0 +1,170 @@
+package request
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseETags(t *testing.T) {
+	for value, expected := range map[string][]string{
+		`"a"`:                   {`"a"`},
+		`W/"a", "b"`:            {`W/"a"`, `"b"`},
+		`"a,b" ,W/"c"`:          {`"a,b"`, `W/"c"`},
+		`*`:                     {"*"},
+		`bogus, "a"`:            {`"a"`},
+		`"unterminated`:         nil,
+		``:                      nil,
+		` W/"x" , , "y"  `:      {`W/"x"`, `"y"`},
+		`"1", W/"2", "3", W/""`: {`"1"`, `W/"2"`, `"3"`, `W/""`},
+	} {
+		assert.Equal(t, expected, parseETags(value), value)
+	}
+}
+
+func TestWeakMatch(t *testing.T) {
+	assert.True(t, weakMatch(`"1"`, `"1"`))
+	assert.True(t, weakMatch(`W/"1"`, `"1"`))
+	assert.True(t, weakMatch(`W/"1"`, `W/"1"`))
+	assert.False(t, weakMatch(`"1"`, `"2"`))
+	assert.False(t, weakMatch(`W/"1"`, `W/"2"`))
+	assert.False(t, weakMatch(`1`, `1`))
+}
+
+func TestETagRevalidation(t *testing.T) {
+	var conditions []string
+	newTemplate := func(etag string) *Request {
+		conditions = nil
+		return newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			condition := req.Header.Get("If-None-Match")
+			conditions = append(conditions, condition)
+			for _, tag := range parseETags(condition) {
+				if weakMatch(tag, etag) {
+					w.Header().Set("ETag", etag)
+					w.Header().Set("X-Checked", "yes")
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+			}
+			w.Header().Set("ETag", etag)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ID":1,"Name":"John"}`))
+		})
+	}
+
+	t.Run("304 serves the cached response", func(t *testing.T) {
+		template := newTemplate(`"v1"`).EnableCache(0, 10)
+
+		first, err := template.New().Get("http://example.com/config").Execute()
+		assert.Nil(t, err)
+		assert.False(t, first.Revalidated)
+
+		success := &fakeSuccess{}
+		second, err := template.New().Get("http://example.com/config").SetSuccess(success).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, second.StatusCode)
+		assert.True(t, second.Revalidated)
+		assert.False(t, second.FromCache)
+		assert.Equal(t, "yes", second.Header.Get("X-Checked"))
+		assert.Equal(t, "application/json", second.Header.Get("Content-Type"))
+		assert.Equal(t, &fakeSuccess{ID: 1, Name: "John"}, success)
+		assert.Equal(t, []string{"", `"v1"`}, conditions)
+		assert.Nil(t, second.Err())
+	})
+
+	t.Run("fresh responses are not revalidated", func(t *testing.T) {
+		clock := newFakeClock()
+		template := newTemplate(`"v1"`).EnableCache(time.Minute, 10).SetClock(clock)
+
+		template.New().Get("http://example.com/config").Execute()
+		resp, _ := template.New().Get("http://example.com/config").Execute()
+		assert.True(t, resp.FromCache)
+		assert.Equal(t, []string{""}, conditions)
+
+		clock.Advance(time.Minute)
+		resp, _ = template.New().Get("http://example.com/config").Execute()
+		assert.True(t, resp.Revalidated)
+		resp, _ = template.New().Get("http://example.com/config").Execute()
+		assert.True(t, resp.FromCache)
+		assert.False(t, resp.Revalidated)
+		assert.Equal(t, []string{"", `"v1"`}, conditions)
+	})
+
+	t.Run("weak etag", func(t *testing.T) {
+		template := newTemplate(`W/"v1"`).EnableCache(0, 10)
+
+		template.New().Get("http://example.com/config").Execute()
+		resp, err := template.New().Get("http://example.com/config").Execute()
+		assert.Nil(t, err)
+		assert.True(t, resp.Revalidated)
+		assert.Equal(t, []string{"", `W/"v1"`}, conditions)
+	})
+
+	t.Run("changed representation replaces the cached one", func(t *testing.T) {
+		etag := `"v1"`
+		calls := 0
+		template := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			calls++
+			if calls == 2 {
+				etag = `"v2"`
+			}
+			if req.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			w.Write([]byte(`{"ID":` + etag[2:3] + `}`))
+		}).EnableCache(0, 10)
+
+		for i, expected := range []int{1, 2, 2} {
+			success := &fakeSuccess{}
+			resp, err := template.New().Get("http://example.com/config").SetSuccess(success).Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, expected, success.ID)
+			assert.Equal(t, i == 2, resp.Revalidated)
+		}
+	})
+
+	t.Run("caller's own tags are kept", func(t *testing.T) {
+		template := newTemplate(`"v1"`).EnableCache(0, 10)
+
+		template.New().Get("http://example.com/config").Execute()
+		resp, err := template.New().Get("http://example.com/config").SetHeader("If-None-Match", `"old", W/"older"`).Execute()
+		assert.Nil(t, err)
+		assert.True(t, resp.Revalidated)
+		assert.Equal(t, `"old", W/"older", "v1"`, conditions[1])
+	})
+
+	t.Run("a 304 for the caller's tag is passed through", func(t *testing.T) {
+		template := newTemplate(`"v1"`).EnableCache(0, 10)
+
+		template.New().Get("http://example.com/config").Execute()
+		resp, err := template.New().Get("http://example.com/config").SetHeader("If-None-Match", `W/"v1"`).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+		assert.False(t, resp.Revalidated)
+		assert.Equal(t, `W/"v1"`, conditions[1])
+	})
+
+	t.Run("bypass sends no condition", func(t *testing.T) {
+		template := newTemplate(`"v1"`).EnableCache(0, 10)
+
+		template.New().Get("http://example.com/config").Execute()
+		resp, err := template.New().Get("http://example.com/config").NoCache().Execute()
+		assert.Nil(t, err)
+		assert.False(t, resp.Revalidated)
+		assert.Equal(t, []string{"", ""}, conditions)
+	})
+
+	t.Run("expired responses without an etag are not kept", func(t *testing.T) {
+		template := newMockRequest(fakeHandler(200, `{"ID":1}`, nil)).EnableCache(0, 10)
+
+		template.New().Get("http://example.com/config").Execute()
+		assert.Equal(t, 0, template.cache.Len())
+		resp, _ := template.New().Get("http://example.com/config").Execute()
+		assert.False(t, resp.Revalidated)
+		assert.False(t, resp.FromCache)
+	})
+}
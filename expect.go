@@ -0,0 +1,29 @@
+package request
+
+import (
+	"net/http"
+	"time"
+)
+
+const defaultExpectContinueTimeout = time.Second
+
+//SetExpectContinue sends the Expect: 100-continue header when enabled, so the server can reject a request
+//before its body is uploaded. The transport waits up to one second for the server's first response
+//before sending the body anyway. The client and its transport are copied so requests sharing them are not affected
+func (r *Request) SetExpectContinue(enabled bool) *Request {
+	if r == nil {
+		return nil
+	}
+	if !enabled {
+		r.header.Del("Expect")
+		return r
+	}
+
+	r.header.Set("Expect", "100-continue")
+	r.configureTransport(func(transport *http.Transport) {
+		if transport.ExpectContinueTimeout == 0 {
+			transport.ExpectContinueTimeout = defaultExpectContinueTimeout
+		}
+	})
+	return r
+}
@@ -0,0 +1,43 @@
+package request
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetExpectContinue(t *testing.T) {
+	var expect string
+	var received int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expect = r.Header.Get("Expect")
+		body, _ := ioutil.ReadAll(r.Body)
+		received = len(body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	payload := bytes.Repeat([]byte("x"), 1<<16)
+	r := New().Post(server.URL).SetBody(bytes.NewReader(payload)).SetExpectContinue(true)
+
+	req, err := r.Request()
+	assert.Nil(t, err)
+	assert.Equal(t, "100-continue", req.Header.Get("Expect"))
+	assert.Equal(t, defaultExpectContinueTimeout, r.client.(*http.Client).Transport.(*http.Transport).ExpectContinueTimeout)
+
+	result, err := r.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 201, result.StatusCode)
+	assert.Equal(t, "100-continue", expect)
+	assert.Equal(t, len(payload), received)
+
+	t.Run("disabled", func(t *testing.T) {
+		req, err := New().Post(server.URL).SetExpectContinue(true).SetExpectContinue(false).Request()
+		assert.Nil(t, err)
+		assert.Empty(t, req.Header.Get("Expect"))
+	})
+}
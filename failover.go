@@ -0,0 +1,233 @@
+package request
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultProbeInterval = 30 * time.Second
+
+//FailoverPolicy decides whether a result should be retried against the next base URL.
+//resp is nil when the request failed before a response was received, in which case err holds the transport error
+type FailoverPolicy func(resp *Response, err error) bool
+
+//DefaultFailoverPolicy fails over on transport errors and 5xx responses
+func DefaultFailoverPolicy(resp *Response, err error) bool {
+	if resp == nil {
+		return err != nil
+	}
+
+	return resp.StatusCode >= 500
+}
+
+//SetBaseURL sets the base URL which relative request URLs are resolved against
+func (r *Request) SetBaseURL(baseURL string) *Request {
+	return r.SetBaseURLs(baseURL)
+}
+
+//SetBaseURLs sets base URLs to fail over between. Relative request URLs are sent to the last base URL known to be good,
+//trying the next one whenever the failover policy says so. After failing over, the first base URL is probed again
+//once the probe interval has passed. Use SetHostSelector to spread requests across the base URLs instead.
+//The base URLs and their state are shared with every request derived from this one via New
+func (r *Request) SetBaseURLs(urls ...string) *Request {
+	if r == nil {
+		return nil
+	}
+	probe := r.probeInterval
+	if probe == 0 {
+		probe = defaultProbeInterval
+	}
+	r.endpoints = &endpointPool{urls: urls, probe: probe, selector: r.selector}
+	return r
+}
+
+//SetFailoverPolicy replaces the default failover policy
+func (r *Request) SetFailoverPolicy(policy FailoverPolicy) *Request {
+	if r == nil {
+		return nil
+	}
+	r.failPolicy = policy
+	return r
+}
+
+//SetFailoverProbeInterval sets how long a base URL that failed is avoided before it is tried again.
+//It may be called before or after SetBaseURLs. Called after, the request gets its own copy of the base URLs and
+//their state, so requests it shares them with are not affected
+func (r *Request) SetFailoverProbeInterval(interval time.Duration) *Request {
+	if r == nil {
+		return nil
+	}
+	r.probeInterval = interval
+	if r.endpoints != nil {
+		r.endpoints = r.endpoints.clone()
+		r.endpoints.probe = interval
+	}
+	return r
+}
+
+//usesEndpoints reports whether the request URL is resolved against the base URLs
+func (r *Request) usesEndpoints() bool {
+	if r.endpoints == nil || len(r.endpoints.urls) == 0 {
+		return false
+	}
+
+	u, err := url.Parse(r.url)
+	return err == nil && !u.IsAbs()
+}
+
+//failover tries req against every base URL in turn until the failover policy accepts a result
+func (r *Request) failover(req *http.Request, attempt int) (*Response, error) {
+	policy := r.failPolicy
+	if policy == nil {
+		policy = DefaultFailoverPolicy
+	}
+
+	var resp *Response
+	var err error
+	for _, i := range r.endpoints.order(r.now()) {
+		base := r.endpoints.urls[i]
+
+		endpointReq, buildErr := endpointRequest(req, joinURL(base, r.url))
+		if buildErr != nil {
+			return nil, buildErr
+		}
+
+		resp, err = r.exchange(endpointReq, attempt)
+		if resp != nil {
+			resp.Endpoint = base
+		}
+		if !policy(resp, err) {
+			r.endpoints.succeeded(i)
+			return resp, err
+		}
+		r.endpoints.failed(i, r.now())
+	}
+
+	return resp, err
+}
+
+//endpointRequest copies req, pointing it at address while keeping its query
+func endpointRequest(req *http.Request, address string) (*http.Request, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = req.URL.RawQuery
+
+	clone := req.Clone(req.Context())
+	clone.URL = u
+	clone.Host = ""
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+
+	return clone, nil
+}
+
+//joinURL appends a relative path to a base URL
+func joinURL(base, path string) string {
+	if path == "" {
+		return base
+	}
+	if strings.HasPrefix(path, "?") {
+		return base + path
+	}
+
+	return strings.TrimRight(base, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+//endpointPool tracks which base URLs are known to be good
+type endpointPool struct {
+	mu       sync.Mutex
+	urls     []string
+	current  int
+	failedAt []time.Time
+	probe    time.Duration
+	selector HostSelector
+}
+
+//clone returns a copy of the pool along with the state of its base URLs
+func (p *endpointPool) clone() *endpointPool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return &endpointPool{
+		urls:     p.urls,
+		current:  p.current,
+		failedAt: append([]time.Time(nil), p.failedAt...),
+		probe:    p.probe,
+		selector: p.selector,
+	}
+}
+
+//order returns the indexes of the base URLs in the order they should be tried.
+//Without a selector it starts from the last known good base URL, or the first one when it is due to be probed again.
+//With a selector it starts from a base URL selected among the healthy ones and tries unhealthy ones last
+func (p *endpointPool) order(now time.Time) []int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.selector == nil {
+		start := p.current
+		if start != 0 && !p.unhealthy(0, now) {
+			start = 0
+		}
+
+		order := make([]int, len(p.urls))
+		for i := range order {
+			order[i] = (start + i) % len(p.urls)
+		}
+		return order
+	}
+
+	var healthy, unhealthy []int
+	for i := range p.urls {
+		if p.unhealthy(i, now) {
+			unhealthy = append(unhealthy, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+	if len(healthy) == 0 {
+		healthy, unhealthy = unhealthy, nil
+	}
+
+	start := p.selector.Next(len(healthy))
+	order := make([]int, 0, len(p.urls))
+	for i := range healthy {
+		order = append(order, healthy[(start+i)%len(healthy)])
+	}
+	return append(order, unhealthy...)
+}
+
+//unhealthy reports whether base URL i failed within the probe interval before now
+func (p *endpointPool) unhealthy(i int, now time.Time) bool {
+	return i < len(p.failedAt) && !p.failedAt[i].IsZero() && now.Sub(p.failedAt[i]) < p.probe
+}
+
+func (p *endpointPool) succeeded(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.current = i
+	if i < len(p.failedAt) {
+		p.failedAt[i] = time.Time{}
+	}
+}
+
+func (p *endpointPool) failed(i int, now time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.failedAt == nil {
+		p.failedAt = make([]time.Time, len(p.urls))
+	}
+	p.failedAt[i] = now
+}
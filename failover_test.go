@@ -0,0 +1,191 @@
+package request
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/AidenHadisi/go-simple-request/requesttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoinURL(t *testing.T) {
+	cases := []struct {
+		base     string
+		path     string
+		expected string
+	}{
+		{"http://example.com", "/users", "http://example.com/users"},
+		{"http://example.com/", "users", "http://example.com/users"},
+		{"http://example.com/api/", "/users?id=1", "http://example.com/api/users?id=1"},
+		{"http://example.com/api", "", "http://example.com/api"},
+		{"http://example.com/api", "?id=1", "http://example.com/api?id=1"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, joinURL(c.base, c.path))
+	}
+}
+
+func TestSetBaseURL(t *testing.T) {
+	request, err := New().SetBaseURL("http://example.com/api").Get("/users").SetQuery(&fakeQuery{ID: 1}).Request()
+	assert.Nil(t, err)
+	assert.Equal(t, "http://example.com/api/users?id=1&name=", request.URL.String())
+
+	request, err = New().SetBaseURL("http://example.com/api").Get("http://other.com/users").Request()
+	assert.Nil(t, err)
+	assert.Equal(t, "http://other.com/users", request.URL.String())
+}
+
+func TestPath(t *testing.T) {
+	cases := []struct {
+		base     string
+		path     string
+		expected string
+	}{
+		{"http://example.com/api", "/users", "http://example.com/api/users"},
+		{"http://example.com/api", "users", "http://example.com/api/users"},
+		{"http://example.com/api/", "/users", "http://example.com/api/users"},
+		{"http://example.com/api/", "users/1?full=true", "http://example.com/api/users/1?full=true"},
+	}
+
+	for _, c := range cases {
+		request, err := New().SetBaseURL(c.base).Post("").Path(c.path).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, request.URL.String())
+		assert.Equal(t, "POST", request.Method)
+	}
+
+	t.Run("template", func(t *testing.T) {
+		template := New().SetBaseURL("http://example.com").Put("/users/1")
+		request, err := template.New().Path("users/2").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "PUT", request.Method)
+		assert.Equal(t, "http://example.com/users/2", request.URL.String())
+		assert.Equal(t, "/users/1", template.url)
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		var r *Request
+		assert.Nil(t, r.Path("/users"))
+	})
+}
+
+func TestFailoverDeadPrimary(t *testing.T) {
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+
+	var calls int32
+	live := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"id":200, "name":"John"}`))
+	}))
+	defer live.Close()
+
+	template := New().SetBaseURLs(dead.URL, live.URL)
+
+	result, err := template.New().Get("/users/200").SetSuccess(&fakeSuccess{}).Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, live.URL, result.Endpoint)
+	assert.Equal(t, &fakeSuccess{ID: 200, Name: "John"}, result.Success)
+
+	t.Run("remembers last known good", func(t *testing.T) {
+		assert.Equal(t, []int{1, 0}, template.endpoints.order(template.now()))
+
+		result, err := template.New().Get("/users/200").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, live.URL, result.Endpoint)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestFailoverRecovery(t *testing.T) {
+	var primaryDown int32 = 1
+	var primaryCalls int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&primaryCalls, 1)
+		if atomic.LoadInt32(&primaryDown) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer primary.Close()
+
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer secondary.Close()
+
+	clock := requesttest.NewFakeClock(time.Now())
+	template := New().SetClock(clock).SetBaseURLs(primary.URL, secondary.URL).SetFailoverProbeInterval(30 * time.Second)
+
+	result, err := template.New().Get("/").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, secondary.URL, result.Endpoint)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&primaryCalls))
+
+	//the primary is not probed again before the interval passes
+	result, err = template.New().Get("/").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, secondary.URL, result.Endpoint)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&primaryCalls))
+
+	atomic.StoreInt32(&primaryDown, 0)
+	clock.Advance(40 * time.Second)
+
+	result, err = template.New().Get("/").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, primary.URL, result.Endpoint)
+
+	result, err = template.New().Get("/").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, primary.URL, result.Endpoint)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&primaryCalls))
+}
+
+func TestFailoverSettingsBeforeBaseURLs(t *testing.T) {
+	selector := RoundRobin()
+	r := New().SetFailoverProbeInterval(time.Minute).SetHostSelector(selector).SetBaseURLs("http://a", "http://b")
+	assert.Equal(t, time.Minute, r.endpoints.probe)
+	assert.Equal(t, selector, r.endpoints.selector)
+
+	r = New().SetBaseURLs("http://a")
+	assert.Equal(t, defaultProbeInterval, r.endpoints.probe)
+	assert.Nil(t, r.endpoints.selector)
+}
+
+func TestFailoverProbeIntervalNotShared(t *testing.T) {
+	template := New().SetBaseURLs("http://a", "http://b")
+	sibling := template.New()
+	derived := template.New().SetFailoverProbeInterval(time.Minute)
+
+	assert.Equal(t, time.Minute, derived.endpoints.probe)
+	assert.Equal(t, defaultProbeInterval, template.endpoints.probe)
+	assert.Equal(t, defaultProbeInterval, sibling.endpoints.probe)
+	assert.Same(t, template.endpoints, sibling.endpoints)
+}
+
+func TestFailoverPolicy(t *testing.T) {
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer primary.Close()
+
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer secondary.Close()
+
+	onlyConnectionErrors := func(resp *Response, err error) bool {
+		return resp == nil && err != nil
+	}
+
+	result, err := New().SetBaseURLs(primary.URL, secondary.URL).SetFailoverPolicy(onlyConnectionErrors).Get("/").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, result.StatusCode)
+	assert.Equal(t, primary.URL, result.Endpoint)
+}
@@ -0,0 +1,65 @@
+package request
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+//SetBodyFromFile streams the file at path as the raw request body with the given content type.
+//The file is opened when the request is built, again for every retry, and its size is sent as Content-Length.
+//Request and Execute fail if the file cannot be opened
+func (r *Request) SetBodyFromFile(path, contentType string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.body = fileBody{path: path}
+	if contentType != "" {
+		r.header.Set("Content-Type", contentType)
+	}
+	return r
+}
+
+//fileBody is a request body read from a file
+type fileBody struct {
+	path string
+}
+
+//open opens the file, returning it along with its size
+func (f fileBody) open() (*os.File, int64, error) {
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open body file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to open body file: %w", err)
+	}
+
+	return file, info.Size(), nil
+}
+
+//setBody sets the file as the body of req so it can be sent again by reopening it
+func (f fileBody) setBody(req *http.Request) error {
+	file, size, err := f.open()
+	if err != nil {
+		return err
+	}
+
+	if size == 0 {
+		file.Close()
+		req.Body = http.NoBody
+		return nil
+	}
+
+	req.Body = file
+	req.ContentLength = size
+	req.GetBody = func() (io.ReadCloser, error) {
+		file, _, err := f.open()
+		return file, err
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package request
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetBodyFromFile(t *testing.T) {
+	payload := bytes.Repeat([]byte("raw file body "), 1000)
+	path := filepath.Join(t.TempDir(), "upload.bin")
+	assert.Nil(t, os.WriteFile(path, payload, 0o600))
+
+	var bodies [][]byte
+	var lengths []int64
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, body)
+		lengths = append(lengths, r.ContentLength)
+		contentType = r.Header.Get("Content-Type")
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	result, err := New().Put(server.URL).SetBodyFromFile(path, "application/octet-stream").SetRetry(1, 0).Execute()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, result.StatusCode)
+	assert.Equal(t, "application/octet-stream", contentType)
+	//the file is reopened for the retry
+	assert.Equal(t, [][]byte{payload, payload}, bodies)
+	assert.Equal(t, []int64{int64(len(payload)), int64(len(payload))}, lengths)
+
+	t.Run("missing file", func(t *testing.T) {
+		req, err := New().Put(server.URL).SetBodyFromFile(filepath.Join(t.TempDir(), "missing"), "text/plain").Request()
+		assert.Nil(t, req)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
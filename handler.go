@@ -0,0 +1,148 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+//SetHandler replaces the client with one running h in process for every request, without a network listener,
+//for hermetic tests of code using the package against a service's own handler. The handler receives the request's
+//context, so canceling it is observed by the handler, and the response body is streamed to the caller as the
+//handler writes and flushes it. A panic in the handler fails the request with an error
+func (r *Request) SetHandler(h http.Handler) *Request {
+	if r == nil {
+		return nil
+	}
+	r.client = &handlerClient{handler: h}
+	return r
+}
+
+//handlerClient sends requests to an http.Handler in process
+type handlerClient struct {
+	handler http.Handler
+}
+
+func (c *handlerClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	inbound := req.Clone(ctx)
+	inbound.RequestURI = req.URL.RequestURI()
+	inbound.RemoteAddr = "192.0.2.1:1234"
+	inbound.Proto, inbound.ProtoMajor, inbound.ProtoMinor = "HTTP/1.1", 1, 1
+	if inbound.Host == "" {
+		inbound.Host = req.URL.Host
+	}
+	if inbound.Body == nil {
+		inbound.Body = http.NoBody
+	}
+
+	pr, pw := io.Pipe()
+	w := &handlerWriter{header: make(http.Header), body: pw, ready: make(chan struct{})}
+	stop := context.AfterFunc(ctx, func() {
+		pr.CloseWithError(ctx.Err())
+	})
+
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err := fmt.Errorf("handler panic: %v", recovered)
+				w.fail(err)
+				pw.CloseWithError(err)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			pw.Close()
+		}()
+		c.handler.ServeHTTP(w, inbound)
+	}()
+
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		stop()
+		return nil, ctx.Err()
+	}
+	if w.err != nil {
+		stop()
+		return nil, w.err
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", w.status, http.StatusText(w.status)),
+		StatusCode:    w.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        w.sent,
+		Body:          &handlerBody{PipeReader: pr, stop: stop},
+		ContentLength: -1,
+		Request:       req,
+	}, nil
+}
+
+//handlerWriter is the http.ResponseWriter of a handler run in process, streaming the body through a pipe
+type handlerWriter struct {
+	header http.Header
+	body   *io.PipeWriter
+
+	once   sync.Once
+	ready  chan struct{}
+	status int
+	sent   http.Header
+	err    error
+}
+
+func (w *handlerWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *handlerWriter) WriteHeader(status int) {
+	w.once.Do(func() {
+		w.status = status
+		w.sent = w.header.Clone()
+		close(w.ready)
+	})
+}
+
+func (w *handlerWriter) Write(b []byte) (int, error) {
+	if w.header.Get("Content-Type") == "" {
+		w.once.Do(func() {
+			w.header.Set("Content-Type", http.DetectContentType(b))
+			w.status = http.StatusOK
+			w.sent = w.header.Clone()
+			close(w.ready)
+		})
+	}
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+//Flush sends the headers if they were not yet, the body is never buffered
+func (w *handlerWriter) Flush() {
+	w.WriteHeader(http.StatusOK)
+}
+
+//fail reports err instead of a response when the headers were not sent yet
+func (w *handlerWriter) fail(err error) {
+	w.once.Do(func() {
+		w.err = err
+		close(w.ready)
+	})
+}
+
+//handlerBody stops watching the request context once the body is closed
+type handlerBody struct {
+	*io.PipeReader
+	stop func() bool
+}
+
+func (b *handlerBody) Close() error {
+	b.stop()
+	return b.PipeReader.Close()
+}
@@ -0,0 +1,150 @@
+package request
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMux(attempts *int) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ID":1,"Name":"John"}`))
+	})
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var user fakeSuccess
+		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		user.ID = 2
+		w.Header().Set("Location", "/users/2")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(user)
+	})
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		*attempts++
+		if *attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"ID":3}`))
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("["))
+		for i := 0; i < 5; i++ {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			fmt.Fprintf(w, `{"ID":%d}`, i)
+			w.(http.Flusher).Flush()
+		}
+		w.Write([]byte("]"))
+	})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	return mux
+}
+
+func TestSetHandler(t *testing.T) {
+	attempts := 0
+	template := New().SetHandler(newTestMux(&attempts))
+
+	t.Run("decodes", func(t *testing.T) {
+		var user fakeSuccess
+		result, err := template.New().Get("http://api.test/users/1").SetSuccess(&user).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, fakeSuccess{ID: 1, Name: "John"}, user)
+	})
+
+	t.Run("sends body", func(t *testing.T) {
+		var user fakeSuccess
+		var hooked bool
+		result, err := template.New().Post("http://api.test/users").
+			SetBody(&fakeSuccess{Name: "Jane"}).
+			SetSuccess(&user).
+			OnResponse(func(_ *Request, resp *Response) error {
+				hooked = resp.Header.Get("Location") == "/users/2"
+				return nil
+			}).
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 201, result.StatusCode)
+		assert.Equal(t, fakeSuccess{ID: 2, Name: "Jane"}, user)
+		assert.True(t, hooked)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		result, err := template.New().Get("http://api.test/missing").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 404, result.StatusCode)
+		assert.Equal(t, "text/plain; charset=utf-8", result.Header.Get("Content-Type"))
+	})
+
+	t.Run("retries", func(t *testing.T) {
+		result, err := template.New().Get("http://api.test/flaky").SetRetry(3, 0).SetClock(newFakeClock()).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, 3, attempts)
+	})
+
+	t.Run("context", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := template.New().Get("http://api.test/slow").SetContext(ctx).Execute()
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+
+	t.Run("streams", func(t *testing.T) {
+		result, err := template.New().Get("http://api.test/stream").ExecuteStream()
+		assert.Nil(t, err)
+
+		var ids []int
+		err = result.DecodeArrayStream(func(raw json.RawMessage) error {
+			var item fakeSuccess
+			json.Unmarshal(raw, &item)
+			ids = append(ids, item.ID)
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []int{0, 1, 2, 3, 4}, ids)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		_, err := template.New().Get("http://api.test/panic").Execute()
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "boom")
+	})
+
+	t.Run("request as seen by the handler", func(t *testing.T) {
+		var seen *http.Request
+		var body []byte
+		r := New().SetHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = r
+			body, _ = ioutil.ReadAll(r.Body)
+		}))
+		_, err := r.Get("http://api.test/path?q=1").SetHeader("X-Test", "yes").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "/path?q=1", seen.RequestURI)
+		assert.Equal(t, "api.test", seen.Host)
+		assert.Equal(t, "yes", seen.Header.Get("X-Test"))
+		assert.Empty(t, body)
+	})
+}
@@ -0,0 +1,381 @@
+package request
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+//HARRecorder records the exchanges of requests as HAR 1.2 entries, see AttachHAR.
+//The zero value is ready to use and it is safe for concurrent use
+type HARRecorder struct {
+	mu      sync.Mutex
+	entries []harEntry
+}
+
+//AttachHAR records every exchange of the request with the server in rec, including retries. Headers are redacted
+//as set with RedactHeaders and API keys placed in the query are redacted. Response bodies are buffered in memory
+//and request bodies are recorded unless they are streamed. The recorder is shared with every request derived
+//from this one via New. A nil rec stops recording
+func (r *Request) AttachHAR(rec *HARRecorder) *Request {
+	if r == nil {
+		return nil
+	}
+	r.har = rec
+	return r
+}
+
+//Len returns the number of recorded entries
+func (h *HARRecorder) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+//WriteTo writes the recorded entries to w as a HAR 1.2 log in JSON
+func (h *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	h.mu.Lock()
+	entries := append([]harEntry{}, h.entries...)
+	h.mu.Unlock()
+
+	data, err := json.MarshalIndent(harFile{Log: harLog{
+		Version: "1.2",
+		Creator: harCreator{Name: "go-simple-request"},
+		Entries: entries,
+	}}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+func (h *HARRecorder) add(entry harEntry) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry)
+}
+
+type harFile struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Error           string      `json:"_error,omitempty"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int64          `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Expires  string `json:"expires,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string         `json:"mimeType"`
+	Params   []harNameValue `json:"params"`
+	Text     string         `json:"text"`
+}
+
+type harContent struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+//harTimings are in milliseconds, -1 when they do not apply
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+//harTrace collects the times of connection events of an exchange
+type harTrace struct {
+	mu                                      sync.Mutex
+	now                                     func() time.Time
+	getConn, dnsStart, dnsDone              time.Time
+	connectStart, connectDone               time.Time
+	tlsStart, tlsDone, gotConn, wrote, ttfb time.Time
+}
+
+func (t *harTrace) mark(at *time.Time) func() {
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if at.IsZero() {
+			*at = t.now()
+		}
+	}
+}
+
+func (t *harTrace) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		GetConn:              func(string) { t.mark(&t.getConn)() },
+		DNSStart:             func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart)() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.mark(&t.dnsDone)() },
+		ConnectStart:         func(string, string) { t.mark(&t.connectStart)() },
+		ConnectDone:          func(string, string, error) { t.mark(&t.connectDone)() },
+		TLSHandshakeStart:    t.mark(&t.tlsStart),
+		TLSHandshakeDone:     func(_ tls.ConnectionState, _ error) { t.mark(&t.tlsDone)() },
+		GotConn:              func(httptrace.GotConnInfo) { t.mark(&t.gotConn)() },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { t.mark(&t.wrote)() },
+		GotFirstResponseByte: t.mark(&t.ttfb),
+	}
+}
+
+//timings splits the exchange from start to end into HAR timings. When the transport reported no events,
+//such as with a custom client, the time until the response is counted as waiting
+func (t *harTrace) timings(start, responded, end time.Time) harTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timings := harTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1}
+	if t.wrote.IsZero() || t.ttfb.IsZero() {
+		timings.Wait = millis(start, responded)
+		timings.Receive = millis(responded, end)
+		return timings
+	}
+
+	connStart := t.gotConn
+	for _, at := range []time.Time{t.connectStart, t.dnsStart} {
+		if !at.IsZero() && at.Before(connStart) {
+			connStart = at
+		}
+	}
+	if !t.getConn.IsZero() {
+		timings.Blocked = millis(t.getConn, connStart)
+	}
+	if !t.dnsStart.IsZero() && !t.dnsDone.IsZero() {
+		timings.DNS = millis(t.dnsStart, t.dnsDone)
+	}
+	if !t.connectStart.IsZero() && !t.connectDone.IsZero() {
+		//HAR counts the TLS handshake as part of connecting
+		connected := t.connectDone
+		if t.tlsDone.After(connected) {
+			connected = t.tlsDone
+		}
+		timings.Connect = millis(t.connectStart, connected)
+	}
+	if !t.tlsStart.IsZero() && !t.tlsDone.IsZero() {
+		timings.SSL = millis(t.tlsStart, t.tlsDone)
+	}
+	timings.Send = millis(t.gotConn, t.wrote)
+	timings.Wait = millis(t.wrote, t.ttfb)
+	timings.Receive = millis(t.ttfb, end)
+	return timings
+}
+
+func (h harTimings) total() float64 {
+	var total float64
+	for _, timing := range []float64{h.Blocked, h.DNS, h.Connect, h.Send, h.Wait, h.Receive} {
+		if timing > 0 {
+			total += timing
+		}
+	}
+	return total
+}
+
+func millis(from, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return float64(to.Sub(from)) / float64(time.Millisecond)
+}
+
+//recordHAR sends req with send and records the exchange, buffering the response body
+func (r *Request) recordHAR(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	entry := harEntry{Request: r.harRequest(req)}
+
+	trace := &harTrace{now: r.now}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace.clientTrace()))
+
+	start := r.now()
+	resp, err := send(req)
+	responded := r.now()
+	if err != nil {
+		entry.Error = err.Error()
+		entry.Response = harResponse{Cookies: []harCookie{}, Headers: []harNameValue{}, HeadersSize: -1}
+	} else {
+		body, readErr := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+		if readErr != nil {
+			return nil, readErr
+		}
+		entry.Response = r.harResponse(resp, body)
+	}
+
+	entry.StartedDateTime = start.Format(time.RFC3339Nano)
+	entry.Timings = trace.timings(start, responded, r.now())
+	entry.Time = entry.Timings.total()
+	r.har.add(entry)
+	return resp, err
+}
+
+func (r *Request) harRequest(req *http.Request) harRequest {
+	header := r.RedactHeader(req.Header)
+	u := *req.URL
+	params := r.redactQuery(u.Query(), nil)
+	if u.RawQuery != "" {
+		u.RawQuery = params.Encode()
+	}
+
+	entry := harRequest{
+		Method:      req.Method,
+		URL:         u.String(),
+		HTTPVersion: req.Proto,
+		Cookies:     harCookies((&http.Request{Header: header}).Cookies()),
+		Headers:     harHeaders(header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range params[key] {
+			entry.QueryString = append(entry.QueryString, harNameValue{Name: key, Value: value})
+		}
+	}
+
+	switch {
+	case req.Body == nil || req.Body == http.NoBody:
+	case req.GetBody == nil:
+		//streamed bodies cannot be read without consuming them
+		entry.BodySize = -1
+	default:
+		body, err := bodyBytes(req)
+		if err != nil {
+			entry.BodySize = -1
+			break
+		}
+		entry.BodySize = int64(len(body))
+		entry.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Params: []harNameValue{}, Text: string(body)}
+	}
+	return entry
+}
+
+func (r *Request) harResponse(resp *http.Response, body []byte) harResponse {
+	header := r.RedactHeader(resp.Header)
+	content := harContent{Size: int64(len(body)), MimeType: resp.Header.Get("Content-Type"), Text: string(body)}
+	if !utf8.Valid(body) {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+
+	return harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  strings.TrimSpace(strings.TrimPrefix(resp.Status, strconv.Itoa(resp.StatusCode))),
+		HTTPVersion: resp.Proto,
+		Cookies:     harCookies((&http.Response{Header: header}).Cookies()),
+		Headers:     harHeaders(header),
+		Content:     content,
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    int64(len(body)),
+	}
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	headers := []harNameValue{}
+	for _, name := range names {
+		for _, value := range header[name] {
+			headers = append(headers, harNameValue{Name: name, Value: value})
+		}
+	}
+	return headers
+}
+
+func harCookies(cookies []*http.Cookie) []harCookie {
+	converted := []harCookie{}
+	for _, cookie := range cookies {
+		c := harCookie{
+			Name:     cookie.Name,
+			Value:    cookie.Value,
+			Path:     cookie.Path,
+			Domain:   cookie.Domain,
+			HTTPOnly: cookie.HttpOnly,
+			Secure:   cookie.Secure,
+		}
+		if !cookie.Expires.IsZero() {
+			c.Expires = cookie.Expires.Format(time.RFC3339)
+		}
+		converted = append(converted, c)
+	}
+	return converted
+}
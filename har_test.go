@@ -0,0 +1,175 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//assertHARSchema checks v has the members required by the HAR 1.2 schema with the expected types
+func assertHARSchema(t *testing.T, v map[string]interface{}) {
+	log, ok := v["log"].(map[string]interface{})
+	if !assert.True(t, ok, "log") {
+		return
+	}
+	assert.Equal(t, "1.2", log["version"])
+	creator, _ := log["creator"].(map[string]interface{})
+	assert.IsType(t, "", creator["name"])
+	assert.IsType(t, "", creator["version"])
+
+	entries, ok := log["entries"].([]interface{})
+	assert.True(t, ok, "entries")
+	for _, e := range entries {
+		entry := e.(map[string]interface{})
+		_, err := time.Parse(time.RFC3339Nano, entry["startedDateTime"].(string))
+		assert.Nil(t, err)
+		assert.GreaterOrEqual(t, entry["time"].(float64), float64(0))
+		assert.IsType(t, map[string]interface{}{}, entry["cache"])
+
+		request := entry["request"].(map[string]interface{})
+		for _, key := range []string{"method", "url", "httpVersion"} {
+			assert.IsType(t, "", request[key], key)
+		}
+		for _, key := range []string{"cookies", "headers", "queryString"} {
+			assert.IsType(t, []interface{}{}, request[key], key)
+		}
+		for _, key := range []string{"headersSize", "bodySize"} {
+			assert.IsType(t, float64(0), request[key], key)
+		}
+		if postData, ok := request["postData"].(map[string]interface{}); ok {
+			assert.IsType(t, "", postData["mimeType"])
+			assert.IsType(t, "", postData["text"])
+		}
+
+		response := entry["response"].(map[string]interface{})
+		for _, key := range []string{"statusText", "httpVersion", "redirectURL"} {
+			assert.IsType(t, "", response[key], key)
+		}
+		for _, key := range []string{"status", "headersSize", "bodySize"} {
+			assert.IsType(t, float64(0), response[key], key)
+		}
+		for _, key := range []string{"cookies", "headers"} {
+			assert.IsType(t, []interface{}{}, response[key], key)
+		}
+		content := response["content"].(map[string]interface{})
+		assert.IsType(t, float64(0), content["size"])
+		assert.IsType(t, "", content["mimeType"])
+
+		timings := entry["timings"].(map[string]interface{})
+		for _, key := range []string{"send", "wait", "receive"} {
+			assert.GreaterOrEqual(t, timings[key].(float64), float64(0), key)
+		}
+		for _, key := range []string{"blocked", "dns", "connect", "ssl"} {
+			assert.GreaterOrEqual(t, timings[key].(float64), float64(-1), key)
+		}
+	}
+}
+
+func TestAttachHAR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ioutil.ReadAll(r.Body)
+		http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}))
+	defer server.Close()
+
+	rec := &HARRecorder{}
+	success := &fakeSuccess{}
+	_, err := New().Post(server.URL+"/users?page=2").
+		SetAPIKey("key", KeyInQuery, "").
+		SetHeader("Authorization", "Bearer secret").
+		SetHeader("Content-Type", "application/json").
+		SetBody(map[string]string{"name": "John"}).
+		SetSuccess(success).
+		AttachHAR(rec).
+		Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, &fakeSuccess{ID: 1}, success)
+	assert.Equal(t, 1, rec.Len())
+
+	var buf bytes.Buffer
+	n, err := rec.WriteTo(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(buf.Len()), n)
+
+	var har map[string]interface{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &har))
+	assertHARSchema(t, har)
+
+	var file harFile
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &file))
+	entry := file.Log.Entries[0]
+	assert.Equal(t, "POST", entry.Request.Method)
+	assert.Equal(t, server.URL+"/users?api_key=%5BREDACTED%5D&page=2", entry.Request.URL)
+	assert.Equal(t, []harNameValue{{"api_key", Redacted}, {"page", "2"}}, entry.Request.QueryString)
+	assert.Contains(t, entry.Request.Headers, harNameValue{"Authorization", "Bearer [REDACTED]"})
+	assert.Equal(t, &harPostData{MimeType: "application/json", Params: []harNameValue{}, Text: `{"name":"John"}`}, entry.Request.PostData)
+	assert.Equal(t, int64(15), entry.Request.BodySize)
+
+	assert.Equal(t, 201, entry.Response.Status)
+	assert.Equal(t, "Created", entry.Response.StatusText)
+	assert.Equal(t, "HTTP/1.1", entry.Response.HTTPVersion)
+	assert.Equal(t, harContent{Size: 8, MimeType: "application/json", Text: `{"id":1}`}, entry.Response.Content)
+	assert.Empty(t, entry.Response.Cookies, "Set-Cookie is redacted")
+	assert.Greater(t, entry.Timings.Wait, float64(0))
+	assert.Greater(t, entry.Timings.Connect, float64(-1))
+	assert.Equal(t, float64(-1), entry.Timings.SSL)
+}
+
+func TestAttachHARConcurrent(t *testing.T) {
+	rec := &HARRecorder{}
+	base := newMockRequest(fakeHandler(200, `{}`, nil)).AttachHAR(rec)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := base.New().Get("http://example.com").Execute()
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 20, rec.Len())
+
+	var buf bytes.Buffer
+	_, err := rec.WriteTo(&buf)
+	assert.Nil(t, err)
+	var har map[string]interface{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &har))
+	assertHARSchema(t, har)
+}
+
+func TestAttachHARErrors(t *testing.T) {
+	rec := &HARRecorder{}
+	r := &Request{client: &errClient{err: errors.New("connection refused")}, header: make(http.Header)}
+
+	_, err := r.Get("http://example.com").AttachHAR(rec).Execute()
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, rec.Len())
+	assert.Equal(t, "connection refused", rec.entries[0].Error)
+
+	t.Run("binary content", func(t *testing.T) {
+		rec := &HARRecorder{}
+		_, err := newMockRequest(fakeHandler(200, "\xff\xfe", nil)).Get("http://example.com").AttachHAR(rec).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, harContent{Size: 2, Text: "//4=", Encoding: "base64"}, rec.entries[0].Response.Content)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := (&HARRecorder{}).WriteTo(&buf)
+		assert.Nil(t, err)
+		assert.Contains(t, buf.String(), `"entries": []`)
+	})
+}
@@ -0,0 +1,58 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"sort"
+)
+
+type headerOrderKey struct{}
+
+//SetHeaderOrder sets the order in which headers are sent. http.Header does not keep insertion order and the
+//default transport writes headers sorted by name, so the order is carried on the request's context for transports,
+//interceptors and signers that write or sign over headers in order, see HeaderOrder. Dumps and AsCurl follow it too
+func (r *Request) SetHeaderOrder(keys []string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.headerOrder = make([]string, len(keys))
+	for i, key := range keys {
+		r.headerOrder[i] = http.CanonicalHeaderKey(key)
+	}
+	return r
+}
+
+//HeaderOrder returns the names of the headers set on req in the order given to SetHeaderOrder, followed by the
+//headers not listed there sorted by name. Without SetHeaderOrder all names are sorted
+func HeaderOrder(req *http.Request) []string {
+	order, _ := req.Context().Value(headerOrderKey{}).([]string)
+	return orderHeaderNames(req.Header, order)
+}
+
+//withHeaderOrder returns ctx carrying the header order, if any
+func (r *Request) withHeaderOrder(ctx context.Context) context.Context {
+	if r.headerOrder == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, headerOrderKey{}, r.headerOrder)
+}
+
+func orderHeaderNames(header http.Header, order []string) []string {
+	names := make([]string, 0, len(header))
+	listed := make(map[string]bool, len(order))
+	for _, name := range order {
+		if _, ok := header[name]; ok && !listed[name] {
+			names = append(names, name)
+		}
+		listed[name] = true
+	}
+
+	rest := len(names)
+	for name := range header {
+		if !listed[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[rest:])
+	return names
+}
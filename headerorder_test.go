@@ -0,0 +1,72 @@
+package request
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//recordingTransport records the headers of every request as written in HeaderOrder
+type recordingTransport struct {
+	lines []string
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for _, name := range HeaderOrder(req) {
+		for _, value := range req.Header[name] {
+			t.lines = append(t.lines, name+": "+value)
+		}
+	}
+	return &http.Response{
+		StatusCode: 200,
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader("{}")),
+		Request:    req,
+	}, nil
+}
+
+func TestSetHeaderOrder(t *testing.T) {
+	newRecordingRequest := func(transport *recordingTransport) *Request {
+		return &Request{client: &http.Client{Transport: transport}, header: make(http.Header)}
+	}
+
+	t.Run("ordered", func(t *testing.T) {
+		transport := &recordingTransport{}
+		_, err := newRecordingRequest(transport).Get("http://example.com").
+			SetHeader("A-Header", "a").
+			SetHeader("Z-Header", "z").
+			SetHeader("Date", "today").
+			AddHeader("X-Repeat", "1").
+			AddHeader("X-Repeat", "2").
+			SetHeaderOrder([]string{"z-header", "x-repeat", "missing", "date"}).
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"Z-Header: z", "X-Repeat: 1", "X-Repeat: 2", "Date: today", "A-Header: a"}, transport.lines)
+	})
+
+	t.Run("unset", func(t *testing.T) {
+		transport := &recordingTransport{}
+		_, err := newRecordingRequest(transport).Get("http://example.com").
+			SetHeader("Z-Header", "z").
+			SetHeader("A-Header", "a").
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"A-Header: a", "Z-Header: z"}, transport.lines)
+	})
+
+	t.Run("dump", func(t *testing.T) {
+		var buf bytes.Buffer
+		_, err := newMockRequest(fakeHandler(200, `{}`, nil)).Get("http://example.com").
+			SetHeader("A-Header", "a").
+			SetHeader("Z-Header", "z").
+			SetHeaderOrder([]string{"Z-Header"}).
+			EnableDump(&buf, false).
+			Execute()
+		assert.Nil(t, err)
+		assert.Contains(t, buf.String(), "Z-Header: z\nA-Header: a\n")
+	})
+}
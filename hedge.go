@@ -0,0 +1,89 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+//EnableHedging sends up to maxHedges additional copies of an idempotent request, one after every delay
+//without a response. The first response to complete wins and the other attempts are cancelled
+func (r *Request) EnableHedging(delay time.Duration, maxHedges int) *Request {
+	if r == nil {
+		return nil
+	}
+	r.hedgeDelay = delay
+	r.hedges = maxHedges
+	return r
+}
+
+//canHedge reports whether req may be sent more than once concurrently
+func (r *Request) canHedge(req *http.Request) bool {
+	if r.hedges < 1 || !isIdempotent(req.Method) {
+		return false
+	}
+
+	return req.Body == nil || req.GetBody != nil
+}
+
+type hedgeResult struct {
+	resp   *Response
+	err    error
+	hedged bool
+}
+
+//hedge races the original request against delayed copies of it and returns the first response.
+//An error is only returned once every launched copy has failed
+func (r *Request) hedge(req *http.Request, attempt int) (*Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	results := make(chan hedgeResult, r.hedges+1)
+	launch := func(hedged bool) error {
+		clone := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return err
+			}
+			clone.Body = body
+		}
+
+		go func() {
+			resp, err := r.send(clone, attempt)
+			results <- hedgeResult{resp, err, hedged}
+		}()
+		return nil
+	}
+
+	if err := launch(false); err != nil {
+		return nil, err
+	}
+	launched, pending := 1, 1
+
+	clock := r.getClock()
+	next := clock.After(r.hedgeDelay)
+
+	for {
+		select {
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				result.resp.Hedged = result.hedged
+				return result.resp, nil
+			}
+			if pending == 0 {
+				return nil, result.err
+			}
+		case <-next:
+			launched++
+			if err := launch(true); err == nil {
+				pending++
+			}
+			next = nil
+			if launched <= r.hedges {
+				next = clock.After(r.hedgeDelay)
+			}
+		}
+	}
+}
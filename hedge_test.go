@@ -0,0 +1,96 @@
+package request
+
+import (
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//slowFirstHandler stalls the first call until it is cancelled and answers every other call right away
+func slowFirstHandler(calls *int32, cancelled chan struct{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+				close(cancelled)
+			case <-time.After(time.Second):
+			}
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"id":200, "name":"John"}`))
+	}
+}
+
+func TestHedgingFastHedgeWins(t *testing.T) {
+	var calls int32
+	cancelled := make(chan struct{})
+	r := newMockRequest(slowFirstHandler(&calls, cancelled))
+
+	start := time.Now()
+	result, err := r.Get("http://example.com").SetSuccess(&fakeSuccess{}).EnableHedging(20*time.Millisecond, 1).Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.True(t, result.Hedged)
+	assert.Equal(t, &fakeSuccess{ID: 200, Name: "John"}, result.Success)
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+
+	select {
+	case <-cancelled:
+	case <-time.After(500 * time.Millisecond):
+		t.Error("slow attempt was not cancelled")
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestHedgingFastOriginal(t *testing.T) {
+	calls := 0
+	r := newMockRequest(countingHandler(&calls, []int{200}, `{}`))
+
+	result, err := r.Get("http://example.com").EnableHedging(time.Second, 2).Execute()
+	assert.Nil(t, err)
+	assert.False(t, result.Hedged)
+	assert.Equal(t, 1, calls)
+}
+
+func TestHedgingNonIdempotent(t *testing.T) {
+	var calls int32
+	cancelled := make(chan struct{})
+	r := newMockRequest(slowFirstHandler(&calls, cancelled))
+
+	result, err := r.Post("http://example.com").EnableHedging(10*time.Millisecond, 2).Execute()
+	assert.Nil(t, err)
+	assert.False(t, result.Hedged)
+	assert.Equal(t, http.StatusGatewayTimeout, result.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestHedgingWithBody(t *testing.T) {
+	var bodies []string
+	requests := make(chan string, 3)
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		body := make([]byte, req.ContentLength)
+		req.Body.Read(body)
+		requests <- string(body)
+		if len(requests) == 1 {
+			<-req.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+
+	result, err := r.Put("http://example.com").SetBody(&fakeSuccess{ID: 1}).EnableHedging(10*time.Millisecond, 1).Execute()
+	assert.Nil(t, err)
+	assert.True(t, result.Hedged)
+
+	close(requests)
+	for body := range requests {
+		bodies = append(bodies, body)
+	}
+	assert.Equal(t, []string{`{"ID":1,"Name":""}`, `{"ID":1,"Name":""}`}, bodies)
+}
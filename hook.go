@@ -0,0 +1,107 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+//AttemptInfo describes a single attempt of a request
+type AttemptInfo struct {
+	//Attempt is the number of the attempt, starting at 1
+	Attempt int
+	//StatusCode is the status of the response, 0 when none was received
+	StatusCode int
+	//Err is the error of the attempt, if any
+	Err error
+	//Backoff is the delay chosen before the next attempt, 0 when this is the final attempt
+	Backoff time.Duration
+	//Duration is how long the attempt took
+	Duration time.Duration
+	//RequestID is the ID sent with the attempt when EnableRequestID is set
+	RequestID string
+	//Tags are the tags set with SetTag
+	Tags map[string]string
+}
+
+//OnAttempt sets a hook called after every attempt, including the final one and the single attempt of DownloadTo
+//and ExecuteStream.
+//A panic inside the hook is recovered and returned by Execute as an error matching ErrHookPanic
+//once the request is done, without stopping the retries
+func (r *Request) OnAttempt(hook func(info AttemptInfo)) *Request {
+	if r == nil {
+		return nil
+	}
+	r.onAttempt = hook
+	return r
+}
+
+//OnBeforeSend adds a hook called with the final request and the exact body bytes about to be sent,
+//after the body is marshaled and the query and headers are set, and before the request is signed and sent.
+//Hooks run in the order they were added on every attempt and may modify the request.
+//Returning an error aborts Execute with that error without retrying
+func (r *Request) OnBeforeSend(hook func(req *http.Request, body []byte) error) *Request {
+	if r == nil {
+		return nil
+	}
+	r.beforeSend = append(r.beforeSend, hook)
+	return r
+}
+
+//runBeforeSend calls the before send hooks in order, stopping at the first error
+func (r *Request) runBeforeSend(req *http.Request) error {
+	if len(r.beforeSend) == 0 {
+		return nil
+	}
+
+	body, err := bodyBytes(req)
+	if err != nil {
+		return err
+	}
+	for _, hook := range r.beforeSend {
+		if err := hook(req, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newAttemptInfo(attempt int, requestID string, resp *Response, err error, backoff, duration time.Duration) AttemptInfo {
+	info := AttemptInfo{
+		Attempt:   attempt,
+		Err:       err,
+		Backoff:   backoff,
+		Duration:  duration,
+		RequestID: requestID,
+	}
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+	}
+	return info
+}
+
+//runAttemptHook calls the attempt hook, converting a panic into an error
+func (r *Request) runAttemptHook(info AttemptInfo) (err error) {
+	if r.onAttempt == nil {
+		return nil
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("%w: attempt %d: %v", ErrHookPanic, info.Attempt, recovered)
+		}
+	}()
+
+	info.Tags = r.Tags()
+	r.onAttempt(info)
+	return nil
+}
+
+//joinHookErr adds hook panics to the error returned by Execute, leaving err untouched when there are none
+func joinHookErr(err, hookErr error) error {
+	if hookErr == nil {
+		return err
+	}
+	return errors.Join(err, hookErr)
+}
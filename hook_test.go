@@ -0,0 +1,135 @@
+package request
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnAttempt(t *testing.T) {
+	clock := newFakeClock()
+	calls := 0
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		calls++
+		clock.Advance(time.Duration(calls) * time.Second)
+		if calls <= 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	r.SetClock(clock)
+
+	var infos []AttemptInfo
+	result, err := r.Get("http://example.com").SetRetry(3, 100*time.Millisecond).OnAttempt(func(info AttemptInfo) {
+		infos = append(infos, info)
+	}).Execute()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, []AttemptInfo{
+		{Attempt: 1, StatusCode: 500, Backoff: 100 * time.Millisecond, Duration: time.Second},
+		{Attempt: 2, StatusCode: 500, Backoff: 200 * time.Millisecond, Duration: 2 * time.Second},
+		{Attempt: 3, StatusCode: 500, Backoff: 400 * time.Millisecond, Duration: 3 * time.Second},
+		{Attempt: 4, StatusCode: 200, Duration: 4 * time.Second},
+	}, infos)
+}
+
+func TestOnAttemptErrors(t *testing.T) {
+	refused := errors.New("connection refused")
+	client := &errClient{err: refused}
+	r := &Request{client: client, clock: newFakeClock()}
+
+	var infos []AttemptInfo
+	_, err := r.Get("http://example.com").SetRetry(1, time.Second).OnAttempt(func(info AttemptInfo) {
+		infos = append(infos, info)
+	}).Execute()
+
+	assert.Equal(t, refused, err)
+	assert.Len(t, infos, 2)
+	for _, info := range infos {
+		assert.Equal(t, refused, info.Err)
+		assert.Equal(t, 0, info.StatusCode)
+	}
+	assert.Equal(t, time.Second, infos[0].Backoff)
+	assert.Equal(t, time.Duration(0), infos[1].Backoff)
+}
+
+func TestOnAttemptPanic(t *testing.T) {
+	calls := 0
+	r := newMockRequest(countingHandler(&calls, []int{500, 200}, `{}`))
+
+	attempts := 0
+	result, err := r.Get("http://example.com").SetRetry(2, 0).OnAttempt(func(info AttemptInfo) {
+		attempts++
+		if info.Attempt == 1 {
+			panic("boom")
+		}
+	}).Execute()
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 2, attempts)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.True(t, errors.Is(err, ErrHookPanic))
+	assert.Contains(t, err.Error(), "attempt 1: boom")
+}
+
+func TestOnBeforeSend(t *testing.T) {
+	var checksums, bodies, paths []string
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		checksums = append(checksums, req.Header.Get("X-Checksum"))
+		paths = append(paths, req.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var order []string
+	result, err := r.Post("http://example.com/v1/orders").SetBody(&fakeSuccess{ID: 1, Name: "Bob"}).
+		OnBeforeSend(func(req *http.Request, body []byte) error {
+			order = append(order, "checksum")
+			sum := sha256.Sum256(body)
+			req.Header.Set("X-Checksum", hex.EncodeToString(sum[:]))
+			return nil
+		}).
+		OnBeforeSend(func(req *http.Request, body []byte) error {
+			order = append(order, "rewrite")
+			req.URL.Path = "/v2/orders"
+			return nil
+		}).
+		Execute()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, []string{"checksum", "rewrite"}, order)
+	assert.Equal(t, []string{`{"ID":1,"Name":"Bob"}`}, bodies)
+	sum := sha256.Sum256([]byte(`{"ID":1,"Name":"Bob"}`))
+	assert.Equal(t, []string{hex.EncodeToString(sum[:])}, checksums)
+	assert.Equal(t, []string{"/v2/orders"}, paths)
+}
+
+func TestOnBeforeSendReject(t *testing.T) {
+	denied := errors.New("host is denied")
+	calls := 0
+	r := newMockRequest(countingHandler(&calls, []int{200}, `{}`)).OnBeforeSend(func(req *http.Request, body []byte) error {
+		if req.URL.Hostname() == "internal.example.com" {
+			return denied
+		}
+		return nil
+	})
+
+	result, err := r.New().Get("http://internal.example.com/admin").SetRetry(3, 0).Execute()
+	assert.Nil(t, result)
+	assert.Equal(t, denied, err)
+	assert.Equal(t, 0, calls)
+
+	_, err = r.New().Get("http://example.com").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, calls)
+}
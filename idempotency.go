@@ -0,0 +1,57 @@
+package request
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+//SetIdempotencyKey sets the Idempotency-Key header sent with every attempt of the request
+func (r *Request) SetIdempotencyKey(key string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.idemKey = key
+	return r
+}
+
+//AutoIdempotencyKey generates a random Idempotency-Key on every Execute, DownloadTo or ExecuteStream when no key
+//is set. The generated key is reused for all retries of that Execute and exposed on the Response
+func (r *Request) AutoIdempotencyKey() *Request {
+	if r == nil {
+		return nil
+	}
+	r.autoIdem = true
+	return r
+}
+
+//idempotencyKey returns the key to send for a single Execute
+func (r *Request) idempotencyKey() (string, error) {
+	if r.idemKey != "" || !r.autoIdem {
+		return r.idemKey, nil
+	}
+
+	return newIdempotencyKey()
+}
+
+//newIdempotencyKey returns a random Idempotency-Key
+func newIdempotencyKey() (string, error) {
+	key, err := newUUID()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate idempotency key: %w", err)
+	}
+	return key, nil
+}
+
+//newUUID returns a random version 4 UUID
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
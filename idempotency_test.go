@@ -0,0 +1,65 @@
+package request
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//headerRecorder fails the first attempts with 503 and records the given header of every attempt
+func headerRecorder(key string, failures int, values *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		*values = append(*values, r.Header.Get(key))
+		if len(*values) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func TestSetIdempotencyKey(t *testing.T) {
+	var keys []string
+	r := newMockRequest(headerRecorder("Idempotency-Key", 2, &keys))
+
+	result, err := r.Post("http://example.com").SetIdempotencyKey("order-1").SetRetry(3, 0).Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, []string{"order-1", "order-1", "order-1"}, keys)
+	assert.Equal(t, "order-1", result.IdempotencyKey)
+}
+
+func TestAutoIdempotencyKey(t *testing.T) {
+	var keys []string
+	r := newMockRequest(headerRecorder("Idempotency-Key", 2, &keys))
+	r.Post("http://example.com").AutoIdempotencyKey().SetRetry(3, 0)
+
+	result, err := r.Execute()
+	assert.Nil(t, err)
+	assert.Len(t, keys, 3)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`), keys[0])
+	assert.Equal(t, keys[0], keys[1])
+	assert.Equal(t, keys[0], keys[2])
+	assert.Equal(t, keys[0], result.IdempotencyKey)
+
+	t.Run("new key per execute", func(t *testing.T) {
+		keys = nil
+		second, err := r.Execute()
+		assert.Nil(t, err)
+		assert.NotEqual(t, result.IdempotencyKey, second.IdempotencyKey)
+		assert.Len(t, keys, 3)
+		assert.Equal(t, []string{second.IdempotencyKey, second.IdempotencyKey, second.IdempotencyKey}, keys)
+	})
+}
+
+func TestNoIdempotencyKey(t *testing.T) {
+	var keys []string
+	r := newMockRequest(headerRecorder("Idempotency-Key", 0, &keys))
+
+	result, err := r.Post("http://example.com").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{""}, keys)
+	assert.Empty(t, result.IdempotencyKey)
+}
@@ -0,0 +1,71 @@
+package request
+
+import "net/http"
+
+//Interceptor wraps sending a request. It receives the request and next, which sends it through the remaining
+//interceptors and then the client, and may modify the request, short-circuit it, call next more than once
+//or inspect and replace the response
+type Interceptor func(req *http.Request, next func(req *http.Request) (*http.Response, error)) (*http.Response, error)
+
+//AddInterceptor adds an interceptor around sending every attempt with the client. Interceptors run in the order
+//they were added, the first one outermost, after the request is signed and before the response body is read
+func (r *Request) AddInterceptor(interceptor Interceptor) *Request {
+	if r == nil {
+		return nil
+	}
+	r.interceptors = append(r.interceptors, interceptor)
+	return r
+}
+
+//roundTrip sends req with the client through the interceptors. It returns ErrNilResponse when an interceptor
+//returns neither a response nor an error, and gives responses without a body or header empty ones
+func (r *Request) roundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.intercept(0, req)
+	if err != nil {
+		return resp, err
+	}
+	if resp == nil {
+		return nil, ErrNilResponse
+	}
+	if resp.Body == nil {
+		resp.Body = http.NoBody
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	return resp, nil
+}
+
+func (r *Request) intercept(i int, req *http.Request) (*http.Response, error) {
+	if i == len(r.interceptors) {
+		return r.transmit(req)
+	}
+	return r.interceptors[i](req, func(req *http.Request) (*http.Response, error) {
+		return r.intercept(i+1, req)
+	})
+}
+
+//transmit sends req with the client, recording, dumping and printing the exchange when enabled
+func (r *Request) transmit(req *http.Request) (*http.Response, error) {
+	r.transport.share()
+	send := r.client.Do
+	if r.debug != nil {
+		next := send
+		send = func(req *http.Request) (*http.Response, error) {
+			return r.debugExchange(req, next)
+		}
+	}
+	if r.dump != nil {
+		next := send
+		send = func(req *http.Request) (*http.Response, error) {
+			return r.dumpExchange(req, next)
+		}
+	}
+	if r.har != nil {
+		next := send
+		send = func(req *http.Request) (*http.Response, error) {
+			return r.recordHAR(req, next)
+		}
+	}
+	return send(req)
+}
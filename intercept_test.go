@@ -0,0 +1,116 @@
+package request
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddInterceptor(t *testing.T) {
+	t.Run("order", func(t *testing.T) {
+		var order []string
+		record := func(name string) Interceptor {
+			return func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+				order = append(order, name+" before")
+				resp, err := next(req)
+				order = append(order, name+" after")
+				return resp, err
+			}
+		}
+
+		r := newMockRequest(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "client")
+			w.WriteHeader(http.StatusOK)
+		})
+		_, err := r.Get("http://example.com").AddInterceptor(record("a")).AddInterceptor(record("b")).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a before", "b before", "client", "b after", "a after"}, order)
+	})
+
+	t.Run("modifies request and response", func(t *testing.T) {
+		var received string
+		r := newMockRequest(func(w http.ResponseWriter, r *http.Request) {
+			received = r.Header.Get("X-Intercepted")
+			w.WriteHeader(http.StatusOK)
+		})
+
+		resp, err := r.Get("http://example.com").AddInterceptor(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			req.Header.Set("X-Intercepted", "yes")
+			resp, err := next(req)
+			if resp != nil {
+				resp.Header.Set("X-Seen", "yes")
+			}
+			return resp, err
+		}).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "yes", received)
+		assert.Equal(t, "yes", resp.Header.Get("X-Seen"))
+	})
+
+	t.Run("short-circuit", func(t *testing.T) {
+		calls := 0
+		success := &fakeSuccess{}
+		r := newMockRequest(countingHandler(&calls, []int{200}, `{}`))
+
+		_, err := r.Get("http://example.com").SetSuccess(success).AddInterceptor(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     make(http.Header),
+				Body:       io.NopCloser(strings.NewReader(`{"id":1,"name":"cached"}`)),
+			}, nil
+		}).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 0, calls)
+		assert.Equal(t, &fakeSuccess{ID: 1, Name: "cached"}, success)
+	})
+
+	t.Run("short-circuit without body", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(200, `{}`, nil))
+
+		resp, err := r.Get("http://example.com").AddInterceptor(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusNoContent}, nil
+		}).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Empty(t, resp.Body)
+	})
+
+	t.Run("nil response", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(200, `{}`, nil))
+
+		_, err := r.Get("http://example.com").AddInterceptor(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			return nil, nil
+		}).Execute()
+		assert.ErrorIs(t, err, ErrNilResponse)
+	})
+
+	t.Run("calls next more than once", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{500, 200}, `{}`))
+
+		resp, err := r.Get("http://example.com").AddInterceptor(func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			resp, err := next(req)
+			if err == nil && resp.StatusCode >= 500 {
+				resp.Body.Close()
+				return next(req)
+			}
+			return resp, err
+		}).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, 200, resp.StatusCode)
+	})
+
+	t.Run("copied by New", func(t *testing.T) {
+		noop := func(req *http.Request, next func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+			return next(req)
+		}
+		base := New().AddInterceptor(noop)
+		derived := base.New().AddInterceptor(noop)
+		assert.Len(t, base.interceptors, 1)
+		assert.Len(t, derived.interceptors, 2)
+	})
+}
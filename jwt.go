@@ -0,0 +1,111 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultJWTRefreshSkew = 30 * time.Second
+
+//JWTProvider fetches a new token along with the time it expires
+type JWTProvider interface {
+	Token(ctx context.Context) (token string, expiry time.Time, err error)
+}
+
+//JWTOption configures SetJWTProvider
+type JWTOption func(*jwtCache)
+
+//WithRefreshSkew sets how long before its expiry a token is refreshed. Defaults to 30 seconds
+func WithRefreshSkew(skew time.Duration) JWTOption {
+	return func(c *jwtCache) {
+		c.skew = skew
+	}
+}
+
+//WithGracePeriod keeps using the cached token for up to grace past its expiry when refreshing it fails
+func WithGracePeriod(grace time.Duration) JWTOption {
+	return func(c *jwtCache) {
+		c.grace = grace
+	}
+}
+
+//SetJWTProvider sends a bearer token from p in the Authorization header of every attempt.
+//The token is cached and refreshed once it is within the refresh skew of its expiry, with concurrent
+//refreshes coalesced into a single call to p. When refreshing fails, the cached token keeps being used
+//until its expiry plus the grace period, after which the request fails with the wrapped refresh error.
+//The cache is shared with every request derived from this one via New
+func (r *Request) SetJWTProvider(p JWTProvider, opts ...JWTOption) *Request {
+	if r == nil {
+		return nil
+	}
+	cache := &jwtCache{provider: p, skew: defaultJWTRefreshSkew}
+	for _, opt := range opts {
+		opt(cache)
+	}
+	r.jwt = cache
+	return r
+}
+
+//jwtCache holds the current token and coalesces concurrent refreshes
+type jwtCache struct {
+	provider JWTProvider
+	skew     time.Duration
+	grace    time.Duration
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+	call   *jwtCall
+}
+
+type jwtCall struct {
+	done chan struct{}
+	err  error
+}
+
+//get returns a token valid at now, refreshing it if needed
+func (c *jwtCache) get(ctx context.Context, now time.Time) (string, error) {
+	c.mu.Lock()
+	if c.token != "" && now.Before(c.expiry.Add(-c.skew)) {
+		token := c.token
+		c.mu.Unlock()
+		return token, nil
+	}
+
+	call := c.call
+	if call == nil {
+		call = &jwtCall{done: make(chan struct{})}
+		c.call = call
+		c.mu.Unlock()
+		c.refresh(ctx, call)
+	} else {
+		c.mu.Unlock()
+		<-call.done
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if call.err == nil {
+		return c.token, nil
+	}
+	if c.token != "" && now.Before(c.expiry.Add(c.grace)) {
+		return c.token, nil
+	}
+	return "", fmt.Errorf("failed to refresh JWT: %w", call.err)
+}
+
+func (c *jwtCache) refresh(ctx context.Context, call *jwtCall) {
+	token, expiry, err := c.provider.Token(ctx)
+
+	c.mu.Lock()
+	if err == nil {
+		c.token, c.expiry = token, expiry
+	}
+	call.err = err
+	c.call = nil
+	c.mu.Unlock()
+
+	close(call.done)
+}
@@ -0,0 +1,153 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AidenHadisi/go-simple-request/requesttest"
+	"github.com/stretchr/testify/assert"
+)
+
+//fakeJWTProvider issues numbered tokens valid for ttl, or err when set
+type fakeJWTProvider struct {
+	mu    sync.Mutex
+	clock *requesttest.FakeClock
+	ttl   time.Duration
+	delay time.Duration
+	err   error
+	calls int
+}
+
+func (p *fakeJWTProvider) Token(ctx context.Context) (string, time.Time, error) {
+	time.Sleep(p.delay)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.err != nil {
+		return "", time.Time{}, p.err
+	}
+	return fmt.Sprintf("token-%d", p.calls), p.clock.Now().Add(p.ttl), nil
+}
+
+func (p *fakeJWTProvider) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.err = err
+}
+
+func authorizationRecorder(tokens *[]string) http.HandlerFunc {
+	var mu sync.Mutex
+	return func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		*tokens = append(*tokens, req.Header.Get("Authorization"))
+	}
+}
+
+func TestSetJWTProvider(t *testing.T) {
+	clock := requesttest.NewFakeClock(time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC))
+	provider := &fakeJWTProvider{clock: clock, ttl: 5 * time.Minute}
+	var tokens []string
+	template := newMockRequest(authorizationRecorder(&tokens)).SetClock(clock).SetJWTProvider(provider)
+
+	execute := func() {
+		_, err := template.New().Get("http://example.com").Execute()
+		assert.Nil(t, err)
+	}
+
+	execute()
+	clock.Advance(4 * time.Minute)
+	execute()
+	//within the default skew of 30 seconds before expiry
+	clock.Advance(31 * time.Second)
+	execute()
+
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}, tokens)
+	assert.Equal(t, 2, provider.calls)
+}
+
+func TestJWTProviderConcurrentRefresh(t *testing.T) {
+	clock := requesttest.NewFakeClock(time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC))
+	provider := &fakeJWTProvider{clock: clock, ttl: time.Hour, delay: 20 * time.Millisecond}
+	var tokens []string
+	template := newMockRequest(authorizationRecorder(&tokens)).SetClock(clock).SetJWTProvider(provider, WithRefreshSkew(time.Minute))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := template.New().Get("http://example.com").Execute()
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, provider.calls)
+	assert.Len(t, tokens, 10)
+	for _, token := range tokens {
+		assert.Equal(t, "Bearer token-1", token)
+	}
+}
+
+func TestJWTProviderRefreshFailure(t *testing.T) {
+	unavailable := errors.New("token endpoint unavailable")
+
+	t.Run("fails once expired", func(t *testing.T) {
+		clock := requesttest.NewFakeClock(time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC))
+		provider := &fakeJWTProvider{clock: clock, ttl: 5 * time.Minute}
+		var tokens []string
+		template := newMockRequest(authorizationRecorder(&tokens)).SetClock(clock).SetJWTProvider(provider)
+
+		_, err := template.New().Get("http://example.com").Execute()
+		assert.Nil(t, err)
+
+		provider.fail(unavailable)
+		//the refresh fails but the token has not expired yet
+		clock.Advance(4*time.Minute + 45*time.Second)
+		_, err = template.New().Get("http://example.com").Execute()
+		assert.Nil(t, err)
+
+		clock.Advance(time.Minute)
+		_, err = template.New().Get("http://example.com").Execute()
+		assert.ErrorIs(t, err, unavailable)
+		assert.Equal(t, []string{"Bearer token-1", "Bearer token-1"}, tokens)
+	})
+
+	t.Run("grace period", func(t *testing.T) {
+		clock := requesttest.NewFakeClock(time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC))
+		provider := &fakeJWTProvider{clock: clock, ttl: 5 * time.Minute}
+		var tokens []string
+		template := newMockRequest(authorizationRecorder(&tokens)).SetClock(clock).SetJWTProvider(provider, WithGracePeriod(2*time.Minute))
+
+		_, err := template.New().Get("http://example.com").Execute()
+		assert.Nil(t, err)
+
+		provider.fail(unavailable)
+		clock.Advance(6 * time.Minute)
+		_, err = template.New().Get("http://example.com").Execute()
+		assert.Nil(t, err)
+
+		clock.Advance(2 * time.Minute)
+		_, err = template.New().Get("http://example.com").Execute()
+		assert.ErrorIs(t, err, unavailable)
+		assert.Equal(t, []string{"Bearer token-1", "Bearer token-1"}, tokens)
+	})
+
+	t.Run("no token yet", func(t *testing.T) {
+		clock := requesttest.NewFakeClock(time.Now())
+		provider := &fakeJWTProvider{clock: clock, ttl: time.Minute, err: unavailable}
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{200}, `{}`)).SetClock(clock).SetJWTProvider(provider)
+
+		_, err := r.Get("http://example.com").Execute()
+		assert.ErrorIs(t, err, unavailable)
+		assert.Equal(t, 0, calls)
+	})
+}
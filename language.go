@@ -0,0 +1,62 @@
+package request
+
+import (
+	"strconv"
+	"strings"
+)
+
+//SetAcceptLanguage sets the Accept-Language header to langs in order of preference, weighting every language
+//after the first with a decreasing quality value: SetAcceptLanguage("en-US", "en", "fr") sends
+//"en-US, en;q=0.9, fr;q=0.8". Blank languages are skipped and the header is removed when none are left
+func (r *Request) SetAcceptLanguage(langs ...string) *Request {
+	if r == nil {
+		return nil
+	}
+
+	value := acceptLanguage(langs)
+	if value == "" {
+		r.header.Del("Accept-Language")
+		return r
+	}
+	r.header.Set("Accept-Language", value)
+	return r
+}
+
+//acceptLanguage formats langs with quality values decreasing in steps small enough to keep every weight distinct.
+//Weights are computed in thousandths, the precision allowed for quality values, so no float formatting is involved
+func acceptLanguage(langs []string) string {
+	tags := make([]string, 0, len(langs))
+	for _, lang := range langs {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			tags = append(tags, lang)
+		}
+	}
+
+	step := 100
+	if len(tags) > 10 {
+		step = 10
+	}
+	if len(tags) > 100 {
+		step = 1
+	}
+
+	parts := make([]string, len(tags))
+	for i, tag := range tags {
+		weight := 1000 - i*step
+		if i == 0 {
+			parts[i] = tag
+			continue
+		}
+		if weight < 1 {
+			weight = 1
+		}
+		parts[i] = tag + ";q=" + qValue(weight)
+	}
+	return strings.Join(parts, ", ")
+}
+
+//qValue formats a weight in thousandths as a quality value without trailing zeros, e.g. 900 as 0.9
+func qValue(thousandths int) string {
+	digits := strconv.Itoa(1000 + thousandths)[1:]
+	return "0." + strings.TrimRight(digits, "0")
+}
@@ -0,0 +1,40 @@
+package request
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetAcceptLanguage(t *testing.T) {
+	t.Run("quality values", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetAcceptLanguage("en-US", "en", " ", "fr-CA", "fr").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "en-US, en;q=0.9, fr-CA;q=0.8, fr;q=0.7", req.Header.Get("Accept-Language"))
+	})
+
+	t.Run("single language", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetAcceptLanguage("de").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "de", req.Header.Get("Accept-Language"))
+	})
+
+	t.Run("no languages removes the header", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetAcceptLanguage("en").SetAcceptLanguage().Request()
+		assert.Nil(t, err)
+		assert.Empty(t, req.Header.Values("Accept-Language"))
+	})
+
+	t.Run("many languages keep distinct weights", func(t *testing.T) {
+		langs := make([]string, 12)
+		for i := range langs {
+			langs[i] = fmt.Sprintf("l%d", i)
+		}
+		parts := strings.Split(acceptLanguage(langs), ", ")
+		assert.Equal(t, "l1;q=0.99", parts[1])
+		assert.Equal(t, "l10;q=0.9", parts[10])
+		assert.Equal(t, "l11;q=0.89", parts[11])
+	})
+}
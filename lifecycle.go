@@ -0,0 +1,66 @@
+package request
+
+import "net/http"
+
+//OnRequest adds a hook called with the request and the final *http.Request before every attempt is sent,
+//after the OnBeforeSend hooks. Hooks run in the order they were added and the first error aborts
+//the request with that error without retrying. Requests created with New inherit the hooks
+func (r *Request) OnRequest(hook func(r *Request, req *http.Request) error) *Request {
+	if r == nil {
+		return nil
+	}
+	r.onRequest = append(r.onRequest, hook)
+	return r
+}
+
+//OnResponse adds a hook called with the final response once it is decoded, whatever its status.
+//Hooks run in the order they were added and the first error is returned along with the response,
+//so a hook may reject a response the server reported as successful. Requests created with New inherit the hooks
+func (r *Request) OnResponse(hook func(r *Request, resp *Response) error) *Request {
+	if r == nil {
+		return nil
+	}
+	r.onResponse = append(r.onResponse, hook)
+	return r
+}
+
+//OnError adds a hook called with the error when the request fails before a response is received and decoded,
+//such as transport, decoding, context and hook errors. Hooks run in the order they were added.
+//Requests created with New inherit the hooks
+func (r *Request) OnError(hook func(r *Request, err error)) *Request {
+	if r == nil {
+		return nil
+	}
+	r.onError = append(r.onError, hook)
+	return r
+}
+
+//runRequestHooks calls the request hooks in order, stopping at the first error
+func (r *Request) runRequestHooks(req *http.Request) error {
+	for _, hook := range r.onRequest {
+		if err := hook(r, req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//finish sets the tags on resp and calls the error hooks when err is set, otherwise the response hooks in order until one fails
+func (r *Request) finish(resp *Response, err error) (*Response, error) {
+	if resp != nil {
+		resp.Tags = r.Tags()
+	}
+	if err != nil {
+		for _, hook := range r.onError {
+			hook(r, err)
+		}
+		return resp, err
+	}
+
+	for _, hook := range r.onResponse {
+		if err := hook(r, resp); err != nil {
+			return resp, err
+		}
+	}
+	return resp, nil
+}
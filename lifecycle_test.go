@@ -0,0 +1,151 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycleHooks(t *testing.T) {
+	t.Run("order", func(t *testing.T) {
+		var calls []string
+		var success fakeSuccess
+		r := newMockRequest(fakeHandler(200, `{"ID":1,"Name":"John"}`, nil))
+		r.Get("http://example.com").SetSuccess(&success).
+			OnBeforeSend(func(req *http.Request, body []byte) error {
+				calls = append(calls, "before send")
+				return nil
+			}).
+			OnRequest(func(hr *Request, req *http.Request) error {
+				assert.Same(t, r, hr)
+				calls = append(calls, "request 1")
+				req.Header.Set("X-Audit", "1")
+				return nil
+			}).
+			OnRequest(func(hr *Request, req *http.Request) error {
+				assert.Equal(t, "1", req.Header.Get("X-Audit"))
+				calls = append(calls, "request 2")
+				return nil
+			}).
+			OnResponse(func(hr *Request, resp *Response) error {
+				assert.Equal(t, 1, success.ID)
+				calls = append(calls, "response 1")
+				return nil
+			}).
+			OnResponse(func(hr *Request, resp *Response) error {
+				calls = append(calls, "response 2")
+				return nil
+			}).
+			OnError(func(hr *Request, err error) {
+				calls = append(calls, "error")
+			})
+
+		_, err := r.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"before send", "request 1", "request 2", "response 1", "response 2"}, calls)
+	})
+
+	t.Run("request hook error short-circuits", func(t *testing.T) {
+		var calls []string
+		var sent int
+		denied := errors.New("denied")
+		r := newMockRequest(func(w http.ResponseWriter, r *http.Request) { sent++ })
+		_, err := r.Get("http://example.com").
+			OnRequest(func(*Request, *http.Request) error {
+				calls = append(calls, "request 1")
+				return denied
+			}).
+			OnRequest(func(*Request, *http.Request) error {
+				calls = append(calls, "request 2")
+				return nil
+			}).
+			OnError(func(hr *Request, err error) {
+				calls = append(calls, "error: "+err.Error())
+			}).
+			Execute()
+		assert.Equal(t, denied, err)
+		assert.Equal(t, 0, sent)
+		assert.Equal(t, []string{"request 1", "error: denied"}, calls)
+	})
+
+	t.Run("response hook vetoes success", func(t *testing.T) {
+		var calls []string
+		invalid := errors.New("invalid response")
+		r := newMockRequest(fakeHandler(200, `{"ID":0}`, nil))
+		result, err := r.Get("http://example.com").SetSuccess(&fakeSuccess{}).
+			OnResponse(func(hr *Request, resp *Response) error {
+				calls = append(calls, "response 1")
+				if resp.Success.(*fakeSuccess).ID == 0 {
+					return invalid
+				}
+				return nil
+			}).
+			OnResponse(func(*Request, *Response) error {
+				calls = append(calls, "response 2")
+				return nil
+			}).
+			Execute()
+		assert.Equal(t, invalid, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, []string{"response 1"}, calls)
+	})
+
+	t.Run("error hooks", func(t *testing.T) {
+		var errs []error
+		var responses int
+		failed := errors.New("connection refused")
+		r := &Request{client: &errClient{err: failed}, header: make(http.Header)}
+		_, err := r.Get("http://example.com").
+			OnResponse(func(*Request, *Response) error {
+				responses++
+				return nil
+			}).
+			OnError(func(hr *Request, err error) { errs = append(errs, err) }).
+			OnError(func(hr *Request, err error) { errs = append(errs, err) }).
+			Execute()
+		assert.True(t, errors.Is(err, failed))
+		assert.Len(t, errs, 2)
+		assert.True(t, errors.Is(errs[0], failed))
+		assert.Equal(t, 0, responses)
+	})
+
+	t.Run("decode error", func(t *testing.T) {
+		var errs []error
+		r := newMockRequest(fakeHandler(200, `{invalid`, nil))
+		_, err := r.Get("http://example.com").SetSuccess(&fakeSuccess{}).
+			OnError(func(hr *Request, err error) { errs = append(errs, err) }).
+			Execute()
+		var decodeErr *DecodeError
+		assert.True(t, errors.As(err, &decodeErr))
+		assert.Equal(t, []error{err}, errs)
+	})
+
+	t.Run("inherited", func(t *testing.T) {
+		var calls []string
+		template := newMockRequest(fakeHandler(200, `{}`, nil)).
+			OnRequest(func(*Request, *http.Request) error {
+				calls = append(calls, "template request")
+				return nil
+			}).
+			OnResponse(func(*Request, *Response) error {
+				calls = append(calls, "template response")
+				return nil
+			})
+
+		child := template.New().Get("http://example.com").
+			OnRequest(func(*Request, *http.Request) error {
+				calls = append(calls, "child request")
+				return nil
+			})
+		_, err := child.Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"template request", "child request", "template response"}, calls)
+
+		calls = nil
+		_, err = template.Get("http://example.com").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"template request", "template response"}, calls)
+	})
+}
@@ -0,0 +1,132 @@
+package request
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+//SetLogger sets a structured logger for the request. Every attempt is logged at debug level when it starts
+//and at info, warn or error level when it completes, and retries are logged at debug level.
+//Request bodies are never logged, headers are logged at debug level with sensitive values redacted as set with RedactHeaders
+//and the URL is logged without credentials or query params unless LogQuery is set
+func (r *Request) SetLogger(logger *slog.Logger) *Request {
+	if r == nil {
+		return nil
+	}
+	r.logger = logger
+	return r
+}
+
+//LogQuery includes the query params in logged URLs, replacing the values of the redact params
+//and of API keys placed in the query with [REDACTED]
+func (r *Request) LogQuery(redact ...string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.logQuery = true
+	r.logRedact = append([]string(nil), redact...)
+	return r
+}
+
+func (r *Request) logStart(req *http.Request, attempt int) {
+	r.warnInsecure(req)
+	if r.logger == nil {
+		return
+	}
+
+	if !r.logger.Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	attrs := append(r.requestAttrs(req, attempt), slog.Any("headers", r.RedactHeader(req.Header)))
+	r.logger.LogAttrs(context.Background(), slog.LevelDebug, "request started", attrs...)
+}
+
+func (r *Request) logDone(req *http.Request, attempt int, resp *Response, err error, duration time.Duration) {
+	if r.logger == nil {
+		return
+	}
+
+	attrs := append(r.requestAttrs(req, attempt), slog.Duration("duration", duration))
+
+	if resp == nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		r.logger.LogAttrs(context.Background(), slog.LevelError, "request failed", attrs...)
+		return
+	}
+
+	level := slog.LevelInfo
+	if resp.StatusCode >= 400 {
+		level = slog.LevelWarn
+	}
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	r.logger.LogAttrs(context.Background(), level, "request completed", attrs...)
+}
+
+func (r *Request) logRetry(req *http.Request, attempt int, delay time.Duration) {
+	if r.logger == nil {
+		return
+	}
+
+	attrs := append(r.requestAttrs(req, attempt), slog.Duration("delay", delay))
+	r.logger.LogAttrs(context.Background(), slog.LevelDebug, "retrying request", attrs...)
+}
+
+//requestAttrs returns the attributes identifying an attempt of req in every log line
+func (r *Request) requestAttrs(req *http.Request, attempt int) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", r.logURL(req)),
+		slog.Int("attempt", attempt),
+	}
+	if id := r.requestIDOf(req); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if len(r.tags) > 0 {
+		attrs = append(attrs, r.tagsAttr())
+	}
+	return attrs
+}
+
+//logURL returns the URL of req to log, sanitized and with its query redacted when LogQuery is set
+func (r *Request) logURL(req *http.Request) string {
+	if !r.logQuery || req.URL.RawQuery == "" {
+		return sanitizeURL(req)
+	}
+
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = r.redactQuery(req.URL.Query(), r.logRedact).Encode()
+	u.Fragment = ""
+	return u.String()
+}
+
+//redactQuery replaces the values of the redact params and of API keys placed in the query in params
+func (r *Request) redactQuery(params url.Values, redact []string) url.Values {
+	for _, k := range r.apiKeys {
+		if k.in == KeyInQuery {
+			redactParam(params, k.name)
+		}
+	}
+	for _, key := range redact {
+		redactParam(params, key)
+	}
+	return params
+}
+
+func redactParam(params url.Values, key string) {
+	for i := range params[key] {
+		params[key][i] = Redacted
+	}
+}
+
+//sanitizeURL strips user info, query params and fragment which may carry secrets
+func sanitizeURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
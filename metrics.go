@@ -0,0 +1,114 @@
+package request
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+//RequestMetrics describes a completed Execute for metrics collection
+type RequestMetrics struct {
+	Method string
+	Host   string
+	//Path is the URL path, normalized when SetMetricsPathNormalizer is set
+	Path string
+	//StatusCode is the status of the final response, 0 when none was received
+	StatusCode int
+	//Duration is how long Execute took, including retries
+	Duration time.Duration
+	//Attempts is the number of attempts sent, 0 when the request could not be built
+	Attempts int
+	//RequestSize is the size of the request body, -1 when it is streamed
+	RequestSize int64
+	//ResponseSize is the size of the final response body
+	ResponseSize int64
+	//Err is the error returned by Execute, if any
+	Err error
+	//RequestID is the ID sent with the request when EnableRequestID is set
+	RequestID string
+	//Tags are the tags set with SetTag
+	Tags map[string]string
+}
+
+//OnMetrics sets a hook called with the metrics of every Execute, DownloadTo or ExecuteStream once it completes, for counters and histograms
+//of upstream calls. A panic inside the hook is recovered and returned by Execute as an error matching ErrHookPanic
+func (r *Request) OnMetrics(hook func(m RequestMetrics)) *Request {
+	if r == nil {
+		return nil
+	}
+	r.metrics = hook
+	return r
+}
+
+//SetMetricsPathNormalizer sets how the URL path is reported to the OnMetrics hook. Paths are reported as is by default,
+//so IDs in paths should be replaced, e.g. /users/42 by /users/{id}, to keep the cardinality of metric labels bounded
+func (r *Request) SetMetricsPathNormalizer(normalize func(path string) string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.normalizePath = normalize
+	return r
+}
+
+//execution tracks a single Execute, DownloadTo or ExecuteStream: the idempotency key and request ID sent with
+//each of its attempts and, for telemetry, the last attempt sent
+type execution struct {
+	idempotencyKey string
+	requestID      string
+	attempts       int
+	req            *http.Request
+}
+
+//annotate sets the idempotency key and request ID of the execution on resp
+func (e *execution) annotate(resp *Response) {
+	if resp != nil {
+		resp.IdempotencyKey = e.idempotencyKey
+		resp.RequestID = e.requestID
+	}
+}
+
+//runMetricsHook reports the metrics of an Execute, converting a panic into an error
+func (r *Request) runMetricsHook(exec *execution, resp *Response, err error, duration time.Duration) (hookErr error) {
+	if r.metrics == nil {
+		return nil
+	}
+
+	m := RequestMetrics{
+		Method:   r.method,
+		Duration: duration,
+		Attempts: exec.attempts,
+		Err:      err,
+		Tags:     r.Tags(),
+	}
+	u, _ := url.Parse(r.url)
+	if exec.req != nil {
+		m.Method = exec.req.Method
+		m.RequestID = r.requestIDOf(exec.req)
+		u = exec.req.URL
+		m.RequestSize = exec.req.ContentLength
+		if m.RequestSize == 0 && exec.req.Body != nil && exec.req.Body != http.NoBody {
+			m.RequestSize = -1
+		}
+	}
+	if u != nil {
+		m.Host = u.Host
+		m.Path = u.Path
+	}
+	if r.normalizePath != nil {
+		m.Path = r.normalizePath(m.Path)
+	}
+	if resp != nil {
+		m.StatusCode = resp.StatusCode
+		m.ResponseSize = int64(len(resp.Body))
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			hookErr = fmt.Errorf("%w: metrics: %v", ErrHookPanic, recovered)
+		}
+	}()
+
+	r.metrics(m)
+	return nil
+}
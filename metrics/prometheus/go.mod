@@ -0,0 +1,11 @@
+module github.com/AidenHadisi/go-simple-request/metrics/prometheus
+
+go 1.21
+
+require (
+	github.com/AidenHadisi/go-simple-request v0.0.0
+	github.com/prometheus/client_golang v1.17.0
+	github.com/stretchr/testify v1.8.4
+)
+
+replace github.com/AidenHadisi/go-simple-request => ../..
@@ -0,0 +1,72 @@
+//Package prometheus reports the metrics of requests to Prometheus through the OnMetrics hook.
+//It is a separate module so the core module does not depend on the Prometheus client
+package prometheus
+
+import (
+	"strconv"
+
+	request "github.com/AidenHadisi/go-simple-request"
+	prom "github.com/prometheus/client_golang/prometheus"
+)
+
+//StatusError is the status label of requests which got no response, such as transport errors
+const StatusError = "error"
+
+//Collector counts requests and observes their duration, labeled by method, host, path and status.
+//Register it with OnMetrics: r.OnMetrics(collector.Observe)
+type Collector struct {
+	requests *prom.CounterVec
+	duration *prom.HistogramVec
+}
+
+//NewCollector registers a counter vec named <namespace>_http_client_requests_total and a histogram vec named
+//<namespace>_http_client_request_duration_seconds with reg, bucketed with buckets or prom.DefBuckets when none
+//are given. Paths are reported as normalized by SetMetricsPathNormalizer, which should be set to keep the
+//cardinality of the path label bounded
+func NewCollector(reg prom.Registerer, namespace string, buckets ...float64) (*Collector, error) {
+	if len(buckets) == 0 {
+		buckets = prom.DefBuckets
+	}
+
+	c := &Collector{
+		requests: prom.NewCounterVec(prom.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_client_requests_total",
+			Help:      "Number of HTTP requests sent, by method, host, path and status.",
+		}, []string{"method", "host", "path", "status"}),
+		duration: prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_client_request_duration_seconds",
+			Help:      "Duration of HTTP requests including retries, by method, host, path and status.",
+			Buckets:   buckets,
+		}, []string{"method", "host", "path", "status"}),
+	}
+	if err := reg.Register(c.requests); err != nil {
+		return nil, err
+	}
+	if err := reg.Register(c.duration); err != nil {
+		reg.Unregister(c.requests)
+		return nil, err
+	}
+	return c, nil
+}
+
+//Observe records the metrics of a request. It is meant to be passed to OnMetrics
+func (c *Collector) Observe(m request.RequestMetrics) {
+	labels := prom.Labels{
+		"method": m.Method,
+		"host":   m.Host,
+		"path":   m.Path,
+		"status": status(m),
+	}
+	c.requests.With(labels).Inc()
+	c.duration.With(labels).Observe(m.Duration.Seconds())
+}
+
+//status returns the status label of a request, StatusError when no response was received
+func status(m request.RequestMetrics) string {
+	if m.StatusCode == 0 {
+		return StatusError
+	}
+	return strconv.Itoa(m.StatusCode)
+}
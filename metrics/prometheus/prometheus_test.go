@@ -0,0 +1,81 @@
+package prometheus
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	request "github.com/AidenHadisi/go-simple-request"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type errClient struct {
+	err error
+}
+
+func (c errClient) Do(req *http.Request) (*http.Response, error) {
+	return nil, c.err
+}
+
+func TestCollector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/users/404" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	host := server.Listener.Addr().String()
+
+	reg := prom.NewRegistry()
+	collector, err := NewCollector(reg, "api")
+	assert.Nil(t, err)
+
+	ids := regexp.MustCompile(`/\d+`)
+	template := request.New().OnMetrics(collector.Observe).SetMetricsPathNormalizer(func(path string) string {
+		return ids.ReplaceAllString(path, "/{id}")
+	})
+	count := func(method, host, path, status string) float64 {
+		return testutil.ToFloat64(collector.requests.WithLabelValues(method, host, path, status))
+	}
+
+	t.Run("success", func(t *testing.T) {
+		_, err := template.New().Get(server.URL + "/users/1").Execute()
+		assert.Nil(t, err)
+		_, err = template.New().Get(server.URL + "/users/2").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, float64(2), count("GET", host, "/users/{id}", "200"))
+	})
+
+	t.Run("HTTP error", func(t *testing.T) {
+		resp, err := template.New().Get(server.URL + "/users/404").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, float64(1), count("GET", host, "/users/{id}", "404"))
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		_, err := template.New().SetClient(errClient{errors.New("connection refused")}).
+			Delete("http://upstream.test/users/3").Execute()
+		assert.NotNil(t, err)
+		assert.Equal(t, float64(1), count("DELETE", "upstream.test", "/users/{id}", StatusError))
+	})
+
+	t.Run("durations", func(t *testing.T) {
+		assert.Equal(t, 3, testutil.CollectAndCount(collector.duration))
+		assert.Equal(t, 3, testutil.CollectAndCount(collector.requests))
+	})
+
+	t.Run("registered once", func(t *testing.T) {
+		_, err := NewCollector(reg, "api")
+		assert.NotNil(t, err)
+
+		_, err = NewCollector(reg, "other")
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,87 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnMetrics(t *testing.T) {
+	ids := regexp.MustCompile(`/\d+`)
+	normalize := func(path string) string {
+		return ids.ReplaceAllString(path, "/{id}")
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var metrics []RequestMetrics
+		r := newMockRequest(fakeHandler(201, `{"id":42}`, nil))
+
+		_, err := r.Post("http://example.com/users/42/posts?page=2").SetBody(strings.NewReader("hello")).
+			SetMetricsPathNormalizer(normalize).
+			OnMetrics(func(m RequestMetrics) { metrics = append(metrics, m) }).
+			Execute()
+		assert.Nil(t, err)
+		if assert.Len(t, metrics, 1) {
+			m := metrics[0]
+			assert.Equal(t, "POST", m.Method)
+			assert.Equal(t, "example.com", m.Host)
+			assert.Equal(t, "/users/{id}/posts", m.Path)
+			assert.Equal(t, 201, m.StatusCode)
+			assert.Equal(t, 1, m.Attempts)
+			assert.Equal(t, int64(5), m.RequestSize)
+			assert.Equal(t, int64(9), m.ResponseSize)
+			assert.Nil(t, m.Err)
+		}
+	})
+
+	t.Run("http error with retries", func(t *testing.T) {
+		calls := 0
+		var metrics RequestMetrics
+		r := newMockRequest(countingHandler(&calls, []int{503}, `{}`))
+
+		_, err := r.Get("http://example.com/users").SetRetry(2, time.Millisecond).SetClock(newFakeClock()).
+			OnMetrics(func(m RequestMetrics) { metrics = m }).
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 503, metrics.StatusCode)
+		assert.Equal(t, 3, metrics.Attempts)
+		assert.Equal(t, "/users", metrics.Path)
+		assert.Equal(t, int64(0), metrics.RequestSize)
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		var metrics RequestMetrics
+		transportErr := errors.New("connection refused")
+		r := &Request{client: &errClient{err: transportErr}, header: make(http.Header)}
+
+		_, err := r.Get("http://example.com/users").OnMetrics(func(m RequestMetrics) { metrics = m }).Execute()
+		assert.ErrorIs(t, err, transportErr)
+		assert.Equal(t, 0, metrics.StatusCode)
+		assert.Equal(t, 1, metrics.Attempts)
+		assert.ErrorIs(t, metrics.Err, transportErr)
+		assert.Equal(t, "example.com", metrics.Host)
+	})
+
+	t.Run("request not built", func(t *testing.T) {
+		var metrics RequestMetrics
+		_, err := New().Post("http://example.com/upload").SetBodyFromFile("missing.json", "").
+			OnMetrics(func(m RequestMetrics) { metrics = m }).Execute()
+		assert.NotNil(t, err)
+		assert.Equal(t, 0, metrics.Attempts)
+		assert.Equal(t, "POST", metrics.Method)
+		assert.Equal(t, "/upload", metrics.Path)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(200, `{}`, nil))
+
+		result, err := r.Get("http://example.com").OnMetrics(func(RequestMetrics) { panic("boom") }).Execute()
+		assert.ErrorIs(t, err, ErrHookPanic)
+		assert.Equal(t, 200, result.StatusCode)
+	})
+}
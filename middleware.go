@@ -0,0 +1,65 @@
+package request
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+//Endpoint sends a request and returns the response, see Middleware
+type Endpoint func(ctx context.Context, req *http.Request) (*http.Response, error)
+
+//Middleware wraps an endpoint, for example to cache responses or retry on authentication errors.
+//It may modify the request or context before calling next, call next more than once, replace the response
+//or return without calling next at all, in which case the request never reaches the network
+type Middleware func(next Endpoint) Endpoint
+
+//Use adds middlewares around sending every attempt. Middlewares and interceptors compose in the order they
+//were added, the first one outermost, after the request is signed and before the response body is read.
+//The context passed to next becomes the context of the request sent
+func (r *Request) Use(mw ...Middleware) *Request {
+	if r == nil {
+		return nil
+	}
+	for _, m := range mw {
+		m := m
+		r.AddInterceptor(func(req *http.Request, next func(req *http.Request) (*http.Response, error)) (*http.Response, error) {
+			endpoint := m(func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				if ctx != req.Context() {
+					req = req.WithContext(ctx)
+				}
+				return next(req)
+			})
+			return endpoint(req.Context(), req)
+		})
+	}
+	return r
+}
+
+//LoggingMiddleware logs every request sent through it at info level, or error level when it fails,
+//with the method, URL without query params, fragment and credentials, status and duration.
+//Durations are measured with the clock set with SetClock
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next Endpoint) Endpoint {
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("url", sanitizeURL(req)),
+			}
+
+			clock := clockOf(ctx)
+			start := clock.Now()
+			resp, err := next(ctx, req)
+			attrs = append(attrs, slog.Duration("duration", clock.Now().Sub(start)))
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+				logger.LogAttrs(ctx, slog.LevelError, "request failed", attrs...)
+				return resp, err
+			}
+
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			logger.LogAttrs(ctx, slog.LevelInfo, "request completed", attrs...)
+			return resp, nil
+		}
+	}
+}
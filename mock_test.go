@@ -0,0 +1,49 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetMockResponse(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		var success fakeSuccess
+		result, err := New().Post("http://example.com/users").
+			SetBody(&fakeSuccess{Name: "John"}).
+			SetSuccess(&success).
+			SetMockResponse(201, `{"ID":1,"Name":"John"}`, http.Header{"X-Request-Id": {"abc"}}).
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 201, result.StatusCode)
+		assert.Equal(t, "abc", result.Header.Get("X-Request-ID"))
+		assert.Equal(t, fakeSuccess{ID: 1, Name: "John"}, success)
+		assert.Nil(t, result.Err())
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		var failure fakeSuccess
+		result, err := New().Get("http://example.com/users/2").
+			SetSuccess(&fakeSuccess{}).
+			SetFailure(&failure).
+			SetMockResponse(404, `{"ID":2,"Name":"not found"}`, nil).
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 404, result.StatusCode)
+		assert.Equal(t, fakeSuccess{ID: 2, Name: "not found"}, failure)
+
+		var httpErr *HTTPError
+		assert.True(t, errors.As(result.Err(), &httpErr))
+		assert.Equal(t, 404, httpErr.StatusCode)
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := New().Get("http://example.com").SetContext(ctx).SetMockResponse(200, `{}`, nil).Execute()
+		assert.True(t, errors.Is(err, context.Canceled))
+	})
+}
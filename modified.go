@@ -0,0 +1,34 @@
+package request
+
+import (
+	"net/http"
+	"time"
+)
+
+//SetIfModifiedSince makes the request conditional on the resource having changed since t, so the server
+//answers 304 Not Modified otherwise. The header holds whole seconds in GMT: t is converted to UTC and its
+//sub-second part is truncated rather than rounded, as rounding up would hide changes made up to a second later.
+//A zero t removes the header.
+//With a cache, expired responses carrying a Last-Modified date are revalidated this way automatically
+func (r *Request) SetIfModifiedSince(t time.Time) *Request {
+	if r == nil {
+		return nil
+	}
+
+	if t.IsZero() {
+		r.header.Del("If-Modified-Since")
+		return r
+	}
+	r.header.Set("If-Modified-Since", t.UTC().Format(http.TimeFormat))
+	return r
+}
+
+//lastModified returns the Last-Modified header of resp when it is a valid HTTP date, to be sent back
+//unchanged in If-Modified-Since as RFC 9110 section 13.1.3 recommends
+func lastModified(resp *Response) string {
+	date := resp.Header.Get("Last-Modified")
+	if _, err := http.ParseTime(date); err != nil {
+		return ""
+	}
+	return date
+}
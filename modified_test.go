@@ -0,0 +1,136 @@
+package request
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetIfModifiedSince(t *testing.T) {
+	header := func(r *Request) string {
+		req, err := r.Request()
+		assert.Nil(t, err)
+		return req.Header.Get("If-Modified-Since")
+	}
+
+	t.Run("whole seconds in GMT", func(t *testing.T) {
+		tokyo := time.FixedZone("JST", 9*60*60)
+		modified := time.Date(2021, time.June, 1, 21, 30, 15, 0, tokyo)
+		assert.Equal(t, "Tue, 01 Jun 2021 12:30:15 GMT", header(New().Get("http://example.com").SetIfModifiedSince(modified)))
+	})
+
+	t.Run("sub-second part is truncated", func(t *testing.T) {
+		modified := time.Date(2021, time.June, 1, 12, 30, 15, 999999999, time.UTC)
+		assert.Equal(t, "Tue, 01 Jun 2021 12:30:15 GMT", header(New().Get("http://example.com").SetIfModifiedSince(modified)))
+	})
+
+	t.Run("crossing midnight in another zone", func(t *testing.T) {
+		newYork := time.FixedZone("EDT", -4*60*60)
+		modified := time.Date(2021, time.December, 31, 22, 0, 0, 500000000, newYork)
+		assert.Equal(t, "Sat, 01 Jan 2022 02:00:00 GMT", header(New().Get("http://example.com").SetIfModifiedSince(modified)))
+	})
+
+	t.Run("zero time removes the header", func(t *testing.T) {
+		r := New().Get("http://example.com").SetIfModifiedSince(time.Now()).SetIfModifiedSince(time.Time{})
+		assert.Equal(t, "", header(r))
+	})
+}
+
+func TestLastModifiedRevalidation(t *testing.T) {
+	const lastModified = "Tue, 01 Jun 2021 12:00:00 GMT"
+	var conditions []string
+	newTemplate := func(date string) *Request {
+		conditions = nil
+		modified, _ := http.ParseTime(date)
+		return newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			condition := req.Header.Get("If-Modified-Since")
+			conditions = append(conditions, condition)
+			if since, err := http.ParseTime(condition); err == nil && !modified.After(since) {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", date)
+			w.Write([]byte(`{"ID":1,"Name":"John"}`))
+		})
+	}
+
+	t.Run("304 serves the cached response", func(t *testing.T) {
+		template := newTemplate(lastModified).EnableCache(0, 10)
+
+		template.New().Get("http://example.com/config").Execute()
+		success := &fakeSuccess{}
+		resp, err := template.New().Get("http://example.com/config").SetSuccess(success).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.True(t, resp.Revalidated)
+		assert.Equal(t, &fakeSuccess{ID: 1, Name: "John"}, success)
+		assert.Equal(t, []string{"", lastModified}, conditions)
+	})
+
+	t.Run("200 replaces the cached response", func(t *testing.T) {
+		date := lastModified
+		calls := 0
+		template := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			calls++
+			conditions = append(conditions, req.Header.Get("If-Modified-Since"))
+			if calls == 2 {
+				date = "Tue, 01 Jun 2021 13:00:00 GMT"
+			}
+			if req.Header.Get("If-Modified-Since") == date {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", date)
+			w.Write([]byte(`{}`))
+		}).EnableCache(0, 10)
+		conditions = nil
+
+		for i := 0; i < 3; i++ {
+			resp, err := template.New().Get("http://example.com/config").Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, i == 2, resp.Revalidated)
+		}
+		assert.Equal(t, []string{"", lastModified, "Tue, 01 Jun 2021 13:00:00 GMT"}, conditions)
+	})
+
+	t.Run("the caller's date is kept and its 304 passed through", func(t *testing.T) {
+		template := newTemplate(lastModified).EnableCache(0, 10)
+
+		template.New().Get("http://example.com/config").Execute()
+		since := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+		resp, err := template.New().Get("http://example.com/config").SetIfModifiedSince(since).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+		assert.False(t, resp.Revalidated)
+	})
+
+	t.Run("invalid dates are not used", func(t *testing.T) {
+		template := newTemplate("yesterday").EnableCache(0, 10)
+
+		template.New().Get("http://example.com/config").Execute()
+		resp, _ := template.New().Get("http://example.com/config").Execute()
+		assert.False(t, resp.Revalidated)
+		assert.Equal(t, []string{"", ""}, conditions)
+		assert.Equal(t, 0, template.cache.Len())
+	})
+
+	t.Run("sent along with If-None-Match", func(t *testing.T) {
+		var header http.Header
+		template := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			header = req.Header
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Last-Modified", lastModified)
+			if req.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+			}
+		}).EnableCache(0, 10)
+
+		template.New().Get("http://example.com/config").Execute()
+		resp, _ := template.New().Get("http://example.com/config").Execute()
+		assert.True(t, resp.Revalidated)
+		assert.Equal(t, `"v1"`, header.Get("If-None-Match"))
+		assert.Equal(t, lastModified, header.Get("If-Modified-Since"))
+	})
+}
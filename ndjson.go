@@ -0,0 +1,72 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+//SetNDJSONBody streams the values received from ch as the request body, each marshaled to JSON on its own line,
+//with the Content-Type application/x-ndjson. The body ends when ch is closed. Values are only received while
+//the body is being sent, so the dataset is never buffered. The body can only be sent once, so the request is
+//never retried nor sent again after a 401 Unauthorized response, and must not be hedged
+func (r *Request) SetNDJSONBody(ch <-chan interface{}) *Request {
+	if r == nil {
+		return nil
+	}
+	r.body = ndjsonBody{ch: ch}
+	r.header.Set("Content-Type", "application/x-ndjson")
+	return r
+}
+
+//ndjsonBody is a request body streamed from a channel
+type ndjsonBody struct {
+	ch <-chan interface{}
+}
+
+//reader returns a reader receiving from the channel until it is closed or ctx is done
+func (b ndjsonBody) reader(ctx context.Context) io.Reader {
+	return &ndjsonReader{ch: b.ch, ctx: ctx}
+}
+
+//replayable reports whether the request body can be sent again on retry
+func (r *Request) replayable() bool {
+	_, ok := r.body.(ndjsonBody)
+	return !ok
+}
+
+//ndjsonReader marshals values received from a channel into newline delimited JSON as it is read
+type ndjsonReader struct {
+	ch  <-chan interface{}
+	ctx context.Context
+	buf bytes.Buffer
+	err error
+}
+
+func (n *ndjsonReader) Read(p []byte) (int, error) {
+	for n.buf.Len() == 0 {
+		if n.err != nil {
+			return 0, n.err
+		}
+
+		select {
+		case <-n.ctx.Done():
+			n.err = n.ctx.Err()
+		case value, ok := <-n.ch:
+			if !ok {
+				n.err = io.EOF
+				continue
+			}
+			line, err := json.Marshal(value)
+			if err != nil {
+				n.err = fmt.Errorf("failed to marshal NDJSON record: %w", err)
+				continue
+			}
+			n.buf.Write(line)
+			n.buf.WriteByte('\n')
+		}
+	}
+	return n.buf.Read(p)
+}
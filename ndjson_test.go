@@ -0,0 +1,96 @@
+package request
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetNDJSONBody(t *testing.T) {
+	var records []fakeSuccess
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var record fakeSuccess
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			records = append(records, record)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ch := make(chan interface{})
+	go func() {
+		for i := 1; i <= 3; i++ {
+			ch <- fakeSuccess{ID: i, Name: "John"}
+		}
+		close(ch)
+	}()
+
+	result, err := New().Post(server.URL).SetNDJSONBody(ch).Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, "application/x-ndjson", contentType)
+	assert.Equal(t, []fakeSuccess{{1, "John"}, {2, "John"}, {3, "John"}}, records)
+}
+
+func TestSetNDJSONBodyNotRetried(t *testing.T) {
+	calls := 0
+	ch := make(chan interface{}, 1)
+	ch <- map[string]int{"id": 1}
+	close(ch)
+
+	r := newMockRequest(countingHandler(&calls, []int{503}, `{}`))
+	result, err := r.Put("http://example.com").SetNDJSONBody(ch).SetRetry(3, time.Millisecond).Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 503, result.StatusCode)
+	assert.Equal(t, 1, calls)
+
+	t.Run("nor reauthorized", func(t *testing.T) {
+		var calls, refreshes int32
+		ch := make(chan interface{})
+		close(ch)
+
+		r := newMockRequest(bearerHandler("fresh", &calls)).SetTokenRefresher(func() (string, error) {
+			atomic.AddInt32(&refreshes, 1)
+			return "fresh", nil
+		})
+		result, err := r.Post("http://example.com").SetNDJSONBody(ch).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 401, result.StatusCode)
+		assert.Equal(t, int32(1), calls)
+		assert.Equal(t, int32(0), refreshes)
+	})
+}
+
+func TestNDJSONReader(t *testing.T) {
+	t.Run("marshal error", func(t *testing.T) {
+		ch := make(chan interface{}, 1)
+		ch <- func() {}
+		reader := ndjsonBody{ch: ch}.reader(context.Background())
+
+		_, err := reader.Read(make([]byte, 10))
+		assert.Contains(t, err.Error(), "failed to marshal NDJSON record")
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		reader := ndjsonBody{ch: make(chan interface{})}.reader(ctx)
+
+		_, err := reader.Read(make([]byte, 10))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -0,0 +1,50 @@
+package request
+
+import (
+	"net/url"
+)
+
+//Paginate executes the request and keeps following the URL returned by nextFn until it reports done.
+//onPage is called with every page. Relative next URLs are resolved against the current page URL
+//and next URLs are requested with their own query params only.
+//Pages are decoded into the same Success and Failure values, so onPage should copy out what it needs.
+//Paginate stops at the first error
+func (r *Request) Paginate(nextFn func(*Response) (nextURL string, done bool), onPage func(*Response) error) error {
+	page := r
+	for {
+		resp, err := page.Execute()
+		if err != nil {
+			return err
+		}
+		if err := onPage(resp); err != nil {
+			return err
+		}
+
+		next, done := nextFn(resp)
+		if done {
+			return nil
+		}
+
+		nextURL, err := resolveURL(page.url, next)
+		if err != nil {
+			return err
+		}
+		page = page.New()
+		page.query = nil
+		page.url = nextURL
+	}
+}
+
+//resolveURL resolves ref against base
+func resolveURL(base, ref string) (string, error) {
+	refURL, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+
+	return baseURL.ResolveReference(refURL).String(), nil
+}
@@ -0,0 +1,88 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakePage struct {
+	Items []int  `json:"items"`
+	Next  string `json:"next"`
+}
+
+func pagesHandler(requested *[]string) http.HandlerFunc {
+	pages := map[string]string{
+		"":         `{"items": [1, 2], "next": "/items?cursor=b"}`,
+		"cursor=b": `{"items": [3, 4], "next": "http://example.com/items?cursor=c"}`,
+		"cursor=c": `{"items": [5], "next": ""}`,
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		*requested = append(*requested, r.URL.String())
+		body, ok := pages[r.URL.RawQuery]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}
+}
+
+func nextPage(resp *Response) (string, bool) {
+	page := resp.Success.(*fakePage)
+	return page.Next, page.Next == ""
+}
+
+func TestPaginate(t *testing.T) {
+	var requested []string
+	r := newMockRequest(pagesHandler(&requested))
+
+	var items []int
+	err := r.Get("http://example.com/items").SetSuccess(&fakePage{}).Paginate(nextPage, func(resp *Response) error {
+		items = append(items, resp.Success.(*fakePage).Items...)
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, items)
+	assert.Equal(t, []string{
+		"http://example.com/items",
+		"http://example.com/items?cursor=b",
+		"http://example.com/items?cursor=c",
+	}, requested)
+}
+
+func TestPaginateStopsOnError(t *testing.T) {
+	t.Run("onPage error", func(t *testing.T) {
+		var requested []string
+		r := newMockRequest(pagesHandler(&requested))
+		stop := errors.New("stop")
+
+		pages := 0
+		err := r.Get("http://example.com/items").SetSuccess(&fakePage{}).Paginate(nextPage, func(resp *Response) error {
+			pages++
+			if pages == 2 {
+				return stop
+			}
+			return nil
+		})
+
+		assert.Equal(t, stop, err)
+		assert.Len(t, requested, 2)
+	})
+
+	t.Run("execute error", func(t *testing.T) {
+		client := &errClient{err: fmt.Errorf("connection refused")}
+		r := &Request{client: client}
+
+		err := r.Get("http://example.com/items").Paginate(nextPage, func(resp *Response) error {
+			t.Error("onPage must not be called")
+			return nil
+		})
+		assert.NotNil(t, err)
+	})
+}
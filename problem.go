@@ -0,0 +1,63 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+)
+
+//ProblemDetails is an RFC 7807 problem details object
+type ProblemDetails struct {
+	//Type is a URI identifying the problem type, about:blank when absent
+	Type     string `json:"type"`
+	Title    string `json:"title,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	//Extensions holds any other members of the problem object
+	Extensions map[string]interface{} `json:"-"`
+}
+
+//Problem decodes the body of an application/problem+json response.
+//It returns an error matching ErrNotProblem when the Content-Type is not application/problem+json
+//and a *DecodeError when the body is not a JSON object
+func (r *Response) Problem() (*ProblemDetails, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/problem+json" {
+		return nil, fmt.Errorf("%w: content type is %q", ErrNotProblem, r.Header.Get("Content-Type"))
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(r.Body, &members); err != nil {
+		return nil, &DecodeError{Err: err}
+	}
+	if members == nil {
+		return nil, &DecodeError{Err: errors.New("response body is null, not a JSON object")}
+	}
+
+	problem := &ProblemDetails{}
+	if err := json.Unmarshal(r.Body, problem); err != nil {
+		return nil, &DecodeError{Err: err}
+	}
+	if problem.Type == "" {
+		problem.Type = "about:blank"
+	}
+
+	for name, raw := range members {
+		switch name {
+		case "type", "title", "status", "detail", "instance":
+			continue
+		}
+		var value interface{}
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, &DecodeError{Err: err}
+		}
+		if problem.Extensions == nil {
+			problem.Extensions = make(map[string]interface{})
+		}
+		problem.Extensions[name] = value
+	}
+
+	return problem, nil
+}
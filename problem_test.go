@@ -0,0 +1,63 @@
+package request
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProblem(t *testing.T) {
+	t.Run("decoded", func(t *testing.T) {
+		resp := &Response{
+			Header: http.Header{"Content-Type": {"application/problem+json; charset=utf-8"}},
+			Body: []byte(`{
+				"type": "https://example.com/probs/out-of-credit",
+				"title": "You do not have enough credit.",
+				"status": 403,
+				"detail": "Your current balance is 30, but that costs 50.",
+				"instance": "/account/12345/msgs/abc",
+				"balance": 30,
+				"accounts": ["/account/12345", "/account/67890"]
+			}`),
+		}
+
+		problem, err := resp.Problem()
+		assert.Nil(t, err)
+		assert.Equal(t, &ProblemDetails{
+			Type:     "https://example.com/probs/out-of-credit",
+			Title:    "You do not have enough credit.",
+			Status:   403,
+			Detail:   "Your current balance is 30, but that costs 50.",
+			Instance: "/account/12345/msgs/abc",
+			Extensions: map[string]interface{}{
+				"balance":  float64(30),
+				"accounts": []interface{}{"/account/12345", "/account/67890"},
+			},
+		}, problem)
+	})
+
+	t.Run("default type", func(t *testing.T) {
+		resp := &Response{Header: http.Header{"Content-Type": {"application/problem+json"}}, Body: []byte(`{"title":"Not Found","status":404}`)}
+
+		problem, err := resp.Problem()
+		assert.Nil(t, err)
+		assert.Equal(t, &ProblemDetails{Type: "about:blank", Title: "Not Found", Status: 404}, problem)
+	})
+
+	t.Run("wrong content type", func(t *testing.T) {
+		resp := &Response{Header: http.Header{"Content-Type": {"application/json"}}, Body: []byte(`{"title":"Not Found"}`)}
+
+		_, err := resp.Problem()
+		assert.ErrorIs(t, err, ErrNotProblem)
+	})
+
+	t.Run("invalid body", func(t *testing.T) {
+		for _, body := range []string{`not json`, `[]`, `{"status":"404"}`, `null`} {
+			resp := &Response{Header: http.Header{"Content-Type": {"application/problem+json"}}, Body: []byte(body)}
+
+			_, err := resp.Problem()
+			assert.True(t, IsDecodeError(err), body)
+		}
+	})
+}
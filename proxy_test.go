@@ -0,0 +1,141 @@
+package request
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//newTestProxy starts a proxy requiring basic auth for user:pass which forwards http requests and tunnels CONNECT requests
+func newTestProxy(t *testing.T) (*httptest.Server, *int32) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := (&http.Request{Header: http.Header{"Authorization": r.Header["Proxy-Authorization"]}}).BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		atomic.AddInt32(&proxied, 1)
+
+		if r.Method == http.MethodConnect {
+			target, err := net.Dial("tcp", r.Host)
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				target.Close()
+				return
+			}
+			go func() {
+				io.Copy(target, conn)
+				target.Close()
+			}()
+			io.Copy(conn, target)
+			conn.Close()
+			return
+		}
+
+		r.RequestURI = ""
+		r.Header.Del("Proxy-Authorization")
+		resp, err := http.DefaultTransport.RoundTrip(r)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	t.Cleanup(proxy.Close)
+	return proxy, &proxied
+}
+
+//assertRejected returns an assertion that the proxy refused the request, which fails the CONNECT of https targets
+func assertRejected(t *testing.T, target string) func(*Response, error) {
+	return func(result *Response, err error) {
+		if strings.HasPrefix(target, "https") {
+			assert.NotNil(t, err)
+			return
+		}
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusProxyAuthRequired, result.StatusCode)
+	}
+}
+
+func TestSetProxy(t *testing.T) {
+	var leaked int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Proxy-Authorization") != "" {
+			atomic.AddInt32(&leaked, 1)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+
+	//trusts the https target through the transport's RootCAs
+	client := func() *Request {
+		r := New()
+		r.client = secure.Client()
+		return r
+	}
+
+	proxy, proxied := newTestProxy(t)
+	proxyURL, _ := url.Parse(proxy.URL)
+	withUserInfo, _ := url.Parse(proxy.URL)
+	withUserInfo.User = url.UserPassword("user", "pass")
+
+	for _, target := range []string{plain.URL, secure.URL} {
+		t.Run(target, func(t *testing.T) {
+			t.Run("user info", func(t *testing.T) {
+				before := atomic.LoadInt32(proxied)
+				result, err := client().Get(target).SetProxy(withUserInfo).Execute()
+				assert.Nil(t, err)
+				assert.Equal(t, 200, result.StatusCode)
+				assert.Equal(t, before+1, atomic.LoadInt32(proxied))
+			})
+
+			t.Run("proxy auth", func(t *testing.T) {
+				before := atomic.LoadInt32(proxied)
+				result, err := client().Get(target).SetProxy(proxyURL).SetProxyAuth("user", "pass").Execute()
+				assert.Nil(t, err)
+				assert.Equal(t, 200, result.StatusCode)
+				assert.Equal(t, before+1, atomic.LoadInt32(proxied))
+			})
+
+			t.Run("missing credentials", func(t *testing.T) {
+				assertRejected(t, target)(client().Get(target).SetProxy(proxyURL).Execute())
+			})
+
+			t.Run("wrong credentials", func(t *testing.T) {
+				assertRejected(t, target)(client().Get(target).SetProxy(proxyURL).SetProxyAuth("user", "wrong").Execute())
+			})
+		})
+	}
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&leaked))
+
+	t.Run("not sent without proxy", func(t *testing.T) {
+		req, err := client().Get(plain.URL).SetProxy(nil).SetProxyAuth("user", "pass").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "", req.Header.Get("Proxy-Authorization"))
+	})
+
+	t.Run("original client untouched", func(t *testing.T) {
+		base := client()
+		base.New().SetProxy(proxyURL).SetProxyAuth("user", "pass")
+		assert.Nil(t, base.client.(*http.Client).Transport.(*http.Transport).ProxyConnectHeader)
+		assert.Equal(t, "", base.proxyAuth)
+	})
+}
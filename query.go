@@ -0,0 +1,507 @@
+package request
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//QueryOrdering controls the order in which query params are encoded
+type QueryOrdering int
+
+const (
+	//QueryOrderAlphabetical encodes params sorted by key. This is the default
+	QueryOrderAlphabetical QueryOrdering = iota
+	//QueryOrderInsertion encodes params in the order they were added: params already in the URL first,
+	//then those set with SetQuery sorted by key, then those added with AddQueryParam
+	QueryOrderInsertion
+)
+
+//BoolQueryStyle controls how bool fields of the query struct are encoded
+type BoolQueryStyle int
+
+const (
+	//BoolTrueFalse encodes bools as true and false. This is the default
+	BoolTrueFalse BoolQueryStyle = iota
+	//BoolOneZero encodes bools as 1 and 0
+	BoolOneZero
+	//BoolPresence encodes true as a bare flag without a value and leaves false out
+	BoolPresence
+)
+
+//QueryNilHandling controls how nil pointer fields of the query struct are encoded
+type QueryNilHandling int
+
+const (
+	//QueryNilByTag leaves nil fields to go-querystring, which omits them when tagged omitempty
+	//and encodes them as an empty value otherwise. This is the default
+	QueryNilByTag QueryNilHandling = iota
+	//QueryNilOmit leaves nil fields out of the query
+	QueryNilOmit
+	//QueryNilEmpty encodes nil fields as an empty value, e.g. field=, even when tagged omitempty
+	QueryNilEmpty
+)
+
+const (
+	//QueryTimeUnix encodes time fields of the query struct as Unix timestamps in seconds, see SetQueryTimeFormat
+	QueryTimeUnix = "unix"
+	//QueryTimeUnixMilli encodes time fields of the query struct as Unix timestamps in milliseconds, see SetQueryTimeFormat
+	QueryTimeUnixMilli = "unixmilli"
+)
+
+type queryParam struct {
+	key   string
+	value string
+}
+
+//AddQueryParam is used to add a single query param for request
+func (r *Request) AddQueryParam(key, value string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.params = append(r.params, queryParam{key, value})
+	return r
+}
+
+//SetQueryOrdering is used to set the order in which query params are encoded
+func (r *Request) SetQueryOrdering(ordering QueryOrdering) *Request {
+	if r == nil {
+		return nil
+	}
+	r.ordering = ordering
+	return r
+}
+
+//SetQueryTimeFormat is used to set how time fields of the query struct are encoded. layout is a time layout
+//such as time.RFC1123, or QueryTimeUnix or QueryTimeUnixMilli for Unix timestamps. Defaults to RFC3339.
+//Fields with their own unix, unixmilli or layout tag options keep their format
+func (r *Request) SetQueryTimeFormat(layout string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.timeFormat = layout
+	return r
+}
+
+//SetQueryNilHandling is used to set how nil pointer fields of the query struct are encoded,
+//for APIs which tell an absent param from an empty one
+func (r *Request) SetQueryNilHandling(handling QueryNilHandling) *Request {
+	if r == nil {
+		return nil
+	}
+	r.nilHandling = handling
+	return r
+}
+
+//SetQueryPrefix is used to namespace the params encoded from the query set with SetQuery, so that
+//a name param is encoded as prefix[name]. Params in the URL or added with AddQueryParam are left as is
+func (r *Request) SetQueryPrefix(prefix string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.queryPrefix = prefix
+	return r
+}
+
+//SetQueryFieldNameFunc is used to set how the keys of query struct fields without a name in their url tag
+//are derived from the Go field name, e.g. SnakeCase to encode UserName as user_name. It applies to the fields
+//of the query struct and of its embedded structs
+func (r *Request) SetQueryFieldNameFunc(fn func(field string) string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.fieldName = fn
+	return r
+}
+
+//SetBoolQueryStyle is used to set how bool fields of the query struct are encoded
+func (r *Request) SetBoolQueryStyle(style BoolQueryStyle) *Request {
+	if r == nil {
+		return nil
+	}
+	r.boolStyle = style
+	return r
+}
+
+//encodeQuery merges the request query params into u. Params set with SetQuery replace those of the same key in the URL
+//and API keys placed in the query replace any other param of the same key.
+//u is left untouched when there are no params to add
+func (r *Request) encodeQuery(u *url.URL) error {
+	if r.queryErr != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidQuery, r.queryErr)
+	}
+	values, err := r.queryValues()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidQuery, err)
+	}
+	values = r.applyNilHandling(values)
+	flags := r.applyBoolStyle(values)
+	r.applyTimeFormat(values)
+	values, flags = r.applyFieldNames(values, flags)
+	values, flags = r.applyPrefix(values, flags)
+
+	added := r.params
+	for _, k := range r.apiKeys {
+		if k.in != KeyInQuery {
+			continue
+		}
+		if values == nil {
+			values = make(url.Values)
+		}
+		values.Set(k.name, k.key)
+		added = withoutParam(added, k.name)
+	}
+
+	if len(values) == 0 && len(added) == 0 {
+		return nil
+	}
+
+	var raw string
+	if r.ordering == QueryOrderInsertion {
+		raw = encodeOrdered(orderedParams(u.RawQuery, values, added))
+	} else {
+		params := u.Query()
+		for key, v := range values {
+			params[key] = v
+		}
+		for _, param := range added {
+			params.Add(param.key, param.value)
+		}
+		raw = params.Encode()
+	}
+
+	u.RawQuery = stripFlagValues(raw, flags)
+	return nil
+}
+
+//applyBoolStyle rewrites the values of the bool fields of the query struct in the configured style
+//and returns the keys which should be encoded as presence-only flags
+func (r *Request) applyBoolStyle(values url.Values) []string {
+	if r.boolStyle == BoolTrueFalse {
+		return nil
+	}
+
+	var flags []string
+	for _, key := range boolQueryKeys(r.query) {
+		v, ok := values[key]
+		if !ok || len(v) == 0 {
+			continue
+		}
+		b, err := strconv.ParseBool(v[0])
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case r.boolStyle == BoolOneZero && b:
+			values.Set(key, "1")
+		case r.boolStyle == BoolOneZero:
+			values.Set(key, "0")
+		case b:
+			values.Set(key, "")
+			flags = append(flags, key)
+		default:
+			values.Del(key)
+		}
+	}
+	return flags
+}
+
+//applyNilHandling adds or removes the nil pointer fields of the query struct as configured.
+//It returns values, allocated when a field is added to a nil values
+func (r *Request) applyNilHandling(values url.Values) url.Values {
+	if r.nilHandling == QueryNilByTag {
+		return values
+	}
+
+	v, ok := queryStruct(r.query)
+	if !ok {
+		return values
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+		if field.PkgPath != "" || field.Anonymous || (value.Kind() != reflect.Ptr && value.Kind() != reflect.Interface) || !value.IsNil() {
+			continue
+		}
+
+		name := queryFieldName(field)
+		if name == "-" {
+			continue
+		}
+		if r.nilHandling == QueryNilOmit {
+			values.Del(name)
+			continue
+		}
+		if values == nil {
+			values = make(url.Values)
+		}
+		values.Set(name, "")
+	}
+	return values
+}
+
+//applyPrefix namespaces the keys of values and flags with the query prefix
+func (r *Request) applyPrefix(values url.Values, flags []string) (url.Values, []string) {
+	if r.queryPrefix == "" || len(values) == 0 {
+		return values, flags
+	}
+
+	prefixed := make(url.Values, len(values))
+	for key, v := range values {
+		prefixed[r.queryPrefix+"["+key+"]"] = v
+	}
+	prefixedFlags := make([]string, len(flags))
+	for i, flag := range flags {
+		prefixedFlags[i] = r.queryPrefix + "[" + flag + "]"
+	}
+	return prefixed, prefixedFlags
+}
+
+//applyFieldNames renames the keys of untagged query struct fields in values and flags with the field name func
+func (r *Request) applyFieldNames(values url.Values, flags []string) (url.Values, []string) {
+	if r.fieldName == nil || len(values) == 0 {
+		return values, flags
+	}
+	v, ok := queryStruct(r.query)
+	if !ok {
+		return values, flags
+	}
+
+	renames := make(map[string]string)
+	r.fieldRenames(v.Type(), renames)
+	renamed := make(url.Values, len(values))
+	for key, v := range values {
+		if name, ok := renames[key]; ok {
+			key = name
+		}
+		renamed[key] = append(renamed[key], v...)
+	}
+	renamedFlags := make([]string, len(flags))
+	for i, flag := range flags {
+		if name, ok := renames[flag]; ok {
+			flag = name
+		}
+		renamedFlags[i] = flag
+	}
+	return renamed, renamedFlags
+}
+
+//fieldRenames maps the names of the untagged fields of t and of its embedded structs to their keys
+func (r *Request) fieldRenames(t reflect.Type, renames map[string]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.Split(field.Tag.Get("url"), ",")[0] != "" {
+			continue
+		}
+		if field.Anonymous {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				r.fieldRenames(embedded, renames)
+				continue
+			}
+		}
+		if field.PkgPath == "" {
+			renames[field.Name] = r.fieldName(field.Name)
+		}
+	}
+}
+
+//SnakeCase converts a Go field name to snake case, keeping acronyms together: UserName becomes user_name
+//and HTTPServerID becomes http_server_id. It is meant for SetQueryFieldNameFunc
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, c := range runes {
+		if unicode.IsUpper(c) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+//boolQueryKeys returns the query keys of the bool fields of a query struct
+func boolQueryKeys(query interface{}) []string {
+	v, ok := queryStruct(query)
+	if !ok {
+		return nil
+	}
+
+	var keys []string
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.PkgPath != "" || fieldType.Kind() != reflect.Bool {
+			continue
+		}
+
+		if name := queryFieldName(field); name != "-" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+//applyTimeFormat rewrites the values of the time fields of the query struct in the configured format.
+//Fields with their own unix, unixmilli or layout tag options are left as encoded
+func (r *Request) applyTimeFormat(values url.Values) {
+	if r.timeFormat == "" {
+		return
+	}
+
+	v, ok := queryStruct(r.query)
+	if !ok {
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+		if field.PkgPath != "" || value.Type() != reflect.TypeOf(time.Time{}) || hasTimeFormatTag(field) {
+			continue
+		}
+
+		name := queryFieldName(field)
+		if _, ok := values[name]; !ok {
+			continue
+		}
+		values.Set(name, formatQueryTime(value.Interface().(time.Time), r.timeFormat))
+	}
+}
+
+//hasTimeFormatTag reports whether the time field sets its own format with go-querystring tag options
+func hasTimeFormatTag(field reflect.StructField) bool {
+	if field.Tag.Get("layout") != "" {
+		return true
+	}
+	for _, option := range strings.Split(field.Tag.Get("url"), ",")[1:] {
+		if option == "unix" || option == "unixmilli" {
+			return true
+		}
+	}
+	return false
+}
+
+func formatQueryTime(t time.Time, format string) string {
+	switch format {
+	case QueryTimeUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case QueryTimeUnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	return t.Format(format)
+}
+
+//queryStruct dereferences query, reporting false when it is not a struct
+func queryStruct(query interface{}) (reflect.Value, bool) {
+	v := reflect.ValueOf(query)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return v, false
+		}
+		v = v.Elem()
+	}
+	return v, v.Kind() == reflect.Struct
+}
+
+//queryFieldName returns the query key of a struct field as encoded by go-querystring, "-" when it is skipped
+func queryFieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("url"), ",")[0]
+	if name == "" {
+		name = field.Name
+	}
+	return name
+}
+
+//stripFlagValues turns "flag=" pairs of the given keys into bare "flag" entries
+func stripFlagValues(raw string, flags []string) string {
+	if len(flags) == 0 {
+		return raw
+	}
+
+	pairs := strings.Split(raw, "&")
+	for _, flag := range flags {
+		for i, pair := range pairs {
+			if pair == url.QueryEscape(flag)+"=" {
+				pairs[i] = url.QueryEscape(flag)
+			}
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+//orderedParams lists the params of rawQuery, values and added in insertion order
+func orderedParams(rawQuery string, values url.Values, added []queryParam) []queryParam {
+	var params []queryParam
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value := pair, ""
+		if i := strings.Index(pair, "="); i >= 0 {
+			key, value = pair[:i], pair[i+1:]
+		}
+		key, err := url.QueryUnescape(key)
+		if err != nil {
+			continue
+		}
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			continue
+		}
+		if _, ok := values[key]; ok {
+			continue
+		}
+		params = append(params, queryParam{key, value})
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range values[key] {
+			params = append(params, queryParam{key, value})
+		}
+	}
+
+	return append(params, added...)
+}
+
+func encodeOrdered(params []queryParam) string {
+	var b strings.Builder
+	for _, param := range params {
+		if b.Len() > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(url.QueryEscape(param.key))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(param.value))
+	}
+	return b.String()
+}
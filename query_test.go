@@ -0,0 +1,245 @@
+package request
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddQueryParam(t *testing.T) {
+	request, err := New().Get("http://example.com?b=1").AddQueryParam("a", "2").AddQueryParam("a", "3").Request()
+	assert.Nil(t, err)
+	assert.Equal(t, "http://example.com?a=2&a=3&b=1", request.URL.String())
+}
+
+func TestSetQueryOrdering(t *testing.T) {
+	build := func(ordering QueryOrdering) *Request {
+		return New().Get("http://example.com/sign?z=0&id=1").
+			SetQuery(&fakeQuery{ID: 20, Name: "John Doe"}).
+			AddQueryParam("timestamp", "100").
+			AddQueryParam("nonce", "abc").
+			AddQueryParam("api key", "k&v").
+			SetQueryOrdering(ordering)
+	}
+
+	t.Run("alphabetical by default", func(t *testing.T) {
+		request, err := build(QueryOrderAlphabetical).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "api+key=k%26v&id=20&name=John+Doe&nonce=abc&timestamp=100&z=0", request.URL.RawQuery)
+	})
+
+	t.Run("insertion", func(t *testing.T) {
+		request, err := build(QueryOrderInsertion).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "z=0&id=20&name=John+Doe&timestamp=100&nonce=abc&api+key=k%26v", request.URL.RawQuery)
+	})
+
+	t.Run("copied by New", func(t *testing.T) {
+		original := build(QueryOrderInsertion)
+		copied := original.New().AddQueryParam("extra", "1")
+		assert.Len(t, original.params, 3)
+		assert.Len(t, copied.params, 4)
+		assert.Equal(t, QueryOrderInsertion, copied.ordering)
+	})
+}
+
+type fakeBoolQuery struct {
+	ID       int   `url:"id"`
+	Active   bool  `url:"active"`
+	Archived bool  `url:"archived"`
+	Verified *bool `url:"verified,omitempty"`
+	Deleted  bool  `url:"-"`
+}
+
+func TestSetBoolQueryStyle(t *testing.T) {
+	verified := true
+	query := &fakeBoolQuery{ID: 1, Active: true, Verified: &verified, Deleted: true}
+
+	cases := []struct {
+		style    BoolQueryStyle
+		expected string
+	}{
+		{BoolTrueFalse, "active=true&archived=false&id=1&verified=true"},
+		{BoolOneZero, "active=1&archived=0&id=1&verified=1"},
+		{BoolPresence, "active&id=1&verified"},
+	}
+
+	for _, c := range cases {
+		request, err := New().Get("http://example.com").SetQuery(query).SetBoolQueryStyle(c.style).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, c.expected, request.URL.RawQuery)
+	}
+
+	t.Run("presence with insertion order", func(t *testing.T) {
+		request, err := New().Get("http://example.com").SetQuery(query).AddQueryParam("page", "2").
+			SetBoolQueryStyle(BoolPresence).SetQueryOrdering(QueryOrderInsertion).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "active&id=1&verified&page=2", request.URL.RawQuery)
+	})
+}
+
+type fakeTimeQuery struct {
+	Since   time.Time  `url:"since"`
+	Until   *time.Time `url:"until,omitempty"`
+	Created time.Time  `url:"created,unix"`
+	Updated time.Time  `url:"updated" layout:"2006-01-02"`
+	Page    int        `url:"page"`
+}
+
+func TestSetQueryTimeFormat(t *testing.T) {
+	since := time.Date(2021, time.June, 1, 12, 30, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+	query := &fakeTimeQuery{Since: since, Until: &until, Created: since, Updated: since, Page: 2}
+
+	cases := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{"rfc3339 default", "", "created=1622550600&page=2&since=2021-06-01T12%3A30%3A00Z&until=2021-06-02T12%3A30%3A00Z&updated=2021-06-01"},
+		{"custom layout", "2006-01-02 15:04", "created=1622550600&page=2&since=2021-06-01+12%3A30&until=2021-06-02+12%3A30&updated=2021-06-01"},
+		{"unix", QueryTimeUnix, "created=1622550600&page=2&since=1622550600&until=1622637000&updated=2021-06-01"},
+		{"unix milli", QueryTimeUnixMilli, "created=1622550600&page=2&since=1622550600000&until=1622637000000&updated=2021-06-01"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := New().Get("http://example.com").SetQuery(query).SetQueryTimeFormat(c.format).Request()
+			assert.Nil(t, err)
+			assert.Equal(t, c.expected, req.URL.RawQuery)
+		})
+	}
+
+	t.Run("omitted", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetQuery(&fakeTimeQuery{Since: since}).SetQueryTimeFormat(QueryTimeUnix).Request()
+		assert.Nil(t, err)
+		assert.NotContains(t, req.URL.RawQuery, "until")
+		assert.Contains(t, req.URL.RawQuery, "since=1622550600")
+	})
+}
+
+type fakeNilQuery struct {
+	Name   *string `url:"name,omitempty"`
+	Filter *string `url:"filter"`
+	Tag    *string `url:"tag,omitempty"`
+	Skip   *string `url:"-"`
+	Page   int     `url:"page"`
+}
+
+func TestSetQueryNilHandling(t *testing.T) {
+	tag := "go"
+	query := &fakeNilQuery{Tag: &tag, Page: 1}
+
+	cases := []struct {
+		name     string
+		handling QueryNilHandling
+		expected string
+	}{
+		{"by tag default", QueryNilByTag, "filter=&page=1&tag=go"},
+		{"omit", QueryNilOmit, "page=1&tag=go"},
+		{"empty", QueryNilEmpty, "filter=&name=&page=1&tag=go"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := New().Get("http://example.com").SetQuery(query).SetQueryNilHandling(c.handling).Request()
+			assert.Nil(t, err)
+			assert.Equal(t, c.expected, req.URL.RawQuery)
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetQuery(query).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "filter=&page=1&tag=go", req.URL.RawQuery)
+	})
+
+	t.Run("insertion order", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetQuery(&fakeNilQuery{}).
+			SetQueryNilHandling(QueryNilEmpty).SetQueryOrdering(QueryOrderInsertion).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "filter=&name=&page=0&tag=", req.URL.RawQuery)
+	})
+}
+
+func TestSetQueryPrefix(t *testing.T) {
+	t.Run("prefixed", func(t *testing.T) {
+		req, err := New().Get("http://example.com?sort=name").SetQuery(&fakeQuery{ID: 1, Name: "x"}).
+			SetQueryPrefix("filter").AddQueryParam("page", "2").Request()
+		assert.Nil(t, err)
+
+		params := req.URL.Query()
+		assert.Equal(t, "x", params.Get("filter[name]"))
+		assert.Equal(t, "1", params.Get("filter[id]"))
+		assert.Equal(t, "name", params.Get("sort"))
+		assert.Equal(t, "2", params.Get("page"))
+		assert.Equal(t, "filter%5Bid%5D=1&filter%5Bname%5D=x&page=2&sort=name", req.URL.RawQuery)
+	})
+
+	t.Run("none by default", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetQuery(&fakeQuery{ID: 1, Name: "x"}).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "id=1&name=x", req.URL.RawQuery)
+	})
+
+	t.Run("bool flags", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetQuery(&fakeBoolQuery{ID: 1, Active: true}).
+			SetBoolQueryStyle(BoolPresence).SetQueryPrefix("f").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "f%5Bactive%5D&f%5Bid%5D=1", req.URL.RawQuery)
+	})
+
+	t.Run("api key unprefixed", func(t *testing.T) {
+		req, err := New().Get("http://example.com").SetQuery(&fakeQuery{ID: 1}).
+			SetQueryPrefix("filter").SetAPIKey("key", KeyInQuery, "").Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "key", req.URL.Query().Get("api_key"))
+	})
+}
+
+func TestSetQueryFieldNameFunc(t *testing.T) {
+	type Paging struct {
+		PageSize int
+	}
+	type search struct {
+		Paging
+		UserName string
+		UserID   int    `url:",omitempty"`
+		Sort     string `url:"order_by"`
+		Active   bool
+	}
+	query := &search{Paging: Paging{PageSize: 10}, UserName: "John", UserID: 7, Sort: "name", Active: true}
+
+	t.Run("snake case", func(t *testing.T) {
+		request, err := New().Get("http://example.com").SetQuery(query).SetQueryFieldNameFunc(SnakeCase).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "active=true&order_by=name&page_size=10&user_id=7&user_name=John", request.URL.RawQuery)
+	})
+
+	t.Run("field names by default", func(t *testing.T) {
+		request, err := New().Get("http://example.com").SetQuery(query).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "Active=true&PageSize=10&UserID=7&UserName=John&order_by=name", request.URL.RawQuery)
+	})
+
+	t.Run("with bool style", func(t *testing.T) {
+		request, err := New().Get("http://example.com").SetQuery(query).SetQueryFieldNameFunc(SnakeCase).
+			SetBoolQueryStyle(BoolPresence).Request()
+		assert.Nil(t, err)
+		assert.Equal(t, "active&order_by=name&page_size=10&user_id=7&user_name=John", request.URL.RawQuery)
+	})
+}
+
+func TestSnakeCase(t *testing.T) {
+	for name, expected := range map[string]string{
+		"UserName":     "user_name",
+		"ID":           "id",
+		"UserID":       "user_id",
+		"HTTPServerID": "http_server_id",
+		"Page2Size":    "page2_size",
+		"name":         "name",
+	} {
+		assert.Equal(t, expected, SnakeCase(name), name)
+	}
+}
@@ -0,0 +1,165 @@
+package request
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+//Queue executes enqueued requests in the background with a fixed pool of workers
+type Queue struct {
+	client Doer
+	jobs   chan queueJob
+	reject bool
+
+	mu     sync.RWMutex
+	closed bool
+	//quit is closed by Shutdown to unblock Enqueue calls waiting for room in the queue
+	quit chan struct{}
+	//senders counts the Enqueue calls which may still send a job, jobs is only closed once they return
+	senders sync.WaitGroup
+	wg      sync.WaitGroup
+}
+
+type queueJob struct {
+	req  *Request
+	done func(*Response, error)
+}
+
+//QueueOption configures a Queue
+type QueueOption func(*Queue)
+
+//WithQueueSize sets how many jobs may wait for a worker. Defaults to the number of workers
+func WithQueueSize(n int) QueueOption {
+	return func(q *Queue) {
+		if n < 0 {
+			n = 0
+		}
+		q.jobs = make(chan queueJob, n)
+	}
+}
+
+//RejectWhenFull makes Enqueue return ErrQueueFull instead of blocking when the queue is full
+func RejectWhenFull() QueueOption {
+	return func(q *Queue) {
+		q.reject = true
+	}
+}
+
+//NewQueue starts a queue executing requests with the given number of workers.
+//When client is not nil it is used for every job instead of the client of the enqueued request, it may be an
+//*http.Client or any other Doer such as a mock from the requesttest package
+func NewQueue(client Doer, workers int, opts ...QueueOption) *Queue {
+	if workers < 1 {
+		workers = 1
+	}
+
+	q := &Queue{
+		client: client,
+		jobs:   make(chan queueJob, workers),
+		quit:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	q.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go q.work()
+	}
+
+	return q
+}
+
+//Enqueue adds a copy of req to the queue. done, if not nil, is called exactly once with the result of Execute,
+//so each job retries independently according to the retry options of req. A panic inside done is recovered
+//and logged with the logger of req, if any. Enqueue blocks while the queue is full unless RejectWhenFull is set,
+//in which case it returns ErrQueueFull. It returns ErrQueueClosed after Shutdown, including when Shutdown is
+//called while it is blocked, and ErrNilRequest for a nil req
+func (q *Queue) Enqueue(req *Request, done func(*Response, error)) error {
+	if req == nil {
+		return ErrNilRequest
+	}
+
+	q.mu.RLock()
+	if q.closed {
+		q.mu.RUnlock()
+		return ErrQueueClosed
+	}
+	q.senders.Add(1)
+	q.mu.RUnlock()
+	defer q.senders.Done()
+
+	job := queueJob{req: req.New(), done: done}
+	if q.client != nil {
+		job.req.client = q.client
+	}
+
+	if !q.reject {
+		select {
+		case q.jobs <- job:
+			return nil
+		case <-q.quit:
+			return ErrQueueClosed
+		}
+	}
+
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+//Shutdown stops accepting jobs and waits until every enqueued job is done or ctx is done,
+//in which case the remaining jobs keep running in the background and the context error is returned
+func (q *Queue) Shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.quit)
+		go func() {
+			q.senders.Wait()
+			close(q.jobs)
+		}()
+	}
+	q.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for job := range q.jobs {
+		resp, err := job.req.Execute()
+		job.callback(resp, err)
+	}
+}
+
+//callback calls done, recovering from a panic so it cannot kill the worker
+func (j queueJob) callback(resp *Response, err error) {
+	if j.done == nil {
+		return
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil && j.req.logger != nil {
+			j.req.logger.Error("queue callback panicked", slog.Any("panic", recovered))
+		}
+	}()
+
+	j.done(resp, err)
+}
@@ -0,0 +1,181 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	var handled int32
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&handled, 1)
+		w.Header().Set("X-Path", req.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	})
+	q := NewQueue(nil, 4)
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	for i := 0; i < 100; i++ {
+		err := q.Enqueue(r.Get(fmt.Sprintf("http://example.com/hooks/%d", i)), func(resp *Response, err error) {
+			assert.Nil(t, err)
+			mu.Lock()
+			calls[resp.Header.Get("X-Path")]++
+			mu.Unlock()
+		})
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, q.Shutdown(context.Background()))
+
+	assert.Equal(t, int32(100), handled)
+	assert.Len(t, calls, 100)
+	for path, n := range calls {
+		assert.Equal(t, 1, n, path)
+	}
+}
+
+func TestQueueShutdownDrains(t *testing.T) {
+	release := make(chan struct{})
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	q := NewQueue(nil, 1, WithQueueSize(5))
+
+	var done int32
+	for i := 0; i < 5; i++ {
+		assert.Nil(t, q.Enqueue(r.Get("http://example.com"), func(*Response, error) {
+			atomic.AddInt32(&done, 1)
+		}))
+	}
+
+	//jobs are still pending, so a short deadline expires
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Shutdown(ctx), context.DeadlineExceeded)
+	assert.ErrorIs(t, q.Enqueue(r.Get("http://example.com"), nil), ErrQueueClosed)
+
+	close(release)
+	assert.Nil(t, q.Shutdown(context.Background()))
+	assert.Equal(t, int32(5), done)
+}
+
+func TestQueueShutdownUnblocksEnqueue(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	q := NewQueue(nil, 1, WithQueueSize(0))
+
+	assert.Nil(t, q.Enqueue(r.Get("http://example.com"), nil))
+	blocked := make(chan error)
+	go func() {
+		blocked <- q.Enqueue(r.Get("http://example.com"), nil)
+	}()
+
+	//the worker and the queue are busy, so the second Enqueue blocks until Shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, q.Shutdown(ctx), context.DeadlineExceeded)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+	assert.ErrorIs(t, <-blocked, ErrQueueClosed)
+}
+
+func TestQueueClient(t *testing.T) {
+	client := &mockClient{mockHandler: fakeHandler(http.StatusAccepted, `{}`, nil)}
+	q := NewQueue(client, 1)
+
+	var status int
+	assert.Nil(t, q.Enqueue(New().Get("http://example.com"), func(resp *Response, err error) {
+		assert.Nil(t, err)
+		status = resp.StatusCode
+	}))
+	assert.Nil(t, q.Shutdown(context.Background()))
+	assert.Equal(t, http.StatusAccepted, status)
+}
+
+func TestQueueNilRequest(t *testing.T) {
+	q := NewQueue(nil, 1)
+	assert.ErrorIs(t, q.Enqueue(nil, func(*Response, error) {
+		t.Error("unexpected callback")
+	}), ErrNilRequest)
+	assert.Nil(t, q.Shutdown(context.Background()))
+}
+
+func TestQueueRejectWhenFull(t *testing.T) {
+	release := make(chan struct{})
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+	q := NewQueue(nil, 1, WithQueueSize(1), RejectWhenFull())
+
+	assert.Nil(t, q.Enqueue(r.Get("http://example.com"), nil))
+	//wait for the worker to pick up the first job so the next one fills the queue
+	for len(q.jobs) != 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.Nil(t, q.Enqueue(r.Get("http://example.com"), nil))
+	assert.ErrorIs(t, q.Enqueue(r.Get("http://example.com"), nil), ErrQueueFull)
+
+	close(release)
+	assert.Nil(t, q.Shutdown(context.Background()))
+}
+
+func TestQueueCallbackPanic(t *testing.T) {
+	r := newMockRequest(fakeHandler(200, `{}`, nil))
+	q := NewQueue(nil, 1)
+
+	var done int32
+	q.Enqueue(r.Get("http://example.com"), func(*Response, error) {
+		panic("boom")
+	})
+	q.Enqueue(r.Get("http://example.com"), func(*Response, error) {
+		atomic.AddInt32(&done, 1)
+	})
+
+	assert.Nil(t, q.Shutdown(context.Background()))
+	assert.Equal(t, int32(1), done)
+}
+
+func TestQueueRetries(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		calls[req.URL.Path]++
+		n := calls[req.URL.Path]
+		mu.Unlock()
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	q := NewQueue(nil, 2)
+
+	statuses := make([]int, 4)
+	for i := range statuses {
+		i := i
+		q.Enqueue(r.Get(fmt.Sprintf("http://example.com/%d", i)).SetRetry(2, 0), func(resp *Response, err error) {
+			statuses[i] = resp.StatusCode
+		})
+	}
+	assert.Nil(t, q.Shutdown(context.Background()))
+
+	assert.Equal(t, []int{200, 200, 200, 200}, statuses)
+	for path, n := range calls {
+		assert.Equal(t, 3, n, path)
+	}
+}
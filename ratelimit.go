@@ -0,0 +1,99 @@
+package request
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//Limiter paces outgoing requests. Wait blocks until a request may be sent or ctx is done.
+//A *rate.Limiter from golang.org/x/time/rate satisfies this interface
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+//SetRateLimit limits the request to perSecond requests per second with bursts of up to burst requests.
+//The limiter is shared with every request derived from this one via New
+func (r *Request) SetRateLimit(perSecond float64, burst int) *Request {
+	if r == nil {
+		return nil
+	}
+	r.limiter = newTokenBucket(perSecond, burst, r.getClock())
+	return r
+}
+
+//SetRateLimiter sets a custom limiter which every attempt waits on before being sent.
+//The limiter is shared with every request derived from this one via New
+func (r *Request) SetRateLimiter(limiter Limiter) *Request {
+	if r == nil {
+		return nil
+	}
+	r.limiter = limiter
+	return r
+}
+
+//tokenBucket is a simple token bucket limiter
+type tokenBucket struct {
+	mu     sync.Mutex
+	clock  Clock
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(perSecond float64, burst int, clock Clock) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &tokenBucket{
+		clock:  clock,
+		rate:   perSecond,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+//Wait takes a token, waiting for one to become available if needed.
+//The token is given back if ctx is done before it is available
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	delay := b.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	select {
+	case <-b.clock.After(delay):
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+//reserve takes a token and returns how long to wait until it is available
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate <= 0 {
+		return 0
+	}
+
+	now := b.clock.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,69 @@
+package request
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+//resetEpochThreshold separates reset values given as Unix timestamps from ones given as seconds to wait
+const resetEpochThreshold = 1000000000
+
+//RateLimitInfo holds the rate limit state reported by the server
+type RateLimitInfo struct {
+	//Limit is the number of requests allowed in the current window
+	Limit int
+	//Remaining is the number of requests left in the current window
+	Remaining int
+	//Reset is when the current window ends, zero if not reported
+	Reset time.Time
+}
+
+//RateLimit parses the X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset headers,
+//falling back to the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers of the IETF draft.
+//Reset may be either a Unix timestamp or a number of seconds from now.
+//It returns false when neither header family is present or its values are malformed
+func (r *Response) RateLimit() (*RateLimitInfo, bool) {
+	for _, prefix := range []string{"X-RateLimit-", "RateLimit-"} {
+		if info, ok := r.parseRateLimit(prefix, time.Now()); ok {
+			return info, true
+		}
+	}
+	return nil, false
+}
+
+func (r *Response) parseRateLimit(prefix string, now time.Time) (*RateLimitInfo, bool) {
+	limit := r.Header.Get(prefix + "Limit")
+	remaining := r.Header.Get(prefix + "Remaining")
+	reset := r.Header.Get(prefix + "Reset")
+	if limit == "" && remaining == "" && reset == "" {
+		return nil, false
+	}
+
+	info := &RateLimitInfo{}
+	var err error
+	if limit != "" {
+		//the draft allows a quota policy after the limit, e.g. "100, 100;w=60"
+		if info.Limit, err = strconv.Atoi(strings.TrimSpace(strings.SplitN(limit, ",", 2)[0])); err != nil {
+			return nil, false
+		}
+	}
+	if remaining != "" {
+		if info.Remaining, err = strconv.Atoi(strings.TrimSpace(remaining)); err != nil {
+			return nil, false
+		}
+	}
+	if reset != "" {
+		seconds, err := strconv.ParseInt(strings.TrimSpace(reset), 10, 64)
+		if err != nil || seconds < 0 {
+			return nil, false
+		}
+		if seconds >= resetEpochThreshold {
+			info.Reset = time.Unix(seconds, 0)
+		} else {
+			info.Reset = now.Add(time.Duration(seconds) * time.Second)
+		}
+	}
+
+	return info, true
+}
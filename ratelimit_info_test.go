@@ -0,0 +1,96 @@
+package request
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitInfo(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		headers  map[string]string
+		expected *RateLimitInfo
+	}{
+		{
+			name: "x-ratelimit epoch reset",
+			headers: map[string]string{
+				"X-RateLimit-Limit":     "5000",
+				"X-RateLimit-Remaining": "4987",
+				"X-RateLimit-Reset":     "1622552400",
+			},
+			expected: &RateLimitInfo{Limit: 5000, Remaining: 4987, Reset: time.Unix(1622552400, 0)},
+		},
+		{
+			name: "x-ratelimit delta reset",
+			headers: map[string]string{
+				"X-RateLimit-Limit":     "60",
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     "30",
+			},
+			expected: &RateLimitInfo{Limit: 60, Remaining: 0, Reset: now.Add(30 * time.Second)},
+		},
+		{
+			name: "draft",
+			headers: map[string]string{
+				"RateLimit-Limit":     "100, 100;w=60",
+				"RateLimit-Remaining": "50",
+				"RateLimit-Reset":     "50",
+			},
+			expected: &RateLimitInfo{Limit: 100, Remaining: 50, Reset: now.Add(50 * time.Second)},
+		},
+		{
+			name:     "partial",
+			headers:  map[string]string{"X-RateLimit-Remaining": "3"},
+			expected: &RateLimitInfo{Remaining: 3},
+		},
+		{
+			name:    "missing",
+			headers: map[string]string{"Content-Type": "application/json"},
+		},
+		{
+			name:    "malformed",
+			headers: map[string]string{"X-RateLimit-Remaining": "lots"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			resp := &Response{Header: make(http.Header)}
+			for key, value := range c.headers {
+				resp.Header.Set(key, value)
+			}
+
+			info, ok := resp.parseRateLimit("X-RateLimit-", now)
+			if !ok {
+				info, ok = resp.parseRateLimit("RateLimit-", now)
+			}
+			assert.Equal(t, c.expected != nil, ok)
+			assert.Equal(t, c.expected, info)
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	r := newMockRequest(fakeHandler(200, `{}`, map[string]string{
+		"RateLimit-Limit":     "10",
+		"RateLimit-Remaining": "9",
+		"RateLimit-Reset":     "60",
+	}))
+
+	result, err := r.Get("http://example.com").Execute()
+	assert.Nil(t, err)
+
+	info, ok := result.RateLimit()
+	assert.True(t, ok)
+	assert.Equal(t, 10, info.Limit)
+	assert.Equal(t, 9, info.Remaining)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), info.Reset, 5*time.Second)
+
+	_, ok = (&Response{Header: make(http.Header)}).RateLimit()
+	assert.False(t, ok)
+}
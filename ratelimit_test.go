@@ -0,0 +1,95 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitPacing(t *testing.T) {
+	calls := 0
+	clock := newFakeClock()
+	r := newMockRequest(countingHandler(&calls, []int{200}, `{}`))
+	r.Get("http://example.com").SetClock(clock).SetRateLimit(50, 1)
+
+	var times []time.Time
+	for i := 0; i < 5; i++ {
+		_, err := r.Execute()
+		assert.Nil(t, err)
+		times = append(times, clock.Now())
+	}
+
+	assert.Equal(t, 5, calls)
+	//the first request uses the burst, every following one waits for a token every 20ms
+	for i := 1; i < len(times); i++ {
+		assert.Equal(t, 20*time.Millisecond, times[i].Sub(times[i-1]))
+	}
+}
+
+func TestRateLimitBurst(t *testing.T) {
+	calls := 0
+	r := newMockRequest(countingHandler(&calls, []int{200}, `{}`))
+	r.Get("http://example.com").SetRateLimit(1, 3)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err := r.Execute()
+		assert.Nil(t, err)
+	}
+	assert.Less(t, int64(time.Since(start)), int64(100*time.Millisecond))
+}
+
+func TestRateLimitSharedAcrossNew(t *testing.T) {
+	calls := 0
+	clock := newFakeClock()
+	template := newMockRequest(countingHandler(&calls, []int{200}, `{}`))
+	template.Get("http://example.com").SetClock(clock).SetRateLimit(20, 1)
+
+	first := template.New()
+	second := template.New()
+	assert.Same(t, first.limiter, second.limiter)
+
+	start := clock.Now()
+	_, err := first.Execute()
+	assert.Nil(t, err)
+	_, err = second.Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 50*time.Millisecond, clock.Now().Sub(start))
+}
+
+func TestRateLimitDownloadAndStream(t *testing.T) {
+	clock := newFakeClock()
+	template := newMockRequest(fakeHandler(200, `[1,2]`, nil))
+	template.Get("http://example.com").SetClock(clock).SetRateLimit(10, 1)
+
+	start := clock.Now()
+	_, err := template.New().DownloadTo(&bytes.Buffer{}, nil)
+	assert.Nil(t, err)
+	_, err = template.New().DownloadTo(&bytes.Buffer{}, nil)
+	assert.Nil(t, err)
+	result, err := template.New().ExecuteStream()
+	assert.Nil(t, err)
+	assert.Nil(t, result.Close())
+	assert.Equal(t, 200*time.Millisecond, clock.Now().Sub(start))
+}
+
+func TestRateLimitContextCancel(t *testing.T) {
+	calls := 0
+	r := newMockRequest(countingHandler(&calls, []int{200}, `{}`))
+	r.Get("http://example.com").SetRateLimit(1, 1)
+
+	_, err := r.Execute()
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err = r.SetContext(ctx).Execute()
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, 1, calls)
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+}
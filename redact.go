@@ -0,0 +1,154 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+//Redacted replaces the value of redacted headers
+const Redacted = "[REDACTED]"
+
+//DefaultRedactedHeaders are redacted by every request in addition to those set with RedactHeaders
+//and headers carrying an API key set with SetAPIKey
+var DefaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+//RedactHeaders redacts the values of the named headers, in addition to DefaultRedactedHeaders,
+//wherever the request renders headers to text, such as debug logs, dumps, AsCurl and HAR entries
+func (r *Request) RedactHeaders(names ...string) *Request {
+	if r == nil {
+		return nil
+	}
+	for _, name := range names {
+		r.RedactHeaderFunc(name, nil)
+	}
+	return r
+}
+
+//RedactHeaderFunc redacts the named header by replacing each of its values with the result of redact,
+//e.g. KeepAuthScheme to keep the Bearer prefix. A nil redact replaces the whole value with Redacted
+func (r *Request) RedactHeaderFunc(name string, redact func(value string) string) *Request {
+	if r == nil {
+		return nil
+	}
+	if redact == nil {
+		redact = redactValue
+	}
+
+	redactors := make(map[string]func(string) string, len(r.redactors)+1)
+	for key, fn := range r.redactors {
+		redactors[key] = fn
+	}
+	redactors[http.CanonicalHeaderKey(name)] = redact
+	r.redactors = redactors
+	return r
+}
+
+//KeepAuthScheme redacts an Authorization value but keeps its scheme, e.g. "Bearer [REDACTED]".
+//Authorization and Proxy-Authorization headers are redacted with it by default
+func KeepAuthScheme(value string) string {
+	if scheme, _, ok := strings.Cut(value, " "); ok {
+		return scheme + " " + Redacted
+	}
+	return Redacted
+}
+
+//RedactHeader returns a copy of header with the values of redacted headers replaced,
+//for hooks which render headers to text
+func (r *Request) RedactHeader(header http.Header) http.Header {
+	redacted := make(http.Header, len(header))
+	for name, values := range header {
+		redact := r.redactor(name)
+		if redact == nil {
+			redacted[name] = append([]string(nil), values...)
+			continue
+		}
+
+		redacted[name] = make([]string, len(values))
+		for i, value := range values {
+			redacted[name][i] = redact(value)
+		}
+	}
+	return redacted
+}
+
+//redactor returns the function redacting the named header, nil when it is not redacted
+func (r *Request) redactor(name string) func(string) string {
+	name = http.CanonicalHeaderKey(name)
+	if redact, ok := r.redactors[name]; ok {
+		return redact
+	}
+
+	for _, k := range r.apiKeys {
+		if k.in == KeyInHeader && http.CanonicalHeaderKey(k.name) == name {
+			return redactValue
+		}
+	}
+
+	for _, header := range DefaultRedactedHeaders {
+		if http.CanonicalHeaderKey(header) == name {
+			if name == "Authorization" || name == "Proxy-Authorization" {
+				return KeepAuthScheme
+			}
+			return redactValue
+		}
+	}
+	return nil
+}
+
+func redactValue(string) string {
+	return Redacted
+}
+
+type redactionKey struct{}
+
+//withRedaction returns ctx carrying the redaction rules of the request, see RedactedHeader and RedactedURL
+func (r *Request) withRedaction(ctx context.Context) context.Context {
+	return context.WithValue(ctx, redactionKey{}, &Request{redactors: r.redactors, apiKeys: r.apiKeys})
+}
+
+//redactionOf returns the redaction rules of the request that built req, or the default rules
+func redactionOf(req *http.Request) *Request {
+	if rules, ok := req.Context().Value(redactionKey{}).(*Request); ok {
+		return rules
+	}
+	return &Request{}
+}
+
+//RedactedHeader returns a copy of header, sent with req or received in response to it, with redacted headers
+//replaced following the rules of the request that built req, for middlewares and transports which record or
+//render requests. Only DefaultRedactedHeaders are redacted for requests not built by this package
+func RedactedHeader(req *http.Request, header http.Header) http.Header {
+	return redactionOf(req).RedactHeader(header)
+}
+
+//RedactedURL returns the URL of req with its password and the values of API keys sent as query params with
+//SetAPIKey, along with those of the named query params, replaced by Redacted. The query is left as is when
+//nothing is redacted
+func RedactedURL(req *http.Request, params ...string) string {
+	u := *req.URL
+	if password, ok := u.User.Password(); ok && password != "" {
+		u.User = url.UserPassword(u.User.Username(), Redacted)
+	}
+
+	for _, k := range redactionOf(req).apiKeys {
+		if k.in == KeyInQuery {
+			params = append(params, k.name)
+		}
+	}
+	query := u.Query()
+	redacted := false
+	for _, name := range params {
+		if values, ok := query[name]; ok {
+			for i := range values {
+				values[i] = Redacted
+			}
+			redacted = true
+		}
+	}
+	if redacted {
+		u.RawQuery = query.Encode()
+	}
+	return u.String()
+}
@@ -0,0 +1,84 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+)
+
+const defaultMaxRedirects = 10
+
+//PreserveAuthOnRedirect keeps the Authorization header when following a redirect to a different host when preserve is true.
+//By default the header is stripped on such redirects so credentials are not leaked to another server.
+//Only enable it when every host the server may redirect to is trusted with the credentials, as anyone able to
+//control a redirect target receives them. The client's CheckRedirect still applies. The client is copied so
+//requests sharing it are not affected. It has no effect when the client is not an *http.Client
+func (r *Request) PreserveAuthOnRedirect(preserve bool) *Request {
+	if r == nil {
+		return nil
+	}
+	client, ok := r.client.(*http.Client)
+	if !ok || preserve == r.preserveAuth {
+		return r
+	}
+
+	copied := *client
+	if preserve {
+		r.redirectBase = client.CheckRedirect
+		copied.CheckRedirect = preserveAuthRedirect(client.CheckRedirect)
+	} else {
+		copied.CheckRedirect = r.redirectBase
+		r.redirectBase = nil
+	}
+	r.client = &copied
+	r.preserveAuth = preserve
+	return r
+}
+
+//preserveAuthRedirect returns a CheckRedirect function copying the Authorization header of the original request
+//onto redirects which had it stripped, before deferring to next or the default redirect limit
+func preserveAuthRedirect(next func(req *http.Request, via []*http.Request) error) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if auth := via[0].Header.Values("Authorization"); len(auth) > 0 && req.Header.Get("Authorization") == "" {
+			req.Header["Authorization"] = append([]string(nil), auth...)
+		}
+
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= defaultMaxRedirects {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+}
+
+//RedirectHop is a redirect followed while sending a request
+type RedirectHop struct {
+	//URL is the URL which answered with the redirect
+	URL string
+	//StatusCode is the redirect status, such as 301 or 302
+	StatusCode int
+	//Location is the Location header of the redirect
+	Location string
+}
+
+//setRedirects records the redirects followed to receive resp and the final URL on response
+func setRedirects(response *Response, resp *http.Response) {
+	if resp.Request == nil {
+		return
+	}
+	response.FinalURL = resp.Request.URL.String()
+
+	var hops []RedirectHop
+	for req := resp.Request; req.Response != nil && req.Response.Request != nil; req = req.Response.Request {
+		hops = append(hops, RedirectHop{
+			URL:        req.Response.Request.URL.String(),
+			StatusCode: req.Response.StatusCode,
+			Location:   req.Response.Header.Get("Location"),
+		})
+	}
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+	response.RedirectHistory = hops
+}
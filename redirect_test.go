@@ -0,0 +1,108 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreserveAuthOnRedirect(t *testing.T) {
+	var auth string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	//localhost is a different host than 127.0.0.1, so the Authorization header is stripped by default
+	origin := httptest.NewServer(http.RedirectHandler(strings.Replace(target.URL, "127.0.0.1", "localhost", 1), http.StatusFound))
+	defer origin.Close()
+
+	t.Run("stripped by default", func(t *testing.T) {
+		auth = ""
+		result, err := New().Get(origin.URL).SetHeader("Authorization", "Bearer secret").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, "", auth)
+	})
+
+	t.Run("preserved", func(t *testing.T) {
+		auth = ""
+		base := New().SetHeader("Authorization", "Bearer secret")
+		_, err := base.New().Get(origin.URL).PreserveAuthOnRedirect(true).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "Bearer secret", auth)
+
+		//the original client is left untouched
+		auth = ""
+		_, err = base.Get(origin.URL).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "", auth)
+	})
+
+	t.Run("disabled again", func(t *testing.T) {
+		auth = ""
+		_, err := New().Get(origin.URL).SetHeader("Authorization", "Bearer secret").
+			PreserveAuthOnRedirect(true).PreserveAuthOnRedirect(false).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "", auth)
+	})
+
+	t.Run("client check redirect still applies", func(t *testing.T) {
+		stop := errors.New("no redirects")
+		r := New()
+		r.client = &http.Client{CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return stop
+		}}
+
+		_, err := r.Get(origin.URL).PreserveAuthOnRedirect(true).Execute()
+		assert.ErrorIs(t, err, stop)
+	})
+
+	t.Run("redirect limit", func(t *testing.T) {
+		calls := 0
+		loop := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			http.Redirect(w, r, "/", http.StatusFound)
+		}))
+		defer loop.Close()
+
+		_, err := New().Get(loop.URL).PreserveAuthOnRedirect(true).Execute()
+		assert.NotNil(t, err)
+		assert.Equal(t, defaultMaxRedirects, calls)
+	})
+}
+
+func TestRedirectHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/start":
+			http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+		case "/moved":
+			http.Redirect(w, r, "/final?page=1", http.StatusFound)
+		default:
+			w.Write([]byte(`{"ID":1}`))
+		}
+	}))
+	defer server.Close()
+
+	result, err := New().Get(server.URL + "/start").Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, server.URL+"/final?page=1", result.FinalURL)
+	assert.Equal(t, []RedirectHop{
+		{URL: server.URL + "/start", StatusCode: http.StatusMovedPermanently, Location: "/moved"},
+		{URL: server.URL + "/moved", StatusCode: http.StatusFound, Location: "/final?page=1"},
+	}, result.RedirectHistory)
+
+	t.Run("without redirects", func(t *testing.T) {
+		result, err := New().Get(server.URL + "/final").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, server.URL+"/final", result.FinalURL)
+		assert.Empty(t, result.RedirectHistory)
+	})
+}
@@ -4,31 +4,115 @@ package request
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"log/slog"
+	"math/rand"
 	"net/http"
 	"net/url"
 
+	"sync"
 	"time"
 
 	"github.com/google/go-querystring/query"
 )
 
-type httpClient interface {
+//Doer sends HTTP requests, it is implemented by *http.Client
+type Doer interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
-//Request is a simple http request client
+//Request is a simple http request client.
+//The core builder methods are safe to call on a nil *Request and return nil, so a chain started from
+//a nil request fails with ErrNilRequest when it is executed instead of panicking
 type Request struct {
-	client  httpClient
-	method  string
-	url     string
-	header  http.Header
-	query   interface{}
-	body    interface{}
-	Success interface{}
-	Failure interface{}
+	client        Doer
+	ctx           context.Context
+	method        string
+	url           string
+	urlErr        error
+	queryErr      error
+	header        http.Header
+	query         interface{}
+	params        []queryParam
+	ordering      QueryOrdering
+	boolStyle     BoolQueryStyle
+	timeFormat    string
+	nilHandling   QueryNilHandling
+	queryPrefix   string
+	fieldName     func(field string) string
+	body          interface{}
+	length        int64
+	retries       int
+	wait          time.Duration
+	maxWait       time.Duration
+	budget        time.Duration
+	retryTokens   *RetryTokens
+	clock         Clock
+	backoff       Backoff
+	maxBackoff    time.Duration
+	jitter        Jitter
+	jitterRand    *rand.Rand
+	anyMethod     bool
+	policy        RetryPolicy
+	onAttempt     func(AttemptInfo)
+	logger        *slog.Logger
+	logQuery      bool
+	logRedact     []string
+	truncate      int64
+	maxBody       int64
+	compress      bool
+	compressAbove int64
+	strict        bool
+	idemKey       string
+	autoIdem      bool
+	limiter       Limiter
+	sem           *semaphore
+	hedgeDelay    time.Duration
+	hedges        int
+	flight        *FlightGroup
+	cache         *ResponseCache
+	noCache       bool
+	endpoints     *endpointPool
+	failPolicy    FailoverPolicy
+	probeInterval time.Duration
+	selector      HostSelector
+	hmac          *HMACConfig
+	auth          *tokenRefresher
+	insecure      bool
+	insecureWarn  *sync.Once
+	preserveAuth  bool
+	redirectBase  func(req *http.Request, via []*http.Request) error
+	awsSigner     *awsV4Signer
+	jwt           *jwtCache
+	apiKeys       []apiKey
+	statusRetries map[int]int
+	redactors     map[string]func(value string) string
+	tags          map[string]string
+	csrf          *csrfConfig
+	proxyAuth     string
+	transport     *ownedTransport
+	dialTimeout   time.Duration
+	unixSocket    string
+	beforeSend    []func(req *http.Request, body []byte) error
+	onRequest     []func(r *Request, req *http.Request) error
+	onResponse    []func(r *Request, resp *Response) error
+	onError       []func(r *Request, err error)
+	interceptors  []Interceptor
+	dump          *dumper
+	debug         *debugger
+	har           *HARRecorder
+	metrics       func(m RequestMetrics)
+	reqID         *requestIDConfig
+	headerOrder   []string
+	normalizePath func(path string) string
+	verify        func(statusCode int, header http.Header, body []byte) error
+	Success       interface{}
+	Failure       interface{}
 }
 
 //New creates a new Request
@@ -42,26 +126,109 @@ func New() *Request {
 
 //New creates a new request from existing request
 func (r *Request) New() *Request {
+	if r == nil {
+		return nil
+	}
+	//the new request shares the client, so neither may configure the transport in place anymore
+	r.transport.share()
+
 	headers := make(http.Header)
 	for key, value := range r.header {
 		headers[key] = value
 	}
 
 	return &Request{
-		client:  r.client,
-		method:  r.method,
-		url:     r.url,
-		header:  headers,
-		query:   r.query,
-		body:    r.body,
-		Success: r.Success,
-		Failure: r.Failure,
+		client:        r.client,
+		ctx:           r.ctx,
+		method:        r.method,
+		url:           r.url,
+		urlErr:        r.urlErr,
+		queryErr:      r.queryErr,
+		header:        headers,
+		query:         r.query,
+		params:        append([]queryParam(nil), r.params...),
+		ordering:      r.ordering,
+		boolStyle:     r.boolStyle,
+		timeFormat:    r.timeFormat,
+		nilHandling:   r.nilHandling,
+		queryPrefix:   r.queryPrefix,
+		fieldName:     r.fieldName,
+		body:          r.body,
+		length:        r.length,
+		retries:       r.retries,
+		wait:          r.wait,
+		maxWait:       r.maxWait,
+		budget:        r.budget,
+		retryTokens:   r.retryTokens,
+		clock:         r.clock,
+		backoff:       r.backoff,
+		maxBackoff:    r.maxBackoff,
+		jitter:        r.jitter,
+		jitterRand:    r.jitterRand,
+		anyMethod:     r.anyMethod,
+		policy:        r.policy,
+		onAttempt:     r.onAttempt,
+		logger:        r.logger,
+		logQuery:      r.logQuery,
+		logRedact:     r.logRedact,
+		truncate:      r.truncate,
+		maxBody:       r.maxBody,
+		compress:      r.compress,
+		compressAbove: r.compressAbove,
+		strict:        r.strict,
+		idemKey:       r.idemKey,
+		autoIdem:      r.autoIdem,
+		limiter:       r.limiter,
+		sem:           r.sem,
+		hedgeDelay:    r.hedgeDelay,
+		hedges:        r.hedges,
+		flight:        r.flight,
+		cache:         r.cache,
+		noCache:       r.noCache,
+		endpoints:     r.endpoints,
+		failPolicy:    r.failPolicy,
+		probeInterval: r.probeInterval,
+		selector:      r.selector,
+		hmac:          r.hmac,
+		auth:          r.auth,
+		insecure:      r.insecure,
+		insecureWarn:  r.insecureWarn,
+		preserveAuth:  r.preserveAuth,
+		redirectBase:  r.redirectBase,
+		awsSigner:     r.awsSigner,
+		jwt:           r.jwt,
+		apiKeys:       append([]apiKey(nil), r.apiKeys...),
+		statusRetries: r.statusRetries,
+		redactors:     r.redactors,
+		tags:          r.tags,
+		csrf:          r.csrf,
+		proxyAuth:     r.proxyAuth,
+		dialTimeout:   r.dialTimeout,
+		unixSocket:    r.unixSocket,
+		beforeSend:    append([]func(req *http.Request, body []byte) error(nil), r.beforeSend...),
+		onRequest:     append([]func(r *Request, req *http.Request) error(nil), r.onRequest...),
+		onResponse:    append([]func(r *Request, resp *Response) error(nil), r.onResponse...),
+		onError:       append(([]func(r *Request, err error))(nil), r.onError...),
+		interceptors:  append([]Interceptor(nil), r.interceptors...),
+		dump:          r.dump,
+		debug:         r.debug,
+		har:           r.har,
+		metrics:       r.metrics,
+		reqID:         r.reqID,
+		headerOrder:   r.headerOrder,
+		normalizePath: r.normalizePath,
+		verify:        r.verify,
+		Success:       r.Success,
+		Failure:       r.Failure,
 	}
 }
 
 //SetSuccess is used to set a custom struct for response body unmarshalling after a successful request
 //Must be passed as a reference
 func (r *Request) SetSuccess(success interface{}) *Request {
+	if r == nil {
+		return nil
+	}
 	r.Success = success
 	return r
 }
@@ -69,108 +236,316 @@ func (r *Request) SetSuccess(success interface{}) *Request {
 //SetFailure is used to set a custom struct for response body unmarshalling after a failed request
 //Must be passed as a reference
 func (r *Request) SetFailure(failure interface{}) *Request {
+	if r == nil {
+		return nil
+	}
 	r.Failure = failure
 	return r
 }
 
 //SetHeader can be used to set a header for the request
 func (r *Request) SetHeader(key, value string) *Request {
+	if r == nil {
+		return nil
+	}
 	r.header.Set(key, value)
 	return r
 }
 
 //AddHeader can be used to add a header for the request
 func (r *Request) AddHeader(key, value string) *Request {
+	if r == nil {
+		return nil
+	}
 	r.header.Add(key, value)
 	return r
 }
 
-//SetQuery is used to set query params for request
+//SetQuery is used to set query params for request. The encoded params are merged with the params already in the
+//URL: a param set by query replaces every value the URL has for it and the others are kept
 func (r *Request) SetQuery(query interface{}) *Request {
+	if r == nil {
+		return nil
+	}
 	r.query = query
+	r.queryErr = nil
+	return r
+}
+
+//SetQueryFromURL is used to set query params for request from the query portion of a full URL.
+//When the URL or its query cannot be parsed, Request and Execute return an error wrapping ErrInvalidQuery
+func (r *Request) SetQueryFromURL(rawurl string) *Request {
+	if r == nil {
+		return nil
+	}
+	path, err := url.Parse(rawurl)
+	if err != nil {
+		r.queryErr = err
+		return r
+	}
+	r.query, r.queryErr = url.ParseQuery(path.RawQuery)
+
 	return r
 }
 
 //SetBody is used to set request body. Must be passed as a pointer to a struct
+//or an io.Reader which is streamed as is. A reader can only be read once so it should not be combined with retries
 func (r *Request) SetBody(body interface{}) *Request {
+	if r == nil {
+		return nil
+	}
 	r.body = body
 	return r
 }
 
+//SetStrictDecode makes decoding fail when the response body contains fields not present in the Success or Failure struct
+func (r *Request) SetStrictDecode(strict bool) *Request {
+	if r == nil {
+		return nil
+	}
+	r.strict = strict
+	return r
+}
+
+//SetMaxBodySize limits the size of the response body. Execute fails with ErrResponseTooLarge when it is exceeded
+func (r *Request) SetMaxBodySize(n int64) *Request {
+	if r == nil {
+		return nil
+	}
+	r.maxBody = n
+	return r
+}
+
+//SetContentLength sets the length of a streamed io.Reader body so it is not sent with chunked encoding
+func (r *Request) SetContentLength(n int64) *Request {
+	if r == nil {
+		return nil
+	}
+	r.length = n
+	return r
+}
+
 //Get request
 func (r *Request) Get(url string) *Request {
-	r.method = "GET"
-	return r.setURL(url)
-
+	return r.setRequest("GET", url)
 }
 
 //Post request
 func (r *Request) Post(url string) *Request {
-	r.method = "POST"
-	return r.setURL(url)
-
+	return r.setRequest("POST", url)
 }
 
 //Put request
 func (r *Request) Put(url string) *Request {
-	r.method = "PUT"
-	return r.setURL(url)
-
+	return r.setRequest("PUT", url)
 }
 
 //Head request
 func (r *Request) Head(url string) *Request {
-	r.method = "HEAD"
-	return r.setURL(url)
-
+	return r.setRequest("HEAD", url)
 }
 
 //Delete request
 func (r *Request) Delete(url string) *Request {
-	r.method = "DELETE"
-	return r.setURL(url)
-
+	return r.setRequest("DELETE", url)
 }
 
 //Patch request
 func (r *Request) Patch(url string) *Request {
-	r.method = "PATCH"
-	return r.setURL(url)
+	return r.setRequest("PATCH", url)
+}
 
+//SetBodyTruncate limits how much of the response body is read. Anything past n bytes is discarded
+//and the response is marked as truncated instead of failing. Truncated bodies are not decoded
+func (r *Request) SetBodyTruncate(n int64) *Request {
+	if r == nil {
+		return nil
+	}
+	r.truncate = n
+	return r
 }
 
-//Request creates and returns and http request
+//SetClient sets the client used to send requests, such as an *http.Client or a mock from the requesttest package.
+//Options configuring the client or its transport, such as SetTimeout, only apply to an *http.Client
+func (r *Request) SetClient(client Doer) *Request {
+	if r == nil {
+		return nil
+	}
+	r.client = client
+	return r
+}
+
+//SetTimeout sets the total time limit of each attempt, including reading the response body.
+//A timeout of zero means no timeout, the context can still cancel the request
+func (r *Request) SetTimeout(timeout time.Duration) *Request {
+	if r == nil {
+		return nil
+	}
+	client, ok := r.client.(*http.Client)
+	if !ok {
+		return r
+	}
+
+	//copy the client so requests created from this one keep their own timeout
+	copied := *client
+	copied.Timeout = timeout
+	r.client = &copied
+	return r
+}
+
+//SetNoTimeout removes the default timeout so long running requests are only bounded by the context
+func (r *Request) SetNoTimeout() *Request {
+	return r.SetTimeout(0)
+}
+
+//SetContext sets the context used for the request and any retries
+func (r *Request) SetContext(ctx context.Context) *Request {
+	if r == nil {
+		return nil
+	}
+	r.ctx = ctx
+	return r
+}
+
+//Request creates and returns and http request.
+//It returns ErrNilRequest when called on a nil request and an error wrapping ErrInvalidQuery when the query cannot be encoded
 func (r *Request) Request() (*http.Request, error) {
-	var req *http.Request
-	var err error
+	if r == nil {
+		return nil, ErrNilRequest
+	}
+
+	body, err := r.bodyReader()
+	if err != nil {
+		return nil, err
+	}
 
-	if r.body != nil {
-		body, err := json.Marshal(r.body)
+	address := r.url
+	if r.usesEndpoints() {
+		//the base URL is picked again for every attempt, see failover
+		address = joinURL(r.endpoints.urls[0], r.url)
+	}
+
+	req, err := http.NewRequestWithContext(r.withClock(r.withRedaction(r.withHeaderOrder(r.context()))), r.method, address, body)
+	if err != nil {
+		return nil, err
+	}
+	if file, ok := r.body.(fileBody); ok {
+		if err := file.setBody(req); err != nil {
+			return nil, err
+		}
+	}
+	if r.length > 0 {
+		req.ContentLength = r.length
+	}
+	req.Header = r.header.Clone()
+	if err := r.compressBody(req); err != nil {
+		return nil, err
+	}
+	r.setAPIKeyHeaders(req.Header)
+	if r.auth != nil {
+		if token := r.auth.current(); token != "" {
+			req.Header.Set("Authorization", bearer(token))
+		}
+	}
+	if r.jwt != nil {
+		token, err := r.jwt.get(req.Context(), r.now())
 		if err != nil {
 			return nil, err
 		}
-		buff := bytes.NewBuffer(body)
+		req.Header.Set("Authorization", bearer(token))
+	}
 
-		req, err = http.NewRequest(r.method, r.url, buff)
-	} else {
-		req, err = http.NewRequest(r.method, r.url, nil)
+	if err := r.encodeQuery(req.URL); err != nil {
+		return nil, err
+	}
+	if err := r.setCSRFHeader(req); err != nil {
+		return nil, err
 	}
+	r.setProxyAuthHeader(req)
 
+	return req, nil
+}
+
+//Execute runs the request and returns a response.
+//It returns ErrNilRequest when called on a nil request, ErrNoURL when no URL is set, an error wrapping ErrInvalidQuery when the query cannot be encoded,
+//an error wrapping ErrResponseTooLarge along with the response when the body exceeds SetMaxBodySize,
+//a *DecodeError along with the response when the body cannot be decoded, and transport errors as returned by the client
+func (r *Request) Execute() (*Response, error) {
+	if r == nil {
+		return nil, ErrNilRequest
+	}
+	if r.url == "" {
+		return nil, ErrNoURL
+	}
+
+	return r.execute(r.sendRequest)
+}
+
+//execute runs a single Execute, DownloadTo or ExecuteStream with send, which sends its attempts, and reports
+//its metrics once the lifecycle hooks ran
+func (r *Request) execute(send func(exec *execution) (*Response, error)) (*Response, error) {
+	start := r.now()
+	exec, err := r.newExecution()
+	var resp *Response
+	if err == nil {
+		resp, err = send(exec)
+	}
+	resp, err = r.finish(resp, err)
+	return resp, joinHookErr(err, r.runMetricsHook(exec, resp, err, r.now().Sub(start)))
+}
+
+func (r *Request) bodyReader() (io.Reader, error) {
+	if r.body == nil {
+		return nil, nil
+	}
+	if reader, ok := r.body.(io.Reader); ok {
+		return reader, nil
+	}
+	if _, ok := r.body.(fileBody); ok {
+		//opened once the request is created, see fileBody.setBody
+		return nil, nil
+	}
+	if body, ok := r.body.(ndjsonBody); ok {
+		return body.reader(r.context()), nil
+	}
+
+	body, err := json.Marshal(r.body)
 	if err != nil {
 		return nil, err
 	}
+	return bytes.NewBuffer(body), nil
+}
 
-	v, err := query.Values(r.query)
-	if err == nil {
-		req.URL.RawQuery = v.Encode()
+func (r *Request) queryValues() (url.Values, error) {
+	if v, ok := r.query.(url.Values); ok {
+		return v, nil
 	}
+	return query.Values(r.query)
+}
 
-	return req, nil
+func (r *Request) context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
 }
 
-//Execute runs the request and returns a response
-func (r *Request) Execute() (*Response, error) {
-	return r.sendRequest()
+//Path sets the URL without changing the method, usually a path relative to the base URL set with SetBaseURL.
+//Leading and trailing slashes are joined with the base URL so exactly one separates them
+func (r *Request) Path(path string) *Request {
+	if r == nil {
+		return nil
+	}
+	return r.setURL(path)
+}
+
+//setRequest sets the method and URL, it is a no-op on a nil request
+func (r *Request) setRequest(method, address string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.method = method
+	return r.setURL(address)
 }
 
 func (r *Request) setURL(address string) *Request {
@@ -178,17 +553,206 @@ func (r *Request) setURL(address string) *Request {
 	if err == nil {
 		r.url = path.String()
 	}
+	//kept for Validate, the previous URL is left as is
+	r.urlErr = err
 
 	return r
 }
 
-func (r *Request) sendRequest() (*Response, error) {
+//newExecution generates the idempotency key and request ID sent with every attempt of an execution
+func (r *Request) newExecution() (*execution, error) {
+	exec := &execution{}
+	var err error
+	if exec.idempotencyKey, err = r.idempotencyKey(); err != nil {
+		return exec, err
+	}
+	if exec.requestID, err = r.requestID(); err != nil {
+		return exec, fmt.Errorf("failed to generate request ID: %w", err)
+	}
+	return exec, nil
+}
 
+//preSend builds attempt of exec and runs the steps preceding every attempt, whether sent by Execute, DownloadTo
+//or ExecuteStream: setting the idempotency key and request ID, the BeforeSend and OnRequest hooks, then waiting
+//on the rate limiter
+func (r *Request) preSend(exec *execution, attempt int) (*http.Request, error) {
 	req, err := r.Request()
 	if err != nil {
 		return nil, err
 	}
-	resp, err := r.do(req)
+	if exec.idempotencyKey != "" {
+		req.Header.Set(idempotencyKeyHeader, exec.idempotencyKey)
+	}
+	r.setRequestID(req, exec.requestID)
+	if err := r.runBeforeSend(req); err != nil {
+		return nil, err
+	}
+	if err := r.runRequestHooks(req); err != nil {
+		return nil, err
+	}
+	if r.limiter != nil {
+		if err := r.limiter.Wait(r.context()); err != nil {
+			return nil, err
+		}
+	}
+	exec.attempts, exec.req = attempt, req
+	return req, nil
+}
+
+//sendOnce sends the single attempt of a DownloadTo or ExecuteStream with do, which are never retried
+func (r *Request) sendOnce(exec *execution, do func(req *http.Request) (*Response, error)) (*Response, error) {
+	req, err := r.preSend(exec, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	start := r.now()
+	resp, err := r.sendWith(req, 1, do)
+	exec.annotate(resp)
+	return resp, joinHookErr(err, r.runAttemptHook(newAttemptInfo(1, exec.requestID, resp, err, 0, r.now().Sub(start))))
+}
+
+func (r *Request) sendRequest(exec *execution) (*Response, error) {
+	policy := r.policy
+	if policy == nil {
+		policy = r.defaultRetryPolicy
+	}
+
+	start := r.now()
+	var hookErr error
+	statusRetries := make(map[int]int)
+	for attempt := 1; ; attempt++ {
+		req, err := r.preSend(exec, attempt)
+		if err != nil {
+			return nil, err
+		}
+		attemptStart := r.now()
+		resp, err := r.attempt(req, attempt)
+		duration := r.now().Sub(attemptStart)
+		exec.annotate(resp)
+
+		var delay time.Duration
+		finalErr := err
+		done := !r.replayable() || !r.retriesLeft(resp, attempt, statusRetries) || !policy(resp, err, attempt)
+		if !done {
+			if resp != nil {
+				statusRetries[resp.StatusCode]++
+			}
+			delay = r.retryDelay(resp, attempt)
+			if budgetErr := r.checkBudget(start, attempt, delay, err); budgetErr != nil {
+				done, delay, finalErr = true, 0, budgetErr
+			} else if r.retryTokens != nil && !r.retryTokens.take(r.now()) {
+				done, delay = true, 0
+			}
+		}
+
+		if panicErr := r.runAttemptHook(newAttemptInfo(attempt, exec.requestID, resp, err, delay, duration)); panicErr != nil {
+			hookErr = errors.Join(hookErr, panicErr)
+		}
+
+		if done {
+			return resp, joinHookErr(finalErr, hookErr)
+		}
+		r.logRetry(req, attempt, delay)
+		if err := r.sleep(delay); err != nil {
+			return resp, joinHookErr(err, hookErr)
+		}
+	}
+}
+
+//attempt sends a single attempt, failing over between base URLs when configured
+//and refreshing the token once on 401 Unauthorized when OnUnauthorized is set
+func (r *Request) attempt(req *http.Request, attempt int) (*Response, error) {
+	var resp *Response
+	var err error
+	if r.usesEndpoints() {
+		resp, err = r.failover(req, attempt)
+	} else {
+		resp, err = r.exchange(req, attempt)
+	}
+
+	if r.auth != nil && resp != nil && resp.StatusCode == http.StatusUnauthorized && r.replayable() {
+		return r.reauthorize(req, resp, attempt)
+	}
+	return resp, err
+}
+
+//exchange sends the request, serving it from the cache, hedging or deduplicating it when enabled, and decodes the response
+func (r *Request) exchange(req *http.Request, attempt int) (*Response, error) {
+	var resp *Response
+	var err error
+
+	if r.canCache(req) {
+		resp, err = r.cached(req, func() (*Response, error) {
+			return r.share(req, attempt)
+		})
+	} else {
+		resp, err = r.share(req, attempt)
+	}
+	if err != nil {
+		return resp, err
+	}
+
+	return resp, r.decode(resp)
+}
+
+//share sends the request, sharing the network call with identical concurrent requests when deduplicating
+func (r *Request) share(req *http.Request, attempt int) (*Response, error) {
+	var resp *Response
+	var err error
+
+	if r.canDeduplicate(req) {
+		resp, err = r.flight.do(flightKey(req, r.perExecutionHeaders()...), func() (*Response, error) {
+			return r.sendOrHedge(req, attempt)
+		})
+		if err == nil {
+			resp = copyResponse(resp)
+		}
+	} else {
+		resp, err = r.sendOrHedge(req, attempt)
+	}
+	return resp, err
+}
+
+func (r *Request) sendOrHedge(req *http.Request, attempt int) (*Response, error) {
+	if r.canHedge(req) {
+		return r.hedge(req, attempt)
+	}
+	return r.send(req, attempt)
+}
+
+//send sends the request, holding a concurrency slot until the body is fully read
+func (r *Request) send(req *http.Request, attempt int) (*Response, error) {
+	return r.sendWith(req, attempt, r.do)
+}
+
+//sendWith signs, logs and sends the request with do, holding a concurrency slot until it returns
+func (r *Request) sendWith(req *http.Request, attempt int, do func(*http.Request) (*Response, error)) (resp *Response, err error) {
+	if r.sem != nil {
+		if err := r.sem.acquire(req.Context()); err != nil {
+			return nil, err
+		}
+		defer func() {
+			//a streamed body holds the slot until it is consumed, see Response.Close
+			if resp != nil && resp.stream != nil {
+				resp.stream.release = r.sem.release
+				return
+			}
+			r.sem.release()
+		}()
+	}
+
+	if err := r.sign(req); err != nil {
+		return nil, err
+	}
+	if err := r.signAWSV4(req); err != nil {
+		return nil, err
+	}
+
+	start := r.now()
+	r.logStart(req, attempt)
+	resp, err = do(req)
+	r.logDone(req, attempt, resp, err, r.now().Sub(start))
 
 	return resp, err
 }
@@ -196,7 +760,7 @@ func (r *Request) sendRequest() (*Response, error) {
 func (r *Request) do(req *http.Request) (*Response, error) {
 
 	response := &Response{}
-	resp, err := r.client.Do(req)
+	resp, err := r.roundTrip(req)
 
 	if err != nil {
 		return nil, err
@@ -209,34 +773,82 @@ func (r *Request) do(req *http.Request) (*Response, error) {
 
 	response.StatusCode = resp.StatusCode
 	response.Header = resp.Header
+	setRedirects(response, resp)
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	bodyBytes, err := r.readBody(resp.Body)
+	if errors.Is(err, ErrResponseTooLarge) {
+		return response, err
+	}
 	if err != nil {
 		return nil, err
 	}
-	err = r.decodeResp(response, bodyBytes)
+	response.Body = bodyBytes
+	if r.truncate > 0 && int64(len(bodyBytes)) > r.truncate {
+		response.Body = bodyBytes[:r.truncate]
+		response.Truncated = true
+	}
 
-	if err != nil {
-		err = fmt.Errorf("failed to decode API response: %s", err.Error())
+	return response, r.verifyResponse(response)
+}
+
+//decode unmarshals the response body into Success or Failure. Truncated bodies are left as is
+func (r *Request) decode(resp *Response) error {
+	if resp.Truncated {
+		return nil
+	}
+
+	if err := r.decodeResp(resp, resp.Body); err != nil {
+		return &DecodeError{Err: err}
+	}
+
+	return nil
+}
+
+//readBody reads the response body, stopping one byte past the truncation or size limit so exceeding it can be detected
+func (r *Request) readBody(body io.Reader) ([]byte, error) {
+	if r.truncate > 0 {
+		return ioutil.ReadAll(io.LimitReader(body, r.truncate+1))
+	}
+	if r.maxBody <= 0 {
+		return ioutil.ReadAll(body)
 	}
 
-	return response, err
+	b, err := ioutil.ReadAll(io.LimitReader(body, r.maxBody+1))
+	if err == nil && int64(len(b)) > r.maxBody {
+		return nil, fmt.Errorf("%w: limit is %d bytes", ErrResponseTooLarge, r.maxBody)
+	}
+	return b, err
 }
 
+//decodeResp unmarshals body into Success or Failure depending on the status. Empty bodies are not decoded
 func (r *Request) decodeResp(resp *Response, body []byte) error {
+	if len(body) == 0 {
+		return nil
+	}
+
 	if status := resp.StatusCode; 200 <= status && status <= 299 {
 		if r.Success != nil {
 			resp.Success = r.Success
 
-			return json.Unmarshal(body, &resp.Success)
+			return r.unmarshal(body, &resp.Success)
 		}
 
 	} else {
 		if r.Failure != nil {
 			resp.Failure = r.Failure
-			return json.Unmarshal(body, &resp.Failure)
+			return r.unmarshal(body, &resp.Failure)
 		}
 	}
 	return nil
 
 }
+
+func (r *Request) unmarshal(body []byte, v interface{}) error {
+	if !r.strict {
+		return json.Unmarshal(body, v)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
@@ -2,12 +2,18 @@ package request
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -50,6 +56,7 @@ func fakeHandler(statusCode int, json string, headers map[string]string) http.Ha
 func newMockRequest(fakeHandler http.HandlerFunc) *Request {
 	return &Request{
 		client: &mockClient{fakeHandler},
+		header: make(http.Header),
 	}
 }
 
@@ -214,3 +221,263 @@ func TestSetBody(t *testing.T) {
 
 	assert.Equal(t, buff.Bytes(), bodyBytes)
 }
+
+func TestBodyWithQuery(t *testing.T) {
+	var body []byte
+	var rawQuery string
+	r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		body, _ = ioutil.ReadAll(req.Body)
+		rawQuery = req.URL.RawQuery
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	result, err := r.Post("http://example.com/users?page=2").
+		SetBody(&fakeSuccess{ID: 10, Name: "Bob"}).
+		SetQuery(&fakeQuery{ID: 20, Name: "John"}).
+		Execute()
+
+	assert.Nil(t, err)
+	assert.Equal(t, 201, result.StatusCode)
+	assert.JSONEq(t, `{"ID":10,"Name":"Bob"}`, string(body))
+	assert.Equal(t, "id=20&name=John&page=2", rawQuery)
+
+	t.Run("invalid body", func(t *testing.T) {
+		req, err := New().Post("http://example.com").SetBody(make(chan int)).SetQuery(&fakeQuery{ID: 20}).Request()
+		assert.Nil(t, req)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestRequestHeader(t *testing.T) {
+	req, err := New().SetHeader("Idempotency-Key", "abc").Post("http://example.com").Request()
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", req.Header.Get("Idempotency-Key"))
+}
+
+func TestSetBodyTruncate(t *testing.T) {
+	t.Run("truncated", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(500, `<html>huge error page</html>`, nil))
+
+		result, err := r.Get("http://example.com").SetFailure(&fakeSuccess{}).SetBodyTruncate(6).Execute()
+		assert.Nil(t, err)
+		assert.True(t, result.Truncated)
+		assert.Equal(t, "<html>", string(result.Body))
+		assert.Nil(t, result.Failure)
+	})
+
+	t.Run("not truncated", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(200, `{"id":200, "name":"John"}`, nil))
+
+		result, err := r.Get("http://example.com").SetSuccess(&fakeSuccess{}).SetBodyTruncate(25).Execute()
+		assert.Nil(t, err)
+		assert.False(t, result.Truncated)
+		assert.Equal(t, `{"id":200, "name":"John"}`, string(result.Body))
+		assert.Equal(t, &fakeSuccess{ID: 200, Name: "John"}, result.Success)
+	})
+}
+
+func TestSetQueryFromURL(t *testing.T) {
+	req := New().SetQueryFromURL("http://old.example.com/path?a=1&b=2&b=3")
+
+	expected := url.Values{"a": {"1"}, "b": {"2", "3"}}
+	assert.Equal(t, expected, req.query)
+
+	request, err := req.Get("http://example.com/other").Request()
+	assert.Nil(t, err)
+	assert.Equal(t, "http://example.com/other?a=1&b=2&b=3", request.URL.String())
+
+	for _, rawurl := range []string{"http://[::1/path?a=1", "http://example.com/?a=%zz"} {
+		invalid := New().Get("http://example.com").SetQueryFromURL(rawurl)
+		_, err = invalid.Request()
+		assert.True(t, errors.Is(err, ErrInvalidQuery), rawurl)
+		_, err = invalid.Execute()
+		assert.True(t, errors.Is(err, ErrInvalidQuery), rawurl)
+
+		_, err = invalid.SetQuery(&fakeQuery{ID: 1}).Request()
+		assert.Nil(t, err)
+	}
+}
+
+func TestSetContentLength(t *testing.T) {
+	var contentLength int64
+	var transferEncoding []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentLength = r.ContentLength
+		transferEncoding = r.TransferEncoding
+		ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	body := func() io.Reader {
+		//MultiReader hides the length from http.NewRequest
+		return io.MultiReader(strings.NewReader(`{"id":10,`), strings.NewReader(`"Name":"Bob"}`))
+	}
+
+	t.Run("chunked without length", func(t *testing.T) {
+		_, err := New().Post(server.URL).SetBody(body()).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(-1), contentLength)
+		assert.Equal(t, []string{"chunked"}, transferEncoding)
+	})
+
+	t.Run("with length", func(t *testing.T) {
+		request := New().Post(server.URL).SetBody(body()).SetContentLength(22)
+
+		req, err := request.Request()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(22), req.ContentLength)
+
+		_, err = request.SetBody(body()).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(22), contentLength)
+		assert.Empty(t, transferEncoding)
+	})
+}
+
+func TestQueryMergesURLParams(t *testing.T) {
+	request, err := New().Get("http://example.com?page=2&id=1").Request()
+	assert.Nil(t, err)
+	assert.Equal(t, "http://example.com?page=2&id=1", request.URL.String())
+
+	request, err = New().Get("http://example.com?page=2&id=1").SetQuery(&fakeQuery{ID: 20}).Request()
+	assert.Nil(t, err)
+	assert.Equal(t, "http://example.com?id=20&name=&page=2", request.URL.String())
+
+	request, err = New().Get("http://example.com?tag=a&tag=b&page=2").SetQuery(url.Values{"tag": {"c"}}).Request()
+	assert.Nil(t, err)
+	assert.Equal(t, "http://example.com?page=2&tag=c", request.URL.String())
+}
+
+func TestSetTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	t.Run("default", func(t *testing.T) {
+		assert.Equal(t, 3*time.Second, New().client.(*http.Client).Timeout)
+	})
+
+	t.Run("cut off", func(t *testing.T) {
+		_, err := New().Get(server.URL).SetTimeout(10 * time.Millisecond).Execute()
+		assert.NotNil(t, err)
+	})
+
+	t.Run("no timeout", func(t *testing.T) {
+		template := New()
+		request := template.New().SetNoTimeout()
+		assert.Equal(t, time.Duration(0), request.client.(*http.Client).Timeout)
+		assert.Equal(t, 3*time.Second, template.client.(*http.Client).Timeout)
+
+		result, err := request.Get(server.URL).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+	})
+
+	t.Run("context still cancels", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := New().Get(server.URL).SetNoTimeout().SetContext(ctx).Execute()
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.Less(t, int64(time.Since(start)), int64(100*time.Millisecond))
+	})
+}
+
+func TestSetStrictDecode(t *testing.T) {
+	body := `{"id":200, "name":"John", "email":"john@example.com"}`
+
+	t.Run("lenient by default", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(200, body, nil))
+
+		result, err := r.Get("http://example.com").SetSuccess(&fakeSuccess{}).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, &fakeSuccess{ID: 200, Name: "John"}, result.Success)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(200, body, nil))
+
+		_, err := r.Get("http://example.com").SetSuccess(&fakeSuccess{}).SetStrictDecode(true).Execute()
+		assert.True(t, IsDecodeError(err))
+		assert.Contains(t, err.Error(), `unknown field "email"`)
+	})
+
+	t.Run("strict without unknown fields", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(400, `{"id":200, "name":"John"}`, nil))
+
+		result, err := r.Get("http://example.com").SetFailure(&fakeSuccess{}).SetStrictDecode(true).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, &fakeSuccess{ID: 200, Name: "John"}, result.Failure)
+	})
+}
+
+func TestNilRequest(t *testing.T) {
+	var r *Request
+
+	chained := r.New().
+		Post("http://example.com").
+		SetHeader("X-Key", "value").
+		AddHeader("X-Key", "other").
+		SetQuery(&fakeQuery{ID: 1}).
+		SetBody(&fakeSuccess{}).
+		SetSuccess(&fakeSuccess{}).
+		SetFailure(&fakeSuccess{}).
+		SetContext(context.Background()).
+		SetTimeout(time.Second)
+	assert.Nil(t, chained)
+
+	for _, method := range []func(string) *Request{r.Get, r.Post, r.Put, r.Head, r.Delete, r.Patch} {
+		assert.Nil(t, method("http://example.com"))
+	}
+
+	result, err := chained.Execute()
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrNilRequest)
+
+	req, err := r.Request()
+	assert.Nil(t, req)
+	assert.ErrorIs(t, err, ErrNilRequest)
+
+	_, err = r.DownloadTo(ioutil.Discard, nil)
+	assert.ErrorIs(t, err, ErrNilRequest)
+
+	result = (<-r.ExecuteAsync(context.Background())).Response
+	assert.Nil(t, result)
+}
+
+func TestNilRequestSetters(t *testing.T) {
+	var r *Request
+	value := reflect.ValueOf(r)
+	requestType := value.Type()
+
+	for i := 0; i < requestType.NumMethod(); i++ {
+		method := requestType.Method(i)
+		signature := method.Type
+		if signature.NumOut() != 1 || signature.Out(0) != requestType {
+			continue
+		}
+
+		args := make([]reflect.Value, signature.NumIn()-1)
+		for j := range args {
+			args[j] = reflect.Zero(signature.In(j + 1))
+		}
+		t.Run(method.Name, func(t *testing.T) {
+			var out []reflect.Value
+			assert.NotPanics(t, func() {
+				if signature.IsVariadic() {
+					out = value.Method(i).CallSlice(args)
+				} else {
+					out = value.Method(i).Call(args)
+				}
+			})
+			if assert.Len(t, out, 1) {
+				assert.True(t, out[0].IsNil())
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package request
+
+import "net/http"
+
+const defaultRequestIDHeader = "X-Request-ID"
+
+type requestIDConfig struct {
+	header string
+	gen    func() string
+}
+
+//EnableRequestID sends a request ID in the header called headerName, or X-Request-ID when empty, generated by gen
+//on every Execute, DownloadTo or ExecuteStream, or a random version 4 UUID when gen is nil. The same ID is sent
+//with every retry of an Execute.
+//When the header is already set, its value is propagated instead so an upstream ID can be reused.
+//The ID is exposed on the Response and passed to the logger, the OnAttempt hook and the OnMetrics hook
+func (r *Request) EnableRequestID(headerName string, gen func() string) *Request {
+	if r == nil {
+		return nil
+	}
+	if headerName == "" {
+		headerName = defaultRequestIDHeader
+	}
+	r.reqID = &requestIDConfig{header: headerName, gen: gen}
+	return r
+}
+
+//requestID returns the ID to send for a single Execute, empty when request IDs are not enabled
+func (r *Request) requestID() (string, error) {
+	if r.reqID == nil {
+		return "", nil
+	}
+	if id := r.header.Get(r.reqID.header); id != "" {
+		return id, nil
+	}
+	if r.reqID.gen != nil {
+		return r.reqID.gen(), nil
+	}
+	return newUUID()
+}
+
+//setRequestID sets id as the request ID header of req
+func (r *Request) setRequestID(req *http.Request, id string) {
+	if id != "" {
+		req.Header.Set(r.reqID.header, id)
+	}
+}
+
+//requestIDOf returns the request ID sent with req, empty when request IDs are not enabled
+func (r *Request) requestIDOf(req *http.Request) string {
+	if r.reqID == nil {
+		return ""
+	}
+	return req.Header.Get(r.reqID.header)
+}
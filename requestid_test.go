@@ -0,0 +1,138 @@
+package request
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestEnableRequestID(t *testing.T) {
+	t.Run("generated", func(t *testing.T) {
+		var seen []string
+		calls := 0
+		statuses := countingHandler(&calls, []int{503, 503, 200}, `{}`)
+		r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			seen = append(seen, req.Header.Get("X-Request-ID"))
+			statuses(w, req)
+		})
+
+		result, err := r.Get("http://example.com").SetRetry(2, time.Millisecond).SetClock(newFakeClock()).
+			EnableRequestID("", nil).Execute()
+		assert.Nil(t, err)
+		assert.Len(t, seen, 3)
+		assert.Regexp(t, uuidPattern, seen[0])
+		assert.Equal(t, []string{seen[0], seen[0], seen[0]}, seen)
+		assert.Equal(t, seen[0], result.RequestID)
+
+		result, err = r.Execute()
+		assert.Nil(t, err)
+		assert.NotEqual(t, seen[0], result.RequestID)
+	})
+
+	t.Run("custom header and generator", func(t *testing.T) {
+		var seen string
+		r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			seen = req.Header.Get("X-Correlation-ID")
+		})
+
+		result, err := r.Get("http://example.com").EnableRequestID("X-Correlation-ID", func() string { return "abc" }).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "abc", seen)
+		assert.Equal(t, "abc", result.RequestID)
+	})
+
+	t.Run("propagated", func(t *testing.T) {
+		var seen string
+		r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			seen = req.Header.Get("X-Request-ID")
+		})
+
+		result, err := r.Get("http://example.com").SetHeader("X-Request-ID", "upstream").
+			EnableRequestID("", func() string { return "generated" }).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "upstream", seen)
+		assert.Equal(t, "upstream", result.RequestID)
+	})
+
+	t.Run("hook payloads", func(t *testing.T) {
+		var attempt AttemptInfo
+		var metrics RequestMetrics
+		handler := &captureHandler{level: slog.LevelDebug}
+		r := newMockRequest(fakeHandler(200, `{}`, nil))
+
+		_, err := r.Get("http://example.com").EnableRequestID("", func() string { return "id-1" }).
+			OnAttempt(func(info AttemptInfo) { attempt = info }).
+			OnMetrics(func(m RequestMetrics) { metrics = m }).
+			SetLogger(slog.New(handler)).
+			Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "id-1", attempt.RequestID)
+		assert.Equal(t, "id-1", metrics.RequestID)
+		for _, record := range handler.records {
+			assert.Equal(t, "id-1", attrs(record)["request_id"].String(), record.Message)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		var seen http.Header
+		r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			seen = req.Header
+		})
+
+		result, err := r.Get("http://example.com").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, "", result.RequestID)
+		assert.Equal(t, "", seen.Get("X-Request-ID"))
+	})
+}
+
+func TestDownloadAndStreamPerExecutionSteps(t *testing.T) {
+	var header http.Header
+	template := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+		header = req.Header.Clone()
+		w.Write([]byte(`[1]`))
+	})
+
+	send := map[string]func(r *Request) (*Response, error){
+		"download": func(r *Request) (*Response, error) {
+			return r.DownloadTo(&bytes.Buffer{}, nil)
+		},
+		"stream": func(r *Request) (*Response, error) {
+			result, err := r.ExecuteStream()
+			if result != nil {
+				result.Close()
+			}
+			return result, err
+		},
+	}
+	for name, send := range send {
+		t.Run(name, func(t *testing.T) {
+			var attempts []AttemptInfo
+			var metrics []RequestMetrics
+			r := template.New().Get("http://example.com").EnableRequestID("", nil).AutoIdempotencyKey().
+				OnAttempt(func(info AttemptInfo) { attempts = append(attempts, info) }).
+				OnMetrics(func(m RequestMetrics) { metrics = append(metrics, m) })
+
+			result, err := send(r)
+			assert.Nil(t, err)
+			assert.Regexp(t, uuidPattern, result.RequestID)
+			assert.Equal(t, result.RequestID, header.Get("X-Request-ID"))
+			assert.Regexp(t, uuidPattern, result.IdempotencyKey)
+			assert.Equal(t, result.IdempotencyKey, header.Get("Idempotency-Key"))
+
+			assert.Len(t, attempts, 1)
+			assert.Equal(t, result.RequestID, attempts[0].RequestID)
+			assert.Equal(t, 200, attempts[0].StatusCode)
+			assert.Len(t, metrics, 1)
+			assert.Equal(t, result.RequestID, metrics[0].RequestID)
+			assert.Equal(t, 1, metrics[0].Attempts)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+//Package requesttest provides helpers for testing code built on the request package
+package requesttest
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+//FakeClock is a request.Clock that only moves when told to.
+//Channels returned by After fire once the clock has been advanced past their deadline
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	auto    bool
+	waiters []waiter
+}
+
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+//NewFakeClock returns a fake clock set to start
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+//SetAutoAdvance makes After advance the clock by the requested duration right away when auto is true,
+//so retries, backoff and rate limiting never block while the elapsed time is still accounted for
+func (c *FakeClock) SetAutoAdvance(auto bool) *FakeClock {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.auto = auto
+	return c
+}
+
+//Now returns the current time of the clock
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+//After returns a channel receiving the clock time once it has been advanced by d
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	w := waiter{deadline: c.now.Add(d), ch: make(chan time.Time, 1)}
+	c.waiters = append(c.waiters, w)
+	if c.auto && d > 0 {
+		c.now = w.deadline
+	}
+	c.mu.Unlock()
+
+	c.fire()
+	return w.ch
+}
+
+//Advance moves the clock forward by d, firing every channel whose deadline has passed
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+
+	c.fire()
+}
+
+//Waiters returns the number of channels returned by After which have not fired yet.
+//It lets a test wait until the code under test is blocked on the clock before advancing it
+func (c *FakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+//fire sends the current time to the waiters whose deadline has passed, earliest first
+func (c *FakeClock) fire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sort.SliceStable(c.waiters, func(i, j int) bool {
+		return c.waiters[i].deadline.Before(c.waiters[j].deadline)
+	})
+
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(c.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = pending
+}
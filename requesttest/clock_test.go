@@ -0,0 +1,49 @@
+package requesttest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+func fired(ch <-chan time.Time) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	clock := NewFakeClock(start)
+	assert.Equal(t, start, clock.Now())
+
+	short := clock.After(time.Second)
+	long := clock.After(time.Minute)
+	assert.Equal(t, 2, clock.Waiters())
+	assert.False(t, fired(short))
+
+	clock.Advance(time.Second)
+	assert.True(t, fired(short))
+	assert.False(t, fired(long))
+	assert.Equal(t, 1, clock.Waiters())
+
+	clock.Advance(time.Hour)
+	assert.True(t, fired(long))
+	assert.Equal(t, 0, clock.Waiters())
+	assert.Equal(t, start.Add(time.Hour+time.Second), clock.Now())
+
+	assert.True(t, fired(clock.After(0)))
+}
+
+func TestFakeClockAutoAdvance(t *testing.T) {
+	clock := NewFakeClock(start).SetAutoAdvance(true)
+
+	assert.True(t, fired(clock.After(3*time.Second)))
+	assert.Equal(t, start.Add(3*time.Second), clock.Now())
+	assert.Equal(t, 0, clock.Waiters())
+}
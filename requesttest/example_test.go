@@ -0,0 +1,69 @@
+package requesttest_test
+
+import (
+	"errors"
+	"fmt"
+
+	request "github.com/AidenHadisi/go-simple-request"
+	"github.com/AidenHadisi/go-simple-request/requesttest"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func ExampleMockDoer() {
+	mock := requesttest.NewMockDoer()
+	mock.On("GET", "/users/1").RespondJSON(200, user{ID: 1, Name: "John"})
+
+	var u user
+	resp, err := request.New().SetClient(mock).Get("https://api.example.com/users/1").SetSuccess(&u).Execute()
+	fmt.Println(resp.StatusCode, u.Name, err)
+	fmt.Println(mock.CallCount("GET", "/users/1"))
+	//Output:
+	//200 John <nil>
+	//1
+}
+
+func ExampleResponder_Respond() {
+	mock := requesttest.NewMockDoer()
+	mock.On("GET", "/jobs/7").
+		Respond(503, "", nil).
+		Respond(200, `{"id":7,"name":"done"}`, nil)
+
+	var job user
+	resp, err := request.New().SetClient(mock).Get("https://api.example.com/jobs/7").
+		SetRetry(1, 0).
+		SetSuccess(&job).
+		Execute()
+	fmt.Println(resp.StatusCode, job.Name, err)
+	fmt.Println(len(mock.Calls()))
+	//Output:
+	//200 done <nil>
+	//2
+}
+
+func ExampleResponder_RespondError() {
+	mock := requesttest.NewMockDoer()
+	mock.OnPrefix("", "/").RespondError(errors.New("connection refused"))
+
+	_, err := request.New().SetClient(mock).Get("https://api.example.com/users").Execute()
+	fmt.Println(err)
+	//Output:
+	//connection refused
+}
+
+func ExampleMockDoer_Calls() {
+	mock := requesttest.NewMockDoer()
+	mock.On("POST", "/users").Respond(201, "", nil)
+
+	request.New().SetClient(mock).Post("https://api.example.com/users").SetBody(user{Name: "John"}).Execute()
+
+	var sent user
+	call := mock.Calls()[0]
+	call.DecodeJSON(&sent)
+	fmt.Println(call.Method, call.URL, sent.Name)
+	//Output:
+	//POST https://api.example.com/users John
+}
@@ -0,0 +1,72 @@
+package requesttest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//RespondWithFile registers a responder answering requests with the method, or any method when it is empty,
+//whose URL matches pattern with the contents of file and status. Every "*" in pattern matches the non-empty
+//text of a path segment, and "{{1}}", "{{2}}" and so on in the file are replaced with the text matched by the first,
+//second and later wildcards. The file is read on every request and the Content-Type inferred from its extension.
+//A missing file fails the request with an error naming the resolved path
+func (m *MockDoer) RespondWithFile(method, pattern, file string, status int) *Responder {
+	responder := &Responder{method: method, pattern: pattern, kind: matchExact}
+	if strings.Contains(pattern, "*") {
+		responder.kind, responder.re = matchWildcard, wildcardRegexp(pattern)
+	}
+	responder.queue(mockResponse{status: status, file: file})
+	return m.register(responder)
+}
+
+//RespondFromDir registers a responder answering every request no other responder matches with a JSON fixture
+//from dir named after the method and path, with "/" replaced by "_": GET /users/42 is answered with
+//dir/GET_users_42.json and status 200. A missing fixture fails the request with an error naming the resolved path
+func (m *MockDoer) RespondFromDir(dir string) *Responder {
+	return m.register(&Responder{kind: matchDir, dir: dir})
+}
+
+//wildcardRegexp compiles pattern into a regular expression capturing the text matched by each "*"
+func wildcardRegexp(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, "([^/]+)") + "$")
+}
+
+//dirFixture returns the fixture file in dir named after the method and path of req
+func dirFixture(dir string, req *http.Request) string {
+	return filepath.Join(dir, req.Method+strings.ReplaceAll(req.URL.Path, "/", "_")+".json")
+}
+
+//loadFixture reads the fixture file of resp, substituting the wildcard captures
+func loadFixture(resp mockResponse, captures []string) (mockResponse, error) {
+	path, err := filepath.Abs(resp.file)
+	if err != nil {
+		path = resp.file
+	}
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return resp, fmt.Errorf("requesttest: failed to read fixture %s: %w", path, err)
+	}
+
+	for i := len(captures) - 1; i >= 0; i-- {
+		body = []byte(strings.ReplaceAll(string(body), "{{"+strconv.Itoa(i+1)+"}}", captures[i]))
+	}
+	resp.body = body
+	resp.header = resp.header.Clone()
+	if resp.header == nil {
+		resp.header = make(http.Header)
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(path)); contentType != "" && resp.header.Get("Content-Type") == "" {
+		resp.header.Set("Content-Type", contentType)
+	}
+	return resp, nil
+}
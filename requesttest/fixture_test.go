@@ -0,0 +1,108 @@
+package requesttest
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRespondWithFile(t *testing.T) {
+	t.Run("template substitution", func(t *testing.T) {
+		m := NewMockDoer()
+		m.RespondWithFile("GET", "/users/*", "testdata/user.json", 200)
+		m.RespondWithFile("GET", "/teams/*/members/*", "testdata/member.json", 200)
+		m.RespondWithFile("", "/greetings/*.txt", "testdata/greeting.txt", 201)
+
+		resp, body, err := send(t, m, "GET", "http://example.com/users/42", "")
+		assert.Nil(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "{\"id\":\"42\",\"name\":\"user 42\"}\n", body)
+
+		_, body, err = send(t, m, "GET", "http://example.com/teams/red/members/7", "")
+		assert.Nil(t, err)
+		assert.Equal(t, "{\"team\":\"red\",\"member\":\"7\"}\n", body)
+
+		resp, body, err = send(t, m, "POST", "http://example.com/greetings/world.txt", "")
+		assert.Nil(t, err)
+		assert.Equal(t, 201, resp.StatusCode)
+		assert.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Equal(t, "hello world\n", body)
+	})
+
+	t.Run("wildcards match within a segment", func(t *testing.T) {
+		m := NewMockDoer()
+		m.RespondWithFile("GET", "/users/*", "testdata/user.json", 200)
+
+		for _, url := range []string{"http://example.com/users/", "http://example.com/users/1/posts", "http://example.com/admin/users/1"} {
+			_, _, err := send(t, m, "GET", url, "")
+			assert.True(t, errors.Is(err, ErrNoResponder), url)
+		}
+	})
+
+	t.Run("precedence", func(t *testing.T) {
+		m := NewMockDoer()
+		m.RespondFromDir("testdata/fixtures")
+		m.OnRegexp("GET", regexp.MustCompile(`/users/`)).Respond(200, "regexp", nil)
+		m.OnPrefix("GET", "/users/1").Respond(200, "prefix", nil)
+		m.On("GET", "/users/*/posts").Respond(200, "literal pattern", nil)
+		m.RespondWithFile("GET", "/users/*/*", "testdata/member.json", 200)
+		m.RespondWithFile("GET", "/users/*/posts", "testdata/user.json", 200)
+		m.RespondWithFile("GET", "/users/*", "testdata/user.json", 200)
+		m.On("GET", "/users/admin").Respond(200, "exact", nil)
+
+		cases := []struct {
+			url      string
+			expected string
+		}{
+			{"http://example.com/users/admin", "exact"},
+			{"http://example.com/users/1", "{\"id\":\"1\",\"name\":\"user 1\"}\n"},
+			{"http://example.com/users/1/posts", "{\"id\":\"1\",\"name\":\"user 1\"}\n"},
+			{"http://example.com/users/*/posts", "literal pattern"},
+			{"http://example.com/users/1/likes", "{\"team\":\"1\",\"member\":\"likes\"}\n"},
+			{"http://example.com/users/1/likes/2", "prefix"},
+			{"http://example.com/users/2/likes/2", "regexp"},
+			{"http://example.com/users/42", "{\"id\":\"42\",\"name\":\"user 42\"}\n"},
+			{"http://example.com/teams", ""},
+		}
+		for _, c := range cases {
+			_, body, err := send(t, m, "GET", c.url, "")
+			if c.expected == "" {
+				assert.NotNil(t, err, c.url)
+				continue
+			}
+			assert.Nil(t, err, c.url)
+			assert.Equal(t, c.expected, body, c.url)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		m := NewMockDoer()
+		m.RespondWithFile("GET", "/users/*", "testdata/missing.json", 200)
+
+		_, _, err := send(t, m, "GET", "http://example.com/users/1", "")
+		abs, _ := filepath.Abs("testdata/missing.json")
+		assert.True(t, errors.Is(err, os.ErrNotExist))
+		assert.Contains(t, err.Error(), abs)
+	})
+}
+
+func TestRespondFromDir(t *testing.T) {
+	m := NewMockDoer()
+	m.RespondFromDir("testdata/fixtures")
+
+	resp, body, err := send(t, m, "GET", "http://example.com/users/42?full=true", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "{\"id\":42,\"name\":\"from dir\"}\n", body)
+
+	_, _, err = send(t, m, "DELETE", "http://example.com/users/42", "")
+	abs, _ := filepath.Abs(filepath.Join("testdata", "fixtures", "DELETE_users_42.json"))
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+	assert.Contains(t, err.Error(), abs)
+}
@@ -0,0 +1,290 @@
+package requesttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+//ErrNoResponder is returned by MockDoer.Do when no responder matches a request
+var ErrNoResponder = errors.New("requesttest: no responder matches the request")
+
+//MockDoer is a client for request.SetClient which answers requests with registered responders instead of
+//the network and records every request it receives. Responders are matched by method and URL pattern:
+//an exact pattern takes precedence over a wildcard pattern, a wildcard pattern with more literal characters over
+//one with fewer, wildcard patterns over prefixes, a longer prefix over a shorter one, prefixes over regular
+//expressions and regular expressions over a fixture directory, with responders registered first winning ties.
+//Patterns starting with "/" are matched against the URL path, other patterns against the full URL.
+//It is safe for concurrent use
+type MockDoer struct {
+	mu         sync.Mutex
+	responders []*Responder
+	calls      []Call
+}
+
+//Call is a request received by a MockDoer
+type Call struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+//DecodeJSON unmarshals the body of the request into v
+func (c Call) DecodeJSON(v interface{}) error {
+	return json.Unmarshal(c.Body, v)
+}
+
+type matchKind int
+
+const (
+	matchExact matchKind = iota
+	matchWildcard
+	matchPrefix
+	matchRegexp
+	matchDir
+)
+
+//Responder answers the requests it matches with its queued responses in order,
+//repeating the last one once the queue is exhausted. Without queued responses it answers 200 OK with an empty body
+type Responder struct {
+	method  string
+	pattern string
+	re      *regexp.Regexp
+	kind    matchKind
+	dir     string
+
+	mu        sync.Mutex
+	responses []mockResponse
+	delay     time.Duration
+	calls     int
+}
+
+type mockResponse struct {
+	status int
+	header http.Header
+	body   []byte
+	file   string
+	err    error
+}
+
+//NewMockDoer returns a MockDoer without responders
+func NewMockDoer() *MockDoer {
+	return &MockDoer{}
+}
+
+//On registers a responder for requests with the method, or any method when it is empty,
+//whose URL equals pattern
+func (m *MockDoer) On(method, pattern string) *Responder {
+	return m.register(&Responder{method: method, pattern: pattern, kind: matchExact})
+}
+
+//OnPrefix registers a responder for requests with the method, or any method when it is empty,
+//whose URL starts with prefix
+func (m *MockDoer) OnPrefix(method, prefix string) *Responder {
+	return m.register(&Responder{method: method, pattern: prefix, kind: matchPrefix})
+}
+
+//OnRegexp registers a responder for requests with the method, or any method when it is empty,
+//whose full URL matches re
+func (m *MockDoer) OnRegexp(method string, re *regexp.Regexp) *Responder {
+	return m.register(&Responder{method: method, re: re, kind: matchRegexp})
+}
+
+func (m *MockDoer) register(responder *Responder) *Responder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.responders = append(m.responders, responder)
+	return responder
+}
+
+//Respond queues a response with the status, body and headers, which may be nil
+func (r *Responder) Respond(status int, body string, header http.Header) *Responder {
+	return r.queue(mockResponse{status: status, header: header.Clone(), body: []byte(body)})
+}
+
+//RespondJSON queues a response with the status and v marshaled as JSON, with the Content-Type application/json.
+//It panics when v cannot be marshaled
+func (r *Responder) RespondJSON(status int, v interface{}) *Responder {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("requesttest: failed to marshal response: %v", err))
+	}
+	return r.queue(mockResponse{status: status, header: http.Header{"Content-Type": {"application/json"}}, body: body})
+}
+
+//RespondError queues a transport error returned instead of a response
+func (r *Responder) RespondError(err error) *Responder {
+	return r.queue(mockResponse{err: err})
+}
+
+//Delay waits d before answering every request, returning the context error if the request is canceled first
+func (r *Responder) Delay(d time.Duration) *Responder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delay = d
+	return r
+}
+
+//Calls returns the number of requests the responder answered
+func (r *Responder) Calls() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.calls
+}
+
+func (r *Responder) queue(resp mockResponse) *Responder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.responses = append(r.responses, resp)
+	return r
+}
+
+//matches reports whether the responder matches req, how specific the match is, higher being more specific,
+//and the text matched by the wildcards of the pattern
+func (r *Responder) matches(req *http.Request) (int, []string, bool) {
+	if r.method != "" && r.method != req.Method {
+		return 0, nil, false
+	}
+
+	target := req.URL.String()
+	if strings.HasPrefix(r.pattern, "/") {
+		target = req.URL.Path
+	}
+	switch r.kind {
+	case matchExact:
+		return 1 << 30, nil, target == r.pattern
+	case matchWildcard:
+		match := r.re.FindStringSubmatch(target)
+		if match == nil {
+			return 0, nil, false
+		}
+		return 1<<20 + len(r.pattern) - strings.Count(r.pattern, "*"), match[1:], true
+	case matchPrefix:
+		return len(r.pattern) + 2, nil, strings.HasPrefix(target, r.pattern)
+	case matchRegexp:
+		return 1, nil, r.re.MatchString(req.URL.String())
+	default:
+		return 0, nil, true
+	}
+}
+
+//next returns the response for the next call and the delay before answering it
+func (r *Responder) next() (mockResponse, time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls++
+	if len(r.responses) == 0 {
+		return mockResponse{status: http.StatusOK}, r.delay
+	}
+	resp := r.responses[0]
+	if len(r.responses) > 1 {
+		r.responses = r.responses[1:]
+	}
+	return resp, r.delay
+}
+
+//Do records req and answers it with the best matching responder
+func (m *MockDoer) Do(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.mu.Lock()
+	m.calls = append(m.calls, Call{Method: req.Method, URL: req.URL.String(), Header: req.Header.Clone(), Body: body})
+	var best *Responder
+	var captures []string
+	bestScore := -1
+	for _, responder := range m.responders {
+		if score, matched, ok := responder.matches(req); ok && score > bestScore {
+			best, captures, bestScore = responder, matched, score
+		}
+	}
+	m.mu.Unlock()
+
+	if best == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoResponder, req.Method, req.URL)
+	}
+
+	resp, delay := best.next()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+	if resp.err != nil {
+		return nil, resp.err
+	}
+	if best.kind == matchDir {
+		resp.file = dirFixture(best.dir, req)
+	}
+	if resp.file != "" {
+		var err error
+		if resp, err = loadFixture(resp, captures); err != nil {
+			return nil, err
+		}
+	}
+
+	header := resp.header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.status, http.StatusText(resp.status)),
+		StatusCode:    resp.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(resp.body)),
+		ContentLength: int64(len(resp.body)),
+		Request:       req,
+	}, nil
+}
+
+//Calls returns the requests received so far in order
+func (m *MockDoer) Calls() []Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Call(nil), m.calls...)
+}
+
+//CallCount returns the number of requests received with the method, or any method when it is empty,
+//and the URL, matched against the path when it starts with "/"
+func (m *MockDoer) CallCount(method, address string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for _, call := range m.calls {
+		target := call.URL
+		if strings.HasPrefix(address, "/") {
+			if u, err := url.Parse(call.URL); err == nil {
+				target = u.Path
+			}
+		}
+		if (method == "" || method == call.Method) && target == address {
+			count++
+		}
+	}
+	return count
+}
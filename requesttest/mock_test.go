@@ -0,0 +1,168 @@
+package requesttest
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func send(t *testing.T, m *MockDoer, method, url, body string) (*http.Response, string, error) {
+	t.Helper()
+	var reader *strings.Reader
+	req, err := http.NewRequest(method, url, nil)
+	if body != "" {
+		reader = strings.NewReader(body)
+		req, err = http.NewRequest(method, url, reader)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := m.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+	return resp, string(data), nil
+}
+
+func TestMockDoerMatching(t *testing.T) {
+	m := NewMockDoer()
+	m.OnRegexp("GET", regexp.MustCompile(`/users/\d+$`)).Respond(200, "regexp", nil)
+	m.OnPrefix("GET", "/users/").Respond(200, "short prefix", nil)
+	m.OnPrefix("GET", "/users/admin").Respond(200, "long prefix", nil)
+	m.On("GET", "/users/1").Respond(200, "exact path", nil)
+	m.On("GET", "http://example.com/users/2?full=true").Respond(200, "exact url", nil)
+	m.On("", "/any").Respond(200, "any method", nil)
+	m.On("GET", "/dup").Respond(200, "first", nil)
+	m.On("GET", "/dup").Respond(200, "second", nil)
+
+	cases := []struct {
+		method   string
+		url      string
+		expected string
+	}{
+		{"GET", "http://example.com/users/1", "exact path"},
+		{"GET", "http://example.com/users/1?page=2", "exact path"},
+		{"GET", "http://example.com/users/2?full=true", "exact url"},
+		{"GET", "http://example.com/users/2", "short prefix"},
+		{"GET", "http://example.com/users/admins", "long prefix"},
+		{"GET", "http://example.com/teams/users/3", "regexp"},
+		{"DELETE", "http://example.com/any", "any method"},
+		{"GET", "http://example.com/dup", "first"},
+	}
+	for _, c := range cases {
+		_, body, err := send(t, m, c.method, c.url, "")
+		assert.Nil(t, err, c.url)
+		assert.Equal(t, c.expected, body, c.url)
+	}
+
+	t.Run("method must match", func(t *testing.T) {
+		_, _, err := send(t, m, "POST", "http://example.com/users/1", "")
+		assert.True(t, errors.Is(err, ErrNoResponder))
+		assert.Contains(t, err.Error(), "POST http://example.com/users/1")
+	})
+}
+
+func TestMockDoerResponses(t *testing.T) {
+	t.Run("queued in order", func(t *testing.T) {
+		m := NewMockDoer()
+		responder := m.On("GET", "/jobs/1").
+			Respond(202, "pending", nil).
+			Respond(200, "done", http.Header{"X-Done": {"true"}})
+
+		var statuses []int
+		var bodies []string
+		for i := 0; i < 3; i++ {
+			resp, body, err := send(t, m, "GET", "http://example.com/jobs/1", "")
+			assert.Nil(t, err)
+			statuses = append(statuses, resp.StatusCode)
+			bodies = append(bodies, body)
+		}
+		assert.Equal(t, []int{202, 200, 200}, statuses)
+		assert.Equal(t, []string{"pending", "done", "done"}, bodies)
+		assert.Equal(t, 3, responder.Calls())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		m := NewMockDoer()
+		m.On("GET", "/users/1").RespondJSON(200, map[string]interface{}{"id": 1})
+
+		resp, body, err := send(t, m, "GET", "http://example.com/users/1", "")
+		assert.Nil(t, err)
+		assert.Equal(t, "200 OK", resp.Status)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		assert.Equal(t, `{"id":1}`, body)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		m := NewMockDoer()
+		m.On("GET", "/ping")
+
+		resp, body, err := send(t, m, "GET", "http://example.com/ping", "")
+		assert.Nil(t, err)
+		assert.Equal(t, 200, resp.StatusCode)
+		assert.Equal(t, "", body)
+	})
+
+	t.Run("transport error", func(t *testing.T) {
+		refused := errors.New("connection refused")
+		m := NewMockDoer()
+		m.On("GET", "/users").RespondError(refused).Respond(200, "ok", nil)
+
+		_, _, err := send(t, m, "GET", "http://example.com/users", "")
+		assert.Equal(t, refused, err)
+		_, body, err := send(t, m, "GET", "http://example.com/users", "")
+		assert.Nil(t, err)
+		assert.Equal(t, "ok", body)
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		m := NewMockDoer()
+		m.On("GET", "/slow").Delay(20 * time.Millisecond)
+
+		start := time.Now()
+		_, _, err := send(t, m, "GET", "http://example.com/slow", "")
+		assert.Nil(t, err)
+		assert.GreaterOrEqual(t, int64(time.Since(start)), int64(20*time.Millisecond))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		m.On("GET", "/slower").Delay(time.Minute)
+		req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.com/slower", nil)
+		_, err = m.Do(req)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	})
+}
+
+func TestMockDoerCalls(t *testing.T) {
+	m := NewMockDoer()
+	m.OnPrefix("", "/users")
+
+	send(t, m, "POST", "http://example.com/users?notify=true", `{"name":"John"}`)
+	send(t, m, "GET", "http://example.com/users/1", "")
+	send(t, m, "GET", "http://example.com/users/1", "")
+	send(t, m, "GET", "http://example.com/teams", "")
+
+	calls := m.Calls()
+	assert.Len(t, calls, 4)
+	assert.Equal(t, "POST", calls[0].Method)
+	assert.Equal(t, "http://example.com/users?notify=true", calls[0].URL)
+
+	var body struct{ Name string }
+	assert.Nil(t, calls[0].DecodeJSON(&body))
+	assert.Equal(t, "John", body.Name)
+
+	assert.Equal(t, 1, m.CallCount("POST", "/users"))
+	assert.Equal(t, 1, m.CallCount("", "http://example.com/users?notify=true"))
+	assert.Equal(t, 2, m.CallCount("GET", "/users/1"))
+	assert.Equal(t, 0, m.CallCount("DELETE", "/users/1"))
+	assert.Equal(t, 1, m.CallCount("", "/teams"))
+}
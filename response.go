@@ -1,11 +1,200 @@
 package request
 
-import "net/http"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
 
 //Response is a response returned from the request
 type Response struct {
-	StatusCode int
-	Header     http.Header
-	Success    interface{}
-	Failure    interface{}
+	StatusCode      int
+	Header          http.Header
+	Body            []byte
+	Truncated       bool
+	IdempotencyKey  string
+	RequestID       string
+	Hedged          bool
+	FromCache       bool
+	Revalidated     bool
+	Endpoint        string
+	FinalURL        string
+	RedirectHistory []RedirectHop
+	Tags            map[string]string
+	Success         interface{}
+	Failure         interface{}
+
+	//stream is the unread body of a response returned by ExecuteStream
+	stream *contextReader
+}
+
+//Err returns nil for 2xx responses and an *HTTPError carrying the status, decoded Failure and raw body otherwise
+func (r *Response) Err() error {
+	if 200 <= r.StatusCode && r.StatusCode <= 299 {
+		return nil
+	}
+
+	return &HTTPError{
+		StatusCode: r.StatusCode,
+		Failure:    r.Failure,
+		Body:       r.Body,
+	}
+}
+
+//ContentIsEmpty reports whether the response body is empty, whatever the status.
+//Empty bodies are never decoded into Success or Failure, which are left nil
+func (r *Response) ContentIsEmpty() bool {
+	return len(r.Body) == 0
+}
+
+//IsJSON reports whether the Content-Type header is a JSON media type, such as application/json
+//or one with a +json suffix like application/vnd.api+json
+func (r *Response) IsJSON() bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+//CopyHeaders returns the values of the named headers keyed by the names as given, so they can be kept
+//without retaining the whole response. Multiple values of a header are joined with ", " and missing headers are omitted
+func (r *Response) CopyHeaders(keys ...string) map[string]string {
+	headers := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if values := r.Header.Values(key); len(values) > 0 {
+			headers[key] = strings.Join(values, ", ")
+		}
+	}
+	return headers
+}
+
+//AsMap decodes the JSON response body into a generic map.
+//It returns a *DecodeError when the body is not a JSON object
+func (r *Response) AsMap() (map[string]interface{}, error) {
+	var m map[string]interface{}
+	if err := json.Unmarshal(r.Body, &m); err != nil {
+		return nil, &DecodeError{Err: err}
+	}
+	if m == nil {
+		return nil, &DecodeError{Err: errors.New("response body is null, not a JSON object")}
+	}
+	return m, nil
+}
+
+//JSONPath returns the value found at a dotted path in the JSON response body, e.g. data.items.0.id.
+//Numeric segments index into arrays. An empty path returns the whole document.
+//It returns a *DecodeError when the body is not JSON and a *JSONPathError matching ErrPathNotFound when the path does not exist
+func (r *Response) JSONPath(path string) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(r.Body, &value); err != nil {
+		return nil, &DecodeError{Err: err}
+	}
+
+	if path == "" {
+		return value, nil
+	}
+
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		current := strings.Join(keys[:i+1], ".")
+
+		switch node := value.(type) {
+		case map[string]interface{}:
+			child, ok := node[key]
+			if !ok {
+				return nil, &JSONPathError{Path: current}
+			}
+			value = child
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil {
+				return nil, &JSONPathError{Path: current, Reason: fmt.Sprintf("%q is not an array index", key)}
+			}
+			if index < 0 || index >= len(node) {
+				return nil, &JSONPathError{Path: current, Reason: fmt.Sprintf("index %d out of range", index)}
+			}
+			value = node[index]
+		default:
+			return nil, &JSONPathError{Path: current}
+		}
+	}
+
+	return value, nil
+}
+
+//BindHeaders maps response headers into the struct pointed to by v using `header:"Name"` field tags.
+//String, []string, bool, integer and float fields are supported. Missing headers leave the field untouched
+func (r *Response) BindHeaders(v interface{}) error {
+	ptr := reflect.ValueOf(v)
+	if ptr.Kind() != reflect.Ptr || ptr.IsNil() || ptr.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("BindHeaders requires a non-nil pointer to a struct, got %T", v)
+	}
+
+	value := ptr.Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := field.Tag.Get("header")
+		if name == "" || name == "-" || field.PkgPath != "" {
+			continue
+		}
+
+		values := r.Header.Values(name)
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setHeaderField(value.Field(i), values); err != nil {
+			return fmt.Errorf("failed to bind header %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func setHeaderField(field reflect.Value, values []string) error {
+	raw := strings.TrimSpace(values[0])
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(values[0])
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported field type %s", field.Type())
+		}
+		field.Set(reflect.ValueOf(append([]string(nil), values...)).Convert(field.Type()))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a bool", raw)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid %s", raw, field.Type())
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid %s", raw, field.Type())
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a valid %s", raw, field.Type())
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
 }
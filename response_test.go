@@ -0,0 +1,247 @@
+package request
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONPath(t *testing.T) {
+	resp := &Response{Body: []byte(`{"data": {"items": [{"id": 7, "tags": ["a", "b"]}, {"id": 8}], "name": "list"}}`)}
+
+	cases := []struct {
+		path     string
+		expected interface{}
+	}{
+		{"data.name", "list"},
+		{"data.items.0.id", float64(7)},
+		{"data.items.1.id", float64(8)},
+		{"data.items.0.tags.1", "b"},
+		{"data.items.1", map[string]interface{}{"id": float64(8)}},
+	}
+
+	for _, c := range cases {
+		value, err := resp.JSONPath(c.path)
+		assert.Nil(t, err, c.path)
+		assert.Equal(t, c.expected, value, c.path)
+	}
+
+	t.Run("whole document", func(t *testing.T) {
+		value, err := resp.JSONPath("")
+		assert.Nil(t, err)
+		assert.IsType(t, map[string]interface{}{}, value)
+	})
+}
+
+func TestJSONPathErrors(t *testing.T) {
+	resp := &Response{Body: []byte(`{"data": {"items": [{"id": 7}]}}`)}
+
+	cases := []struct {
+		path    string
+		message string
+	}{
+		{"data.missing", `request: json path "data.missing" not found`},
+		{"data.items.3", `request: json path "data.items.3": index 3 out of range`},
+		{"data.items.first", `request: json path "data.items.first": "first" is not an array index`},
+		{"data.items.0.id.value", `request: json path "data.items.0.id.value" not found`},
+	}
+
+	for _, c := range cases {
+		value, err := resp.JSONPath(c.path)
+		assert.Nil(t, value)
+		assert.EqualError(t, err, c.message)
+	}
+
+	t.Run("invalid json", func(t *testing.T) {
+		_, err := (&Response{Body: []byte(`<html>`)}).JSONPath("data")
+		assert.NotNil(t, err)
+	})
+}
+
+func TestContentIsEmpty(t *testing.T) {
+	t.Run("empty 200", func(t *testing.T) {
+		success := &fakeSuccess{}
+		failure := &fakeSuccess{}
+		r := newMockRequest(fakeHandler(200, ``, nil))
+
+		result, err := r.Get("http://example.com").SetSuccess(success).SetFailure(failure).Execute()
+		assert.Nil(t, err)
+		assert.True(t, result.ContentIsEmpty())
+		assert.Nil(t, result.Success)
+		assert.Equal(t, &fakeSuccess{}, success)
+	})
+
+	t.Run("empty 404", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(404, ``, nil))
+
+		result, err := r.Get("http://example.com").SetFailure(&fakeSuccess{}).Execute()
+		assert.Nil(t, err)
+		assert.True(t, result.ContentIsEmpty())
+		assert.Nil(t, result.Failure)
+	})
+
+	t.Run("non-empty 200", func(t *testing.T) {
+		success := &fakeSuccess{}
+		r := newMockRequest(fakeHandler(200, `{"id":1}`, nil))
+
+		result, err := r.Get("http://example.com").SetSuccess(success).Execute()
+		assert.Nil(t, err)
+		assert.False(t, result.ContentIsEmpty())
+		assert.Same(t, success, result.Success)
+		assert.Equal(t, 1, success.ID)
+	})
+
+	t.Run("whitespace is not empty", func(t *testing.T) {
+		assert.False(t, (&Response{Body: []byte(" ")}).ContentIsEmpty())
+	})
+}
+
+func TestIsJSON(t *testing.T) {
+	cases := []struct {
+		contentType string
+		expected    bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"Application/JSON", true},
+		{"application/vnd.api+json", true},
+		{"application/problem+json; charset=utf-8", true},
+		{"text/html", false},
+		{"text/html; charset=utf-8", false},
+		{"application/jsonp", false},
+		{"", false},
+	}
+
+	for _, c := range cases {
+		resp := &Response{Header: http.Header{"Content-Type": {c.contentType}}}
+		assert.Equal(t, c.expected, resp.IsJSON(), c.contentType)
+	}
+
+	assert.False(t, (&Response{Header: make(http.Header)}).IsJSON())
+}
+
+func TestCopyHeaders(t *testing.T) {
+	resp := &Response{Header: http.Header{
+		"X-Request-Id": {"abc"},
+		"X-Trace":      {"1", "2"},
+		"Content-Type": {"application/json"},
+	}}
+
+	assert.Equal(t, map[string]string{
+		"x-request-id": "abc",
+		"X-Trace":      "1, 2",
+	}, resp.CopyHeaders("x-request-id", "X-Trace", "X-Missing"))
+	assert.Empty(t, resp.CopyHeaders())
+	assert.Empty(t, (&Response{}).CopyHeaders("X-Request-Id"))
+}
+
+func TestAsMap(t *testing.T) {
+	resp := &Response{Body: []byte(`{"user": {"id": 7, "roles": ["admin"], "profile": {"name": "John"}}, "active": true}`)}
+
+	m, err := resp.AsMap()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"user": map[string]interface{}{
+			"id":      float64(7),
+			"roles":   []interface{}{"admin"},
+			"profile": map[string]interface{}{"name": "John"},
+		},
+		"active": true,
+	}, m)
+
+	for _, body := range []string{`[1, 2]`, `"text"`, `null`, `<html>`} {
+		m, err := (&Response{Body: []byte(body)}).AsMap()
+		assert.Nil(t, m, body)
+		assert.True(t, IsDecodeError(err), body)
+	}
+}
+
+type fakeRateLimitHeaders struct {
+	Limit     int      `header:"X-Rate-Limit-Limit"`
+	Remaining int64    `header:"X-Rate-Limit-Remaining"`
+	Reset     uint     `header:"X-Rate-Limit-Reset"`
+	Cost      float64  `header:"X-Request-Cost"`
+	Cached    bool     `header:"X-Cached"`
+	RequestID string   `header:"X-Request-Id"`
+	Links     []string `header:"Link"`
+	Missing   int      `header:"X-Missing"`
+	Ignored   string
+}
+
+func TestBindHeaders(t *testing.T) {
+	r := newMockRequest(fakeHandler(200, `{}`, map[string]string{
+		"X-Rate-Limit-Limit":     "100",
+		"X-Rate-Limit-Remaining": "42",
+		"X-Rate-Limit-Reset":     "1622548800",
+		"X-Request-Cost":         "1.5",
+		"X-Cached":               "true",
+		"X-Request-Id":           "abc-123",
+		"Link":                   "</page/2>; rel=\"next\"",
+	}))
+
+	result, err := r.Get("http://example.com").Execute()
+	assert.Nil(t, err)
+
+	headers := fakeRateLimitHeaders{Missing: 7}
+	assert.Nil(t, result.BindHeaders(&headers))
+
+	expected := fakeRateLimitHeaders{
+		Limit:     100,
+		Remaining: 42,
+		Reset:     1622548800,
+		Cost:      1.5,
+		Cached:    true,
+		RequestID: "abc-123",
+		Links:     []string{"</page/2>; rel=\"next\""},
+		Missing:   7,
+	}
+	assert.Equal(t, expected, headers)
+}
+
+func TestBindHeadersErrors(t *testing.T) {
+	resp := &Response{Header: http.Header{"X-Rate-Limit-Remaining": {"lots"}}}
+
+	err := resp.BindHeaders(&fakeRateLimitHeaders{})
+	assert.EqualError(t, err, `failed to bind header "X-Rate-Limit-Remaining": "lots" is not a valid int64`)
+
+	assert.NotNil(t, resp.BindHeaders(fakeRateLimitHeaders{}))
+	assert.NotNil(t, resp.BindHeaders(nil))
+}
+
+func TestResponseErr(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(201, `{}`, nil))
+
+		result, err := r.Post("http://example.com").Execute()
+		assert.Nil(t, err)
+		assert.Nil(t, result.Err())
+	})
+
+	t.Run("failure struct", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(404, `{"id":7, "name":"not found"}`, nil))
+
+		result, err := r.Get("http://example.com").SetFailure(&fakeSuccess{}).Execute()
+		assert.Nil(t, err)
+
+		var httpErr *HTTPError
+		assert.True(t, errors.As(result.Err(), &httpErr))
+		assert.Equal(t, 404, httpErr.StatusCode)
+		assert.Equal(t, &fakeSuccess{ID: 7, Name: "not found"}, httpErr.Failure)
+		assert.EqualError(t, httpErr, `request: failed with status 404 Not Found: {"id":7, "name":"not found"}`)
+	})
+
+	t.Run("raw body", func(t *testing.T) {
+		r := newMockRequest(fakeHandler(502, `upstream unavailable`, nil))
+
+		result, err := r.Get("http://example.com").Execute()
+		assert.Nil(t, err)
+
+		var httpErr *HTTPError
+		assert.True(t, errors.As(result.Err(), &httpErr))
+		assert.Nil(t, httpErr.Failure)
+		assert.Equal(t, []byte(`upstream unavailable`), httpErr.Body)
+		assert.EqualError(t, httpErr, `request: failed with status 502 Bad Gateway: upstream unavailable`)
+	})
+}
@@ -0,0 +1,227 @@
+package request
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultMaxRetryAfter = time.Minute
+
+//RetryPolicy decides whether another attempt should be made.
+//resp is nil when the attempt failed before a response was received, in which case err holds the transport error.
+//attempt is the number of attempts made so far, starting at 1
+type RetryPolicy func(resp *Response, err error, attempt int) bool
+
+//DefaultRetryPolicy retries transport errors, 429 Too Many Requests and 5xx responses.
+//When no policy is set, POST and PATCH requests are only retried if it is safe to do so, see RetryNonIdempotent
+func DefaultRetryPolicy(resp *Response, err error, attempt int) bool {
+	if resp == nil {
+		return err != nil
+	}
+
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+//SetRetry enables retries for the request. count is the maximum number of retries after the first attempt
+//and wait is the delay before the first retry, doubled on every subsequent retry unless a backoff strategy is set
+func (r *Request) SetRetry(count int, wait time.Duration) *Request {
+	if r == nil {
+		return nil
+	}
+	r.retries = count
+	r.wait = wait
+	return r
+}
+
+//RetryOnStatus caps how many times a response with the given status is retried, overriding the count set
+//with SetRetry for that status. Retries of other statuses and errors do not count towards the cap.
+//Whether the status is retried at all is still decided by the retry policy
+func (r *Request) RetryOnStatus(status int, maxRetries int) *Request {
+	if r == nil {
+		return nil
+	}
+	statusRetries := make(map[int]int, len(r.statusRetries)+1)
+	for code, max := range r.statusRetries {
+		statusRetries[code] = max
+	}
+	statusRetries[status] = maxRetries
+	r.statusRetries = statusRetries
+	return r
+}
+
+//retriesLeft reports whether another attempt may be made after resp, counting retries per status
+//for statuses with a cap set with RetryOnStatus and overall otherwise
+func (r *Request) retriesLeft(resp *Response, attempt int, statusRetries map[int]int) bool {
+	if resp != nil {
+		if max, ok := r.statusRetries[resp.StatusCode]; ok {
+			return statusRetries[resp.StatusCode] < max
+		}
+	}
+	return attempt <= r.retries
+}
+
+//RetryNonIdempotent allows POST and PATCH requests to be retried like idempotent methods when allow is true.
+//By default they are only retried when the connection could not be established,
+//unless the request carries an Idempotency-Key header
+func (r *Request) RetryNonIdempotent(allow bool) *Request {
+	if r == nil {
+		return nil
+	}
+	r.anyMethod = allow
+	return r
+}
+
+//SetRetryPolicy replaces the default retry policy. The policy is consulted after every attempt
+//and returning false stops retrying immediately
+func (r *Request) SetRetryPolicy(policy RetryPolicy) *Request {
+	if r == nil {
+		return nil
+	}
+	r.policy = policy
+	return r
+}
+
+//defaultRetryPolicy is DefaultRetryPolicy guarded against retrying responses and transport errors
+//of non-idempotent requests that may have been applied on the server
+func (r *Request) defaultRetryPolicy(resp *Response, err error, attempt int) bool {
+	if resp != nil && !r.retrySafe() {
+		return false
+	}
+	if resp == nil && err != nil && !r.canRetryTransportError(err) {
+		return false
+	}
+
+	return DefaultRetryPolicy(resp, err, attempt)
+}
+
+//canRetryTransportError reports whether a request that failed with err can be safely sent again
+func (r *Request) canRetryTransportError(err error) bool {
+	return r.retrySafe() || isPreflightError(err)
+}
+
+//retrySafe reports whether the request can be sent again after it may have reached the server,
+//either because its method is idempotent, retrying was opted into or it carries an idempotency key
+func (r *Request) retrySafe() bool {
+	return isIdempotent(r.method) || r.anyMethod || r.idemKey != "" || r.autoIdem || r.header.Get(idempotencyKeyHeader) != ""
+}
+
+//isIdempotent reports whether sending a request with the given method more than once has the same effect as sending it once
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+//isPreflightError reports whether err happened while dialing, before any of the request was written
+func isPreflightError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+//SetRetryBudget bounds the total time spent on a request and its retries. Time spent in attempts counts towards the budget
+//but attempts are never cut short by it, use SetTimeout for that. Before every retry, the delay computed by the backoff
+//strategy or taken from a Retry-After header is checked against the budget and the context deadline,
+//and when sleeping would exceed either no more attempts are made and a *RetryBudgetError is returned
+func (r *Request) SetRetryBudget(maxElapsed time.Duration) *Request {
+	if r == nil {
+		return nil
+	}
+	r.budget = maxElapsed
+	return r
+}
+
+//SetMaxRetryAfter caps how long a Retry-After header may delay the next retry. Defaults to one minute
+func (r *Request) SetMaxRetryAfter(max time.Duration) *Request {
+	if r == nil {
+		return nil
+	}
+	r.maxWait = max
+	return r
+}
+
+//retryDelay returns how long to wait before the next attempt.
+//A Retry-After header on 429 and 503 responses takes precedence over the backoff strategy
+func (r *Request) retryDelay(resp *Response, attempt int) time.Duration {
+	if resp != nil && (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable) {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), r.now()); ok {
+			max := r.maxWait
+			if max == 0 {
+				max = defaultMaxRetryAfter
+			}
+			if delay > max {
+				delay = max
+			}
+			return delay
+		}
+	}
+
+	backoff := r.backoff
+	if backoff == nil {
+		backoff = ExponentialBackoff{Base: r.wait}
+	}
+
+	delay := backoff.Next(attempt)
+	if r.maxBackoff > 0 && delay > r.maxBackoff {
+		delay = r.maxBackoff
+	}
+	return applyJitter(r.jitter, r.jitterRand, delay)
+}
+
+//checkBudget returns a *RetryBudgetError when sleeping for delay would outlast the retry budget or the context deadline.
+//start is when the first attempt was made and err is the error of the last attempt
+func (r *Request) checkBudget(start time.Time, attempts int, delay time.Duration, err error) error {
+	now := r.now()
+	budgetErr := &RetryBudgetError{
+		Attempts: attempts,
+		Elapsed:  now.Sub(start),
+		Delay:    delay,
+		Err:      err,
+	}
+
+	if deadline, ok := r.context().Deadline(); ok && now.Add(delay).After(deadline) {
+		budgetErr.deadline = true
+		return budgetErr
+	}
+	if r.budget > 0 && budgetErr.Elapsed+delay > r.budget {
+		return budgetErr
+	}
+	return nil
+}
+
+//sleep waits for the given delay unless the request context is done first
+func (r *Request) sleep(delay time.Duration) error {
+	select {
+	case <-r.getClock().After(delay):
+		return nil
+	case <-r.context().Done():
+		return r.context().Err()
+	}
+}
+
+//parseRetryAfter parses a Retry-After header in either delta-seconds or HTTP-date form
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
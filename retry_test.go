@@ -0,0 +1,486 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/AidenHadisi/go-simple-request/requesttest"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRetryable struct {
+	Retryable bool `json:"retryable"`
+}
+
+type errClient struct {
+	calls int
+	err   error
+}
+
+func (c *errClient) Do(req *http.Request) (*http.Response, error) {
+	c.calls++
+	return nil, c.err
+}
+
+//countingHandler responds with the given status codes in order, repeating the last one
+func countingHandler(calls *int, statusCodes []int, json string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := statusCodes[len(statusCodes)-1]
+		if *calls < len(statusCodes) {
+			status = statusCodes[*calls]
+		}
+		*calls++
+
+		w.WriteHeader(status)
+		w.Write([]byte(json))
+	}
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	cases := []struct {
+		resp     *Response
+		err      error
+		expected bool
+	}{
+		{nil, errors.New("connection reset"), true},
+		{&Response{StatusCode: 200}, nil, false},
+		{&Response{StatusCode: 404}, nil, false},
+		{&Response{StatusCode: 429}, nil, true},
+		{&Response{StatusCode: 500}, nil, true},
+		{&Response{StatusCode: 503}, nil, true},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, DefaultRetryPolicy(c.resp, c.err, 1))
+	}
+}
+
+func TestRetryDefaults(t *testing.T) {
+	t.Run("retries server errors", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{500, 502, 200}, `{}`))
+
+		result, err := r.Get("http://example.com").SetRetry(3, 0).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("gives up after count", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{503}, `{}`))
+
+		result, err := r.Get("http://example.com").SetRetry(2, 0).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 503, result.StatusCode)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("retries transport errors", func(t *testing.T) {
+		client := &errClient{err: errors.New("connection refused")}
+		r := &Request{client: client}
+
+		_, err := r.Get("http://example.com").SetRetry(2, 0).Execute()
+		assert.NotNil(t, err)
+		assert.Equal(t, 3, client.calls)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{500}, `{}`))
+
+		_, err := r.Get("http://example.com").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestRetryPolicyFromBody(t *testing.T) {
+	policy := func(resp *Response, err error, attempt int) bool {
+		if resp == nil {
+			return false
+		}
+		failure, ok := resp.Failure.(*fakeRetryable)
+		return ok && failure.Retryable
+	}
+
+	t.Run("retryable", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{400}, `{"retryable": true}`))
+
+		_, err := r.Get("http://example.com").SetFailure(&fakeRetryable{}).SetRetry(2, 0).SetRetryPolicy(policy).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("not retryable 500 stops immediately", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{500}, `{"retryable": false}`))
+
+		result, err := r.Post("http://example.com").SetFailure(&fakeRetryable{}).SetRetry(2, 0).SetRetryPolicy(policy).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 500, result.StatusCode)
+		assert.Equal(t, `{"retryable": false}`, string(result.Body))
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestRetryPolicyOverride(t *testing.T) {
+	policy := func(resp *Response, err error, attempt int) bool {
+		return resp != nil && resp.StatusCode == http.StatusNotFound
+	}
+
+	calls := 0
+	r := newMockRequest(countingHandler(&calls, []int{404, 404, 200}, `{}`))
+
+	result, err := r.Get("http://example.com").SetRetry(5, 0).SetRetryPolicy(policy).Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, 3, calls)
+}
+
+func TestRetryOnStatus(t *testing.T) {
+	cases := []struct {
+		name     string
+		statuses []int
+		calls    int
+		status   int
+	}{
+		{"429 capped at 5", []int{429}, 6, 429},
+		{"503 capped at 2", []int{503}, 3, 503},
+		{"caps counted per status", []int{429, 429, 503, 503, 503, 200}, 5, 503},
+		{"other statuses use the global count", []int{500}, 2, 500},
+		{"success after capped retries", []int{429, 429, 429, 200}, 4, 200},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			calls := 0
+			r := newMockRequest(countingHandler(&calls, c.statuses, `{}`)).SetClock(newFakeClock())
+
+			result, err := r.Get("http://example.com").SetRetry(1, time.Second).
+				RetryOnStatus(http.StatusTooManyRequests, 5).
+				RetryOnStatus(http.StatusServiceUnavailable, 2).
+				Execute()
+
+			assert.Nil(t, err)
+			assert.Equal(t, c.status, result.StatusCode)
+			assert.Equal(t, c.calls, calls)
+		})
+	}
+
+	t.Run("policy still applies", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{404}, `{}`))
+
+		result, err := r.Get("http://example.com").RetryOnStatus(http.StatusNotFound, 3).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 404, result.StatusCode)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"", 0, false},
+		{"120", 2 * time.Minute, true},
+		{"0", 0, true},
+		{"-5", 0, false},
+		{"Tue, 01 Jun 2021 12:00:30 GMT", 30 * time.Second, true},
+		{"Tue, 01 Jun 2021 11:59:00 GMT", 0, true},
+		{"soon", 0, false},
+	}
+
+	for _, c := range cases {
+		delay, ok := parseRetryAfter(c.value, now)
+		assert.Equal(t, c.ok, ok, c.value)
+		assert.Equal(t, c.expected, delay, c.value)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	retryAfterHandler := func(calls *int, retryAfter string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			*calls++
+			if *calls == 1 {
+				w.Header().Set("Retry-After", retryAfter)
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+
+	t.Run("delta seconds", func(t *testing.T) {
+		calls := 0
+		clock := newFakeClock()
+		start := clock.Now()
+		r := newMockRequest(retryAfterHandler(&calls, "1")).SetClock(clock)
+
+		result, err := r.Get("http://example.com").SetRetry(1, 0).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, 2, calls)
+		assert.Equal(t, time.Second, clock.Now().Sub(start))
+	})
+
+	t.Run("http date", func(t *testing.T) {
+		calls := 0
+		clock := newFakeClock()
+		start := clock.Now()
+		date := start.Add(time.Hour).UTC().Format(http.TimeFormat)
+		r := newMockRequest(retryAfterHandler(&calls, date)).SetClock(clock)
+
+		result, err := r.Get("http://example.com").SetRetry(1, 0).SetMaxRetryAfter(20 * time.Millisecond).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, 20*time.Millisecond, clock.Now().Sub(start))
+	})
+
+	t.Run("capped", func(t *testing.T) {
+		calls := 0
+		clock := newFakeClock()
+		start := clock.Now()
+		r := newMockRequest(retryAfterHandler(&calls, "3600")).SetClock(clock)
+
+		result, err := r.Get("http://example.com").SetRetry(1, 0).SetMaxRetryAfter(10 * time.Millisecond).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, 10*time.Millisecond, clock.Now().Sub(start))
+	})
+
+	t.Run("exceeds context deadline", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(retryAfterHandler(&calls, "10"))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		result, err := r.Get("http://example.com").SetContext(ctx).SetRetry(1, 0).Execute()
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "exceeds context deadline")
+		assert.Equal(t, 503, result.StatusCode)
+		assert.Equal(t, 1, calls)
+		assert.Less(t, int64(time.Since(start)), int64(50*time.Millisecond))
+	})
+}
+
+func TestCanRetryTransportError(t *testing.T) {
+	refused := &url.Error{Op: "Post", URL: "http://example.com", Err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}}
+	reset := &url.Error{Op: "Post", URL: "http://example.com", Err: &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}}
+	eof := &url.Error{Op: "Post", URL: "http://example.com", Err: io.EOF}
+
+	cases := []struct {
+		method   string
+		err      error
+		expected bool
+	}{
+		{"GET", refused, true},
+		{"GET", reset, true},
+		{"GET", eof, true},
+		{"HEAD", reset, true},
+		{"PUT", reset, true},
+		{"DELETE", eof, true},
+		{"OPTIONS", eof, true},
+		{"POST", refused, true},
+		{"POST", reset, false},
+		{"POST", eof, false},
+		{"PATCH", refused, true},
+		{"PATCH", reset, false},
+		{"PATCH", eof, false},
+	}
+
+	for _, c := range cases {
+		r := New()
+		r.method = c.method
+		assert.Equal(t, c.expected, r.canRetryTransportError(c.err), "%s %v", c.method, c.err)
+	}
+
+	t.Run("opt in", func(t *testing.T) {
+		assert.True(t, New().Post("http://example.com").RetryNonIdempotent(true).canRetryTransportError(reset))
+		assert.True(t, New().Patch("http://example.com").RetryNonIdempotent(true).canRetryTransportError(eof))
+	})
+
+	t.Run("idempotency key", func(t *testing.T) {
+		assert.True(t, New().Post("http://example.com").SetHeader("Idempotency-Key", "abc").canRetryTransportError(reset))
+	})
+}
+
+func TestRetryNonIdempotent(t *testing.T) {
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+	t.Run("post not retried", func(t *testing.T) {
+		client := &errClient{err: reset}
+		r := &Request{client: client, header: make(http.Header)}
+
+		_, err := r.Post("http://example.com").SetRetry(2, 0).Execute()
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, client.calls)
+	})
+
+	t.Run("post opted in", func(t *testing.T) {
+		client := &errClient{err: reset}
+		r := &Request{client: client, header: make(http.Header)}
+
+		_, err := r.Post("http://example.com").SetRetry(2, 0).RetryNonIdempotent(true).Execute()
+		assert.NotNil(t, err)
+		assert.Equal(t, 3, client.calls)
+	})
+
+	t.Run("post server error not retried", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{503, 200}, `{}`))
+
+		result, err := r.Post("http://example.com").SetRetry(2, 0).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 503, result.StatusCode)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("post server error opted in", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{503, 200}, `{}`))
+
+		result, err := r.Post("http://example.com").SetRetry(2, 0).RetryNonIdempotent(true).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("post server error with idempotency key", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{503, 200}, `{}`))
+
+		result, err := r.Post("http://example.com").SetRetry(2, 0).SetIdempotencyKey("abc").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("put server error retried", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{503, 200}, `{}`))
+
+		result, err := r.Put("http://example.com").SetRetry(2, 0).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, 2, calls)
+	})
+}
+
+//newFakeClock returns a clock advanced by sleeps instead of waiting for them
+func newFakeClock() *requesttest.FakeClock {
+	return requesttest.NewFakeClock(time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)).SetAutoAdvance(true)
+}
+
+func TestRetryBudget(t *testing.T) {
+	t.Run("stops before a sleep it cannot finish", func(t *testing.T) {
+		calls := 0
+		clock := newFakeClock()
+		r := newMockRequest(countingHandler(&calls, []int{500}, `{}`))
+		r.SetClock(clock)
+
+		result, err := r.Get("http://example.com").SetRetry(10, 0).SetBackoff(ConstantBackoff{3 * time.Second}).SetRetryBudget(10 * time.Second).Execute()
+
+		var budgetErr *RetryBudgetError
+		assert.True(t, errors.As(err, &budgetErr))
+		assert.True(t, errors.Is(err, ErrRetryBudgetExceeded))
+		assert.Equal(t, 500, result.StatusCode)
+		assert.Equal(t, 4, calls)
+		assert.Equal(t, 4, budgetErr.Attempts)
+		assert.Equal(t, 9*time.Second, budgetErr.Elapsed)
+		assert.Nil(t, budgetErr.Err)
+		assert.EqualError(t, err, "request: retry delay of 3s exceeds retry budget after 4 attempts in 9s")
+	})
+
+	t.Run("attempt duration counts", func(t *testing.T) {
+		calls := 0
+		clock := newFakeClock()
+		r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			calls++
+			clock.Advance(4 * time.Second)
+			w.WriteHeader(http.StatusBadGateway)
+		})
+		r.SetClock(clock)
+
+		_, err := r.Get("http://example.com").SetRetry(10, 0).SetBackoff(ConstantBackoff{time.Second}).SetRetryBudget(10 * time.Second).Execute()
+
+		var budgetErr *RetryBudgetError
+		assert.True(t, errors.As(err, &budgetErr))
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 14*time.Second, budgetErr.Elapsed)
+	})
+
+	t.Run("retry after counts against budget", func(t *testing.T) {
+		calls := 0
+		clock := newFakeClock()
+		r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			calls++
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+		r.SetClock(clock)
+
+		result, err := r.Get("http://example.com").SetRetry(3, 0).SetRetryBudget(10 * time.Second).Execute()
+
+		assert.True(t, errors.Is(err, ErrRetryBudgetExceeded))
+		assert.Equal(t, 429, result.StatusCode)
+		assert.Equal(t, 1, calls)
+		assert.Contains(t, err.Error(), "request: retry delay of 30s exceeds retry budget after 1 attempts")
+	})
+
+	t.Run("last error is annotated", func(t *testing.T) {
+		clock := newFakeClock()
+		refused := errors.New("connection refused")
+		client := &errClient{err: refused}
+		r := &Request{client: client, clock: clock}
+
+		_, err := r.Get("http://example.com").SetRetry(10, time.Second).SetRetryBudget(5 * time.Second).Execute()
+
+		assert.True(t, errors.Is(err, refused))
+		assert.True(t, errors.Is(err, ErrRetryBudgetExceeded))
+		assert.Equal(t, 3, client.calls)
+		assert.EqualError(t, err, "request: retry delay of 4s exceeds retry budget after 3 attempts in 3s: connection refused")
+	})
+
+	t.Run("context deadline", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{503}, `{}`))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		_, err := r.Get("http://example.com").SetContext(ctx).SetRetry(3, 2*time.Second).SetRetryBudget(time.Minute).Execute()
+
+		var budgetErr *RetryBudgetError
+		assert.True(t, errors.As(err, &budgetErr))
+		assert.True(t, errors.Is(err, context.DeadlineExceeded))
+		assert.False(t, errors.Is(err, ErrRetryBudgetExceeded))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("within budget", func(t *testing.T) {
+		calls := 0
+		r := newMockRequest(countingHandler(&calls, []int{500, 500, 200}, `{}`))
+		r.SetClock(newFakeClock())
+
+		result, err := r.Get("http://example.com").SetRetry(3, time.Second).SetRetryBudget(10 * time.Second).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, 3, calls)
+	})
+}
@@ -0,0 +1,75 @@
+package request
+
+import (
+	"sync"
+	"time"
+)
+
+//RetryTokens caps the total number of retries across every request sharing it, so a wide outage does not turn
+//into a retry storm. Every retry takes a token and retrying stops once none are left. Tokens are refilled one
+//at a time at the refill interval, if any, up to the maximum. It is safe for concurrent use
+type RetryTokens struct {
+	mu        sync.Mutex
+	max       int
+	remaining int
+	refill    time.Duration
+	last      time.Time
+}
+
+//NewRetryTokens returns a budget of maxTotal retries, refilled by one every refillEvery. A refillEvery of zero
+//never refills the budget, see Reset
+func NewRetryTokens(maxTotal int, refillEvery time.Duration) *RetryTokens {
+	return &RetryTokens{max: maxTotal, remaining: maxTotal, refill: refillEvery}
+}
+
+//Remaining returns the number of retries left
+func (b *RetryTokens) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.remaining
+}
+
+//Reset refills the budget to its maximum
+func (b *RetryTokens) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remaining = b.max
+	b.last = time.Time{}
+}
+
+//take takes a token, refilling the tokens earned since the last refill first, and reports whether one was left
+func (b *RetryTokens) take(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.refill > 0 && !b.last.IsZero() {
+		if earned := int(now.Sub(b.last) / b.refill); earned > 0 {
+			b.remaining += earned
+			b.last = b.last.Add(time.Duration(earned) * b.refill)
+			if b.remaining >= b.max {
+				b.remaining = b.max
+				b.last = time.Time{}
+			}
+		}
+	}
+	if b.remaining <= 0 {
+		return false
+	}
+
+	b.remaining--
+	if b.last.IsZero() {
+		b.last = now
+	}
+	return true
+}
+
+//SetRetryTokens shares a budget of retries with every request using tokens, including requests created
+//from this one with New. Once the budget is exhausted failed attempts are no longer retried and the result
+//of the last attempt is returned as is. See SetRetryBudget to bound the time spent retrying a single request
+func (r *Request) SetRetryTokens(tokens *RetryTokens) *Request {
+	if r == nil {
+		return nil
+	}
+	r.retryTokens = tokens
+	return r
+}
@@ -0,0 +1,56 @@
+package request
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetRetryTokens(t *testing.T) {
+	calls := 0
+	tokens := NewRetryTokens(4, 0)
+	template := newMockRequest(countingHandler(&calls, []int{503}, `{}`)).
+		SetRetry(3, 0).
+		SetClock(newFakeClock()).
+		SetRetryTokens(tokens)
+
+	var attempts []int
+	for i := 0; i < 3; i++ {
+		before := calls
+		result, err := template.New().Get("http://example.com").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 503, result.StatusCode)
+		attempts = append(attempts, calls-before)
+	}
+	assert.Equal(t, []int{4, 2, 1}, attempts)
+	assert.Equal(t, 0, tokens.Remaining())
+
+	t.Run("reset", func(t *testing.T) {
+		tokens.Reset()
+		assert.Equal(t, 4, tokens.Remaining())
+
+		before := calls
+		_, err := template.New().Get("http://example.com").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 4, calls-before)
+		assert.Equal(t, 1, tokens.Remaining())
+	})
+}
+
+func TestRetryTokensRefill(t *testing.T) {
+	now := time.Date(2021, time.June, 1, 12, 0, 0, 0, time.UTC)
+	tokens := NewRetryTokens(2, time.Minute)
+
+	assert.True(t, tokens.take(now))
+	assert.True(t, tokens.take(now.Add(10*time.Second)))
+	assert.False(t, tokens.take(now.Add(30*time.Second)))
+
+	//one token is earned a minute after the first was taken
+	assert.True(t, tokens.take(now.Add(time.Minute)))
+	assert.False(t, tokens.take(now.Add(90*time.Second)))
+
+	//refills stop at the maximum
+	assert.True(t, tokens.take(now.Add(time.Hour)))
+	assert.Equal(t, 1, tokens.Remaining())
+}
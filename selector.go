@@ -0,0 +1,61 @@
+package request
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+//HostSelector picks which of n base URLs a request is sent to first. It must be safe for concurrent use
+type HostSelector interface {
+	Next(n int) int
+}
+
+//RoundRobin returns a selector cycling through the base URLs in order
+func RoundRobin() HostSelector {
+	return &roundRobinSelector{}
+}
+
+//Random returns a selector picking base URLs at random. rnd may be nil to use the default source
+func Random(rnd *rand.Rand) HostSelector {
+	return &randomSelector{rnd: rnd}
+}
+
+type roundRobinSelector struct {
+	next uint64
+}
+
+func (s *roundRobinSelector) Next(n int) int {
+	return int((atomic.AddUint64(&s.next, 1) - 1) % uint64(n))
+}
+
+type randomSelector struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func (s *randomSelector) Next(n int) int {
+	if s.rnd == nil {
+		return rand.Intn(n)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rnd.Intn(n)
+}
+
+//SetHostSelector spreads requests across the base URLs set with SetBaseURLs using selector.
+//Base URLs that failed within the probe interval are only tried after the healthy ones.
+//It may be called before or after SetBaseURLs. Called after, the request gets its own copy of the base URLs and
+//their state, so requests it shares them with are not affected
+func (r *Request) SetHostSelector(selector HostSelector) *Request {
+	if r == nil {
+		return nil
+	}
+	r.selector = selector
+	if r.endpoints != nil {
+		r.endpoints = r.endpoints.clone()
+		r.endpoints.selector = selector
+	}
+	return r
+}
@@ -0,0 +1,98 @@
+package request
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCountingServers(n int, counts []int32) ([]*httptest.Server, []string) {
+	servers := make([]*httptest.Server, n)
+	urls := make([]string, n)
+	for i := range servers {
+		i := i
+		servers[i] = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&counts[i], 1)
+		}))
+		urls[i] = servers[i].URL
+	}
+	return servers, urls
+}
+
+func TestRoundRobin(t *testing.T) {
+	counts := make([]int32, 3)
+	servers, urls := newCountingServers(3, counts)
+	for _, server := range servers {
+		defer server.Close()
+	}
+
+	template := New().SetBaseURLs(urls...).SetHostSelector(RoundRobin())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := template.New().Get("/").Execute()
+			assert.Nil(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, []int32{10, 10, 10}, counts)
+}
+
+func TestRandomSelector(t *testing.T) {
+	counts := make([]int32, 3)
+	servers, urls := newCountingServers(3, counts)
+	for _, server := range servers {
+		defer server.Close()
+	}
+
+	template := New().SetBaseURLs(urls...).SetHostSelector(Random(rand.New(rand.NewSource(1))))
+	for i := 0; i < 30; i++ {
+		_, err := template.New().Get("/").Execute()
+		assert.Nil(t, err)
+	}
+
+	for _, count := range counts {
+		assert.InDelta(t, 10, count, 6)
+	}
+	assert.Equal(t, int32(30), counts[0]+counts[1]+counts[2])
+}
+
+func TestSelectorSkipsUnhealthy(t *testing.T) {
+	counts := make([]int32, 3)
+	servers, urls := newCountingServers(3, counts)
+	for _, server := range servers[1:] {
+		defer server.Close()
+	}
+	servers[0].Close()
+
+	template := New().SetBaseURLs(urls...).SetHostSelector(RoundRobin())
+	for i := 0; i < 30; i++ {
+		result, err := template.New().Get("/").Execute()
+		assert.Nil(t, err)
+		assert.NotEqual(t, urls[0], result.Endpoint)
+	}
+
+	assert.Equal(t, int32(0), counts[0])
+	assert.Equal(t, int32(30), counts[1]+counts[2])
+	assert.InDelta(t, 15, counts[1], 2)
+}
+
+func TestHostSelectorNotShared(t *testing.T) {
+	template := New().SetBaseURLs("http://a", "http://b")
+	sibling := template.New()
+	selector := RoundRobin()
+	derived := template.New().SetHostSelector(selector)
+
+	assert.Equal(t, selector, derived.endpoints.selector)
+	assert.Nil(t, template.endpoints.selector)
+	assert.Nil(t, sibling.endpoints.selector)
+}
@@ -0,0 +1,111 @@
+package request
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+//HMACConfig configures HMAC request signing, see SignHMAC
+type HMACConfig struct {
+	//Secret is the HMAC key
+	Secret []byte
+	//Hash is the hash function, defaults to sha256.New
+	Hash func() hash.Hash
+	//Header receives the hex encoded signature
+	Header string
+	//TimestampHeader, if set, receives the Unix timestamp in seconds included in the signature
+	TimestampHeader string
+	//StringToSign assembles the signed message from the final request, the exact body bytes sent and the signing time.
+	//Defaults to the Unix timestamp in seconds, method, escaped path and body concatenated
+	StringToSign func(req *http.Request, body []byte, timestamp time.Time) []byte
+}
+
+//SignHMAC signs every attempt with an HMAC of the message assembled by cfg.StringToSign, computed once the body
+//is marshaled and the query encoded. The body is buffered if it is streamed so the exact bytes sent are signed
+func (r *Request) SignHMAC(cfg HMACConfig) *Request {
+	if r == nil {
+		return nil
+	}
+	if cfg.Hash == nil {
+		cfg.Hash = sha256.New
+	}
+	if cfg.StringToSign == nil {
+		cfg.StringToSign = defaultStringToSign
+	}
+	r.hmac = &cfg
+	return r
+}
+
+//SetHMACSigner signs every attempt with a hex encoded HMAC-SHA256 of the method, path and body
+//separated by newlines, set in the headerName header. The signature is computed over the exact bytes sent
+func (r *Request) SetHMACSigner(secret []byte, headerName string) *Request {
+	return r.SignHMAC(HMACConfig{
+		Secret: secret,
+		Header: headerName,
+		StringToSign: func(req *http.Request, body []byte, timestamp time.Time) []byte {
+			return append([]byte(req.Method+"\n"+req.URL.EscapedPath()+"\n"), body...)
+		},
+	})
+}
+
+func defaultStringToSign(req *http.Request, body []byte, timestamp time.Time) []byte {
+	return append([]byte(strconv.FormatInt(timestamp.Unix(), 10)+req.Method+req.URL.EscapedPath()), body...)
+}
+
+//sign adds the HMAC signature header to req
+func (r *Request) sign(req *http.Request) error {
+	if r.hmac == nil {
+		return nil
+	}
+
+	body, err := bodyBytes(req)
+	if err != nil {
+		return err
+	}
+
+	timestamp := r.now()
+	if r.hmac.TimestampHeader != "" {
+		req.Header.Set(r.hmac.TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	}
+
+	mac := hmac.New(r.hmac.Hash, r.hmac.Secret)
+	mac.Write(r.hmac.StringToSign(req, body, timestamp))
+	req.Header.Set(r.hmac.Header, hex.EncodeToString(mac.Sum(nil)))
+
+	return nil
+}
+
+//bodyBytes returns the body of req without consuming it, buffering streamed bodies if needed
+func bodyBytes(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
@@ -0,0 +1,147 @@
+package request
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AidenHadisi/go-simple-request/requesttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func signatureRecorder(signatures, bodies *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		if r.Body != nil {
+			body, _ = ioutil.ReadAll(r.Body)
+		}
+		*signatures = append(*signatures, r.Header.Get("X-Signature"))
+		*bodies = append(*bodies, string(body))
+	}
+}
+
+func TestSetHMACSigner(t *testing.T) {
+	cases := []struct {
+		name     string
+		request  func(r *Request) *Request
+		body     string
+		expected string
+	}{
+		{
+			"json body",
+			func(r *Request) *Request {
+				return r.Post("http://example.com/v1/orders").SetBody(&fakeSuccess{ID: 1, Name: "Bob"})
+			},
+			`{"ID":1,"Name":"Bob"}`,
+			"c6b635dfd2cab6b17e2dd12274720b077a4e516551efda3a6f14549fd55ac854",
+		},
+		{
+			"streamed body",
+			func(r *Request) *Request {
+				body := io.MultiReader(strings.NewReader(`{"ID":1,`), strings.NewReader(`"Name":"Bob"}`))
+				return r.Post("http://example.com/v1/orders").SetBody(body)
+			},
+			`{"ID":1,"Name":"Bob"}`,
+			"c6b635dfd2cab6b17e2dd12274720b077a4e516551efda3a6f14549fd55ac854",
+		},
+		{
+			"no body",
+			func(r *Request) *Request { return r.Get("http://example.com/v1/orders?page=2") },
+			``,
+			"d18ce3dbdf315f53d6d1da3f323e9ec6a33af2af9092248b054de2b98d6ce46e",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var signatures, bodies []string
+			r := newMockRequest(signatureRecorder(&signatures, &bodies)).SetHMACSigner([]byte("secret"), "X-Signature")
+
+			_, err := c.request(r).Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, []string{c.expected}, signatures)
+			assert.Equal(t, []string{c.body}, bodies)
+		})
+	}
+}
+
+func TestHMACSignerUnset(t *testing.T) {
+	var signatures, bodies []string
+	r := newMockRequest(signatureRecorder(&signatures, &bodies))
+
+	_, err := r.Post("http://example.com/v1/orders").SetBody(&fakeSuccess{}).Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{""}, signatures)
+}
+
+//expectedHMAC computes a signature independently of the signer
+func expectedHMAC(h func() hash.Hash, secret, message string) string {
+	mac := hmac.New(h, []byte(secret))
+	mac.Write([]byte(message))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestSignHMAC(t *testing.T) {
+	clock := requesttest.NewFakeClock(time.Unix(1622548800, 0))
+
+	cases := []struct {
+		name     string
+		request  func(r *Request) *Request
+		config   HMACConfig
+		expected string
+	}{
+		{
+			"json body",
+			func(r *Request) *Request {
+				return r.Post("http://example.com/v1/hooks?attempt=1").SetBody(&fakeSuccess{ID: 1, Name: "Bob"})
+			},
+			HMACConfig{Secret: []byte("secret"), Header: "X-Signature", TimestampHeader: "X-Timestamp"},
+			expectedHMAC(sha256.New, "secret", `1622548800POST/v1/hooks{"ID":1,"Name":"Bob"}`),
+		},
+		{
+			"empty body",
+			func(r *Request) *Request { return r.Get("http://example.com/v1/hooks") },
+			HMACConfig{Secret: []byte("secret"), Header: "X-Signature", TimestampHeader: "X-Timestamp"},
+			expectedHMAC(sha256.New, "secret", `1622548800GET/v1/hooks`),
+		},
+		{
+			"custom hash and message",
+			func(r *Request) *Request {
+				return r.Put("http://example.com/v1/hooks/7?attempt=1").SetBody(strings.NewReader("payload"))
+			},
+			HMACConfig{
+				Secret: []byte("secret"),
+				Hash:   sha512.New,
+				Header: "X-Signature",
+				StringToSign: func(req *http.Request, body []byte, timestamp time.Time) []byte {
+					return []byte(timestamp.UTC().Format(time.RFC3339) + "." + req.URL.RequestURI() + "." + string(body))
+				},
+			},
+			expectedHMAC(sha512.New, "secret", "2021-06-01T12:00:00Z./v1/hooks/7?attempt=1.payload"),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var headers []http.Header
+			r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+				headers = append(headers, req.Header.Clone())
+			}).SetClock(clock).SignHMAC(c.config)
+
+			_, err := c.request(r).Execute()
+			assert.Nil(t, err)
+			assert.Equal(t, c.expected, headers[0].Get("X-Signature"))
+			if c.config.TimestampHeader != "" {
+				assert.Equal(t, "1622548800", headers[0].Get(c.config.TimestampHeader))
+			}
+		})
+	}
+}
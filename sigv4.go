@@ -0,0 +1,241 @@
+package request
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	awsV4Algorithm      = "AWS4-HMAC-SHA256"
+	awsV4TimeFormat     = "20060102T150405Z"
+	awsV4DateFormat     = "20060102"
+	awsUnsignedPayload  = "UNSIGNED-PAYLOAD"
+	awsDateHeader       = "X-Amz-Date"
+	awsContentSHAHeader = "X-Amz-Content-Sha256"
+	awsTokenHeader      = "X-Amz-Security-Token"
+)
+
+//AWSCredentials are the credentials used to sign requests with AWS Signature Version 4
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	//SessionToken is sent in the X-Amz-Security-Token header when set
+	SessionToken string
+}
+
+//CredentialsProvider provides the credentials used to sign every attempt, allowing them to be rotated
+type CredentialsProvider interface {
+	Retrieve(ctx context.Context) (AWSCredentials, error)
+}
+
+//StaticCredentials returns a CredentialsProvider always returning the given credentials
+func StaticCredentials(accessKeyID, secretAccessKey, sessionToken string) CredentialsProvider {
+	return staticCredentials{AWSCredentials{accessKeyID, secretAccessKey, sessionToken}}
+}
+
+type staticCredentials struct {
+	creds AWSCredentials
+}
+
+func (s staticCredentials) Retrieve(ctx context.Context) (AWSCredentials, error) {
+	return s.creds, nil
+}
+
+//SignAWSV4 signs every attempt with AWS Signature Version 4 for the given region and service,
+//setting the Authorization, X-Amz-Date and X-Amz-Content-Sha256 headers once the body and query are final.
+//Every header set on the request is signed along with the host. Streamed bodies, set with an io.Reader
+//and without a known way to read them again, are sent with an UNSIGNED-PAYLOAD hash instead of being buffered.
+//Paths are normalized before signing except for the s3 service
+func (r *Request) SignAWSV4(creds CredentialsProvider, region, service string) *Request {
+	if r == nil {
+		return nil
+	}
+	r.awsSigner = &awsV4Signer{creds: creds, region: region, service: service}
+	return r
+}
+
+type awsV4Signer struct {
+	creds   CredentialsProvider
+	region  string
+	service string
+}
+
+//signAWSV4 adds the AWS Signature Version 4 headers to req
+func (r *Request) signAWSV4(req *http.Request) error {
+	if r.awsSigner == nil {
+		return nil
+	}
+
+	creds, err := r.awsSigner.creds.Retrieve(req.Context())
+	if err != nil {
+		return fmt.Errorf("failed to retrieve AWS credentials: %w", err)
+	}
+
+	payloadHash := awsUnsignedPayload
+	if req.Body == nil || req.Body == http.NoBody || req.GetBody != nil {
+		body, err := bodyBytes(req)
+		if err != nil {
+			return err
+		}
+		payloadHash = hashHex(body)
+	}
+
+	req.Header.Set(awsDateHeader, r.now().UTC().Format(awsV4TimeFormat))
+	req.Header.Set(awsContentSHAHeader, payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set(awsTokenHeader, creds.SessionToken)
+	}
+	req.Header.Del("Authorization")
+
+	r.awsSigner.sign(req, creds, payloadHash)
+	return nil
+}
+
+//sign sets the Authorization header of req, which must carry an X-Amz-Date header, over its current headers
+func (s *awsV4Signer) sign(req *http.Request, creds AWSCredentials, payloadHash string) {
+	amzDate := req.Header.Get(awsDateHeader)
+	scope := strings.Join([]string{amzDate[:len(awsV4DateFormat)], s.region, s.service, "aws4_request"}, "/")
+
+	headers, signedHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s.canonicalPath(req.URL),
+		canonicalQuery(req.URL),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{awsV4Algorithm, amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), amzDate[:len(awsV4DateFormat)])
+	for _, part := range []string{s.region, s.service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsV4Algorithm, creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+//canonicalPath returns the URI encoded path, with dot segments and duplicate slashes removed unless signing for S3
+func (s *awsV4Signer) canonicalPath(u *url.URL) string {
+	p := u.Path
+	if p == "" {
+		return "/"
+	}
+
+	if s.service != "s3" {
+		trailing := strings.HasSuffix(p, "/")
+		p = path.Clean("/" + p)
+		if trailing && p != "/" {
+			p += "/"
+		}
+	}
+
+	return awsURIEncode(p, false)
+}
+
+//canonicalQuery returns the URI encoded query params sorted by name and value
+func canonicalQuery(u *url.URL) string {
+	if u.RawQuery == "" {
+		return ""
+	}
+
+	var params [][2]string
+	for _, pair := range strings.Split(u.RawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if unescaped, err := url.QueryUnescape(value); err == nil {
+			value = unescaped
+		}
+		params = append(params, [2]string{awsURIEncode(key, true), awsURIEncode(value, true)})
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i][0] != params[j][0] {
+			return params[i][0] < params[j][0]
+		}
+		return params[i][1] < params[j][1]
+	})
+
+	encoded := make([]string, len(params))
+	for i, param := range params {
+		encoded[i] = param[0] + "=" + param[1]
+	}
+	return strings.Join(encoded, "&")
+}
+
+//canonicalHeaders returns the canonical headers block, each ending with a newline, and the signed header names
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	values := map[string][]string{"host": {host}}
+	for name, vals := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "authorization" || lower == "user-agent" {
+			continue
+		}
+		values[lower] = append(values[lower], vals...)
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var headers strings.Builder
+	for _, name := range names {
+		trimmed := make([]string, len(values[name]))
+		for i, value := range values[name] {
+			trimmed[i] = strings.Join(strings.Fields(value), " ")
+		}
+		headers.WriteString(name + ":" + strings.Join(trimmed, ",") + "\n")
+	}
+
+	return headers.String(), strings.Join(names, ";")
+}
+
+//awsURIEncode percent encodes every byte except unreserved characters, and slashes unless encodeSlash is set
+func awsURIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hashHex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,194 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AidenHadisi/go-simple-request/requesttest"
+	"github.com/stretchr/testify/assert"
+)
+
+var testAWSCredentials = AWSCredentials{
+	AccessKeyID:     "AKIDEXAMPLE",
+	SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+//TestAWSV4TestSuite checks signatures against vectors from the AWS Signature Version 4 test suite
+func TestAWSV4TestSuite(t *testing.T) {
+	cases := []struct {
+		name     string
+		method   string
+		url      string
+		headers  map[string]string
+		body     string
+		expected string
+	}{
+		{
+			name:     "get-vanilla",
+			method:   "GET",
+			url:      "https://example.amazonaws.com/",
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:     "get-vanilla-query-order-key-case",
+			method:   "GET",
+			url:      "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:     "get-vanilla-empty-query-key",
+			method:   "GET",
+			url:      "https://example.amazonaws.com/?Param1=value1",
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=a67d582fa61cc504c4bae71f336f98b97f1ea3c7a6bfe1b6e45aec72011b9aeb",
+		},
+		{
+			name:     "get-utf8",
+			method:   "GET",
+			url:      "https://example.amazonaws.com/ሴ",
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=8318018e0b0f223aa2bbf98705b62bb787dc9c0e678f255a891fd03141be5d85",
+		},
+		{
+			name:     "post-vanilla",
+			method:   "POST",
+			url:      "https://example.amazonaws.com/",
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name:     "post-x-www-form-urlencoded",
+			method:   "POST",
+			url:      "https://example.amazonaws.com/",
+			headers:  map[string]string{"Content-Type": "application/x-www-form-urlencoded"},
+			body:     "Param1=value1",
+			expected: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+
+	signer := &awsV4Signer{region: "us-east-1", service: "service"}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := http.NewRequest(c.method, c.url, strings.NewReader(c.body))
+			assert.Nil(t, err)
+			req.Header.Set("X-Amz-Date", "20150830T123600Z")
+			for key, value := range c.headers {
+				req.Header.Set(key, value)
+			}
+
+			signer.sign(req, testAWSCredentials, hashHex([]byte(c.body)))
+			assert.Equal(t, c.expected, req.Header.Get("Authorization"))
+		})
+	}
+}
+
+//TestAWSV4Example checks the signature of the IAM ListUsers example from the AWS documentation
+func TestAWSV4Example(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	assert.Nil(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	req.Header.Set("X-Amz-Date", "20150830T123600Z")
+
+	signer := &awsV4Signer{region: "us-east-1", service: "iam"}
+	signer.sign(req, testAWSCredentials, hashHex(nil))
+
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7", req.Header.Get("Authorization"))
+}
+
+func TestCanonicalRequestParts(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/a/./b//../c/?b=2&a-b=3&a=x%20y&a=1&flag", nil)
+	req.Header.Add("My-Header1", "  value1   with  spaces ")
+	req.Header.Add("My-Header1", "value2")
+
+	assert.Equal(t, "/a/c/", (&awsV4Signer{service: "service"}).canonicalPath(req.URL))
+	assert.Equal(t, "/a/./b//../c/", (&awsV4Signer{service: "s3"}).canonicalPath(req.URL))
+	assert.Equal(t, "a=1&a=x%20y&a-b=3&b=2&flag=", canonicalQuery(req.URL))
+
+	headers, signed := canonicalHeaders(req)
+	assert.Equal(t, "host:example.amazonaws.com\nmy-header1:value1 with spaces,value2\n", headers)
+	assert.Equal(t, "host;my-header1", signed)
+}
+
+type headerCapture struct {
+	headers []http.Header
+	bodies  []string
+}
+
+func (c *headerCapture) handler(w http.ResponseWriter, req *http.Request) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+	}
+	c.headers = append(c.headers, req.Header.Clone())
+	c.bodies = append(c.bodies, string(body))
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestSignAWSV4(t *testing.T) {
+	clock := requesttest.NewFakeClock(time.Date(2015, time.August, 30, 12, 36, 0, 0, time.UTC))
+	creds := StaticCredentials(testAWSCredentials.AccessKeyID, testAWSCredentials.SecretAccessKey, "token")
+
+	t.Run("buffered body", func(t *testing.T) {
+		capture := &headerCapture{}
+		r := newMockRequest(capture.handler).SetClock(clock).SignAWSV4(creds, "eu-west-1", "es")
+
+		_, err := r.Post("https://search.example.com/index/_doc").SetBody(&fakeSuccess{ID: 1, Name: "Bob"}).Execute()
+		assert.Nil(t, err)
+
+		header := capture.headers[0]
+		assert.Equal(t, "20150830T123600Z", header.Get("X-Amz-Date"))
+		assert.Equal(t, hashHex([]byte(capture.bodies[0])), header.Get("X-Amz-Content-Sha256"))
+		assert.Equal(t, "token", header.Get("X-Amz-Security-Token"))
+		assert.True(t, strings.HasPrefix(header.Get("Authorization"),
+			"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/eu-west-1/es/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token, Signature="))
+	})
+
+	t.Run("streamed body", func(t *testing.T) {
+		capture := &headerCapture{}
+		r := newMockRequest(capture.handler).SetClock(clock).SignAWSV4(creds, "us-east-1", "s3")
+
+		body := io.MultiReader(strings.NewReader("chunk one, "), strings.NewReader("chunk two"))
+		_, err := r.Put("https://bucket.s3.amazonaws.com/object").SetBody(body).Execute()
+		assert.Nil(t, err)
+
+		assert.Equal(t, "UNSIGNED-PAYLOAD", capture.headers[0].Get("X-Amz-Content-Sha256"))
+		assert.Equal(t, "chunk one, chunk two", capture.bodies[0])
+	})
+
+	t.Run("every attempt is signed", func(t *testing.T) {
+		calls := 0
+		var dates []string
+		r := newMockRequest(func(w http.ResponseWriter, req *http.Request) {
+			calls++
+			dates = append(dates, req.Header.Get("X-Amz-Date"))
+			if calls == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}).SetClock(requesttest.NewFakeClock(time.Date(2015, time.August, 30, 12, 36, 0, 0, time.UTC)).SetAutoAdvance(true))
+
+		_, err := r.Get("https://example.amazonaws.com/").SetRetry(1, time.Second).SignAWSV4(creds, "us-east-1", "service").Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"20150830T123600Z", "20150830T123601Z"}, dates)
+	})
+
+	t.Run("credentials error", func(t *testing.T) {
+		expired := errors.New("expired")
+		r := newMockRequest(fakeHandler(200, `{}`, nil)).SignAWSV4(failingCredentials{expired}, "us-east-1", "service")
+
+		_, err := r.Get("https://example.amazonaws.com/").Execute()
+		assert.ErrorIs(t, err, expired)
+	})
+}
+
+type failingCredentials struct {
+	err error
+}
+
+func (f failingCredentials) Retrieve(ctx context.Context) (AWSCredentials, error) {
+	return AWSCredentials{}, f.err
+}
@@ -0,0 +1,110 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+//ExecuteStream runs the request once and leaves a 2xx response body unread, so it can be consumed incrementally
+//with Response.DecodeArrayStream instead of being buffered in memory. Non-2xx bodies are read and decoded into the
+//response like Execute does. The body is only buffered when OnVerifyResponse is used, to verify it before it is
+//handed out. Canceling the request context aborts reading the body. With SetMaxConcurrency the attempt keeps its
+//slot until the body is consumed by DecodeArrayStream or released by Response.Close, so always call one of them
+func (r *Request) ExecuteStream() (*Response, error) {
+	if r == nil {
+		return nil, ErrNilRequest
+	}
+	if r.url == "" {
+		return nil, ErrNoURL
+	}
+
+	return r.execute(func(exec *execution) (*Response, error) {
+		resp, err := r.sendOnce(exec, r.stream)
+		if err != nil || resp.stream != nil {
+			return resp, err
+		}
+		return resp, r.decode(resp)
+	})
+}
+
+func (r *Request) stream(req *http.Request) (*Response, error) {
+	resp, err := r.roundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+	}
+	setRedirects(response, resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || r.verify != nil {
+		defer resp.Body.Close()
+		body, err := r.readBody(resp.Body)
+		response.Body = body
+		if err != nil {
+			return response, err
+		}
+		return response, r.verifyResponse(response)
+	}
+
+	response.stream = newContextReader(req.Context(), resp.Body)
+	return response, nil
+}
+
+//DecodeArrayStream decodes a JSON array body one element at a time, calling onItem with every element in order.
+//The body is read as it is decoded when the response comes from ExecuteStream and closed once done, otherwise the
+//buffered Body is decoded. It stops at the first error returned by onItem and returns it as is. An empty body has no
+//elements. Malformed JSON and bodies which are not an array are reported as a *DecodeError
+func (r *Response) DecodeArrayStream(onItem func(json.RawMessage) error) error {
+	var body io.Reader = bytes.NewReader(r.Body)
+	if r.stream != nil {
+		defer r.Close()
+		body = r.stream
+	}
+
+	decoder := json.NewDecoder(body)
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return &DecodeError{Err: err}
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return &DecodeError{Err: fmt.Errorf("expected a JSON array, got %v", token)}
+	}
+
+	for decoder.More() {
+		var item json.RawMessage
+		if err := decoder.Decode(&item); err != nil {
+			return &DecodeError{Err: err}
+		}
+		if err := onItem(item); err != nil {
+			return err
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return &DecodeError{Err: err}
+	}
+	return nil
+}
+
+//Close releases the body of a response returned by ExecuteStream which was not consumed, along with its
+//concurrency slot. It is a no-op for other responses
+func (r *Response) Close() error {
+	if r.stream == nil {
+		return nil
+	}
+	r.stream.stop()
+	err := r.stream.r.Close()
+	if r.stream.release != nil {
+		r.stream.release()
+	}
+	r.stream = nil
+	return err
+}
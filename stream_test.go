@@ -0,0 +1,154 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeArrayStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"ID":404,"Name":"missing"}`))
+			return
+		}
+		if r.URL.Path == "/object" {
+			w.Write([]byte(`{"ID":1}`))
+			return
+		}
+		w.Write([]byte("["))
+		for i := 0; i < 1000; i++ {
+			if i > 0 {
+				w.Write([]byte(","))
+			}
+			fmt.Fprintf(w, `{"ID":%d,"Name":"item"}`, i)
+		}
+		w.Write([]byte("]"))
+	}))
+	defer server.Close()
+
+	t.Run("streams every element", func(t *testing.T) {
+		result, err := New().Get(server.URL).ExecuteStream()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Nil(t, result.Body)
+
+		count := 0
+		err = result.DecodeArrayStream(func(raw json.RawMessage) error {
+			var item fakeSuccess
+			if err := json.Unmarshal(raw, &item); err != nil {
+				return err
+			}
+			assert.Equal(t, fakeSuccess{ID: count, Name: "item"}, item)
+			count++
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 1000, count)
+	})
+
+	t.Run("stops on callback error", func(t *testing.T) {
+		result, err := New().Get(server.URL).ExecuteStream()
+		assert.Nil(t, err)
+
+		stop := errors.New("stop")
+		count := 0
+		err = result.DecodeArrayStream(func(json.RawMessage) error {
+			count++
+			if count == 3 {
+				return stop
+			}
+			return nil
+		})
+		assert.Equal(t, stop, err)
+		assert.Equal(t, 3, count)
+	})
+
+	t.Run("not an array", func(t *testing.T) {
+		result, err := New().Get(server.URL + "/object").ExecuteStream()
+		assert.Nil(t, err)
+
+		var decodeErr *DecodeError
+		err = result.DecodeArrayStream(func(json.RawMessage) error { return nil })
+		assert.True(t, errors.As(err, &decodeErr))
+	})
+
+	t.Run("failure is decoded", func(t *testing.T) {
+		var failure fakeSuccess
+		result, err := New().Get(server.URL + "/missing").SetFailure(&failure).ExecuteStream()
+		assert.Nil(t, err)
+		assert.Equal(t, 404, result.StatusCode)
+		assert.Equal(t, fakeSuccess{ID: 404, Name: "missing"}, failure)
+	})
+
+	t.Run("buffered body", func(t *testing.T) {
+		result := &Response{Body: []byte(`[1, 2, 3]`)}
+		var items []string
+		err := result.DecodeArrayStream(func(raw json.RawMessage) error {
+			items = append(items, string(raw))
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"1", "2", "3"}, items)
+
+		assert.Nil(t, (&Response{}).DecodeArrayStream(func(json.RawMessage) error {
+			t.Error("unexpected element")
+			return nil
+		}))
+	})
+
+	t.Run("close", func(t *testing.T) {
+		result, err := New().Get(server.URL).ExecuteStream()
+		assert.Nil(t, err)
+		assert.Nil(t, result.Close())
+		assert.Nil(t, result.Close())
+	})
+}
+
+func TestExecuteStreamHoldsConcurrencySlot(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`[1,2,3]`))
+	}))
+	defer server.Close()
+	template := New().SetMaxConcurrency(2)
+
+	t.Run("until decoded", func(t *testing.T) {
+		result, err := template.New().Get(server.URL).ExecuteStream()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), template.InFlight())
+
+		assert.Nil(t, result.DecodeArrayStream(func(json.RawMessage) error { return nil }))
+		assert.Equal(t, int64(0), template.InFlight())
+	})
+
+	t.Run("until closed", func(t *testing.T) {
+		first, err := template.New().Get(server.URL).ExecuteStream()
+		assert.Nil(t, err)
+		second, err := template.New().Get(server.URL).ExecuteStream()
+		assert.Nil(t, err)
+		assert.Equal(t, int64(2), template.InFlight())
+
+		assert.Nil(t, first.Close())
+		assert.Equal(t, int64(1), template.InFlight())
+		assert.Nil(t, second.Close())
+		assert.Nil(t, second.Close())
+		assert.Equal(t, int64(0), template.InFlight())
+	})
+
+	t.Run("not for buffered bodies", func(t *testing.T) {
+		result, err := template.New().Get(server.URL + "/missing").ExecuteStream()
+		assert.Nil(t, err)
+		assert.Equal(t, 404, result.StatusCode)
+		assert.Equal(t, int64(0), template.InFlight())
+	})
+}
@@ -0,0 +1,50 @@
+package request
+
+import (
+	"log/slog"
+	"sort"
+)
+
+//SetTag sets a metadata tag, such as the logical operation name or a tenant label, which cannot be derived
+//from the URL. Tags are logged, passed to the OnAttempt and OnMetrics hooks, set on the Response and never
+//sent to the server. Requests created with New inherit the tags without sharing later changes
+func (r *Request) SetTag(key, value string) *Request {
+	if r == nil {
+		return nil
+	}
+	tags := make(map[string]string, len(r.tags)+1)
+	for k, v := range r.tags {
+		tags[k] = v
+	}
+	tags[key] = value
+	r.tags = tags
+	return r
+}
+
+//Tags returns a copy of the tags set with SetTag, nil when there are none
+func (r *Request) Tags() map[string]string {
+	if len(r.tags) == 0 {
+		return nil
+	}
+
+	tags := make(map[string]string, len(r.tags))
+	for k, v := range r.tags {
+		tags[k] = v
+	}
+	return tags
+}
+
+//tagsAttr returns the tags as a log group sorted by key
+func (r *Request) tagsAttr() slog.Attr {
+	keys := make([]string, 0, len(r.tags))
+	for key := range r.tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]interface{}, len(keys))
+	for i, key := range keys {
+		attrs[i] = slog.String(key, r.tags[key])
+	}
+	return slog.Group("tags", attrs...)
+}
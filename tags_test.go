@@ -0,0 +1,74 @@
+package request
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httputil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetTag(t *testing.T) {
+	var wire string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		dump, _ := httputil.DumpRequest(r, true)
+		wire = string(dump)
+	}
+
+	t.Run("reported", func(t *testing.T) {
+		var metrics RequestMetrics
+		var attempt AttemptInfo
+		var hooked map[string]string
+		logs := &captureHandler{level: slog.LevelDebug}
+		result, err := newMockRequest(handler).Post("http://example.com/orders").
+			SetBody(&fakeSuccess{Name: "John"}).
+			SetTag("operation", "CreateOrder").
+			SetTag("tenant", "acme").
+			OnMetrics(func(m RequestMetrics) { metrics = m }).
+			OnAttempt(func(info AttemptInfo) { attempt = info }).
+			OnResponse(func(_ *Request, resp *Response) error {
+				hooked = resp.Tags
+				return nil
+			}).
+			SetLogger(slog.New(logs)).
+			Execute()
+		assert.Nil(t, err)
+
+		expected := map[string]string{"operation": "CreateOrder", "tenant": "acme"}
+		assert.Equal(t, expected, metrics.Tags)
+		assert.Equal(t, expected, attempt.Tags)
+		assert.Equal(t, expected, hooked)
+		assert.Equal(t, expected, result.Tags)
+
+		assert.NotEmpty(t, logs.records)
+		for _, record := range logs.records {
+			assert.Equal(t, "[operation=CreateOrder tenant=acme]", attrs(record)["tags"].String(), record.Message)
+		}
+
+		assert.NotContains(t, wire, "CreateOrder")
+		assert.NotContains(t, wire, "acme")
+	})
+
+	t.Run("template derivation", func(t *testing.T) {
+		var metrics []RequestMetrics
+		template := newMockRequest(handler).SetTag("tenant", "acme").
+			OnMetrics(func(m RequestMetrics) { metrics = append(metrics, m) })
+
+		_, err := template.New().Get("http://example.com/orders").SetTag("operation", "ListOrders").Execute()
+		assert.Nil(t, err)
+		_, err = template.New().Get("http://example.com/orders/1").SetTag("tenant", "other").Execute()
+		assert.Nil(t, err)
+
+		assert.Equal(t, map[string]string{"tenant": "acme", "operation": "ListOrders"}, metrics[0].Tags)
+		assert.Equal(t, map[string]string{"tenant": "other"}, metrics[1].Tags)
+		assert.Equal(t, map[string]string{"tenant": "acme"}, template.Tags())
+	})
+
+	t.Run("copies", func(t *testing.T) {
+		r := New().SetTag("tenant", "acme")
+		r.Tags()["tenant"] = "changed"
+		assert.Equal(t, "acme", r.Tags()["tenant"])
+		assert.Nil(t, New().Tags())
+	})
+}
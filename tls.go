@@ -0,0 +1,175 @@
+package request
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//SetInsecureSkipVerify disables verification of the server's certificate chain and host name when skip is true.
+//This makes the connection vulnerable to man-in-the-middle attacks, so only use it in development against
+//servers with self-signed certificates and never in production. The first attempt sent while verification is
+//disabled warns about it, once for this request and every request derived from it via New: at warn level when a
+//logger is set, to the debug writer when Debug is enabled and to stderr otherwise. The client and its transport are copied so requests
+//sharing them are not affected. It has no effect when the client is not an *http.Client
+func (r *Request) SetInsecureSkipVerify(skip bool) *Request {
+	if r == nil {
+		return nil
+	}
+	configured := r.configureTransport(func(transport *http.Transport) {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = skip
+	})
+	if configured {
+		if skip && !r.insecure {
+			r.insecureWarn = &sync.Once{}
+		}
+		r.insecure = skip
+	}
+	return r
+}
+
+//warnInsecure warns once that certificate verification is disabled, see SetInsecureSkipVerify
+func (r *Request) warnInsecure(req *http.Request) {
+	if !r.insecure {
+		return
+	}
+	r.insecureWarn.Do(func() {
+		const warning = "TLS certificate verification disabled"
+		if r.logger != nil {
+			r.logger.Warn(warning, slog.String("url", r.logURL(req)))
+		}
+		if r.debug != nil {
+			fmt.Fprintf(r.debug.w, "! %s for %s\n", warning, r.logURL(req))
+		}
+		if r.logger == nil && r.debug == nil {
+			fmt.Fprintf(os.Stderr, "request: warning: %s for %s\n", warning, r.logURL(req))
+		}
+	})
+}
+
+//configureTransport applies configure to the client's *http.Transport. The first call copies the transport, or
+//http.DefaultTransport when none is set, so requests sharing the client are not affected. Later calls configure
+//that copy in place until the request is sent or another is derived from it with New, so a builder configured
+//with several options keeps a single connection pool. Options set afterwards copy the transport again, which
+//starts a new connection pool. It reports false when the client is not an *http.Client or its transport not an
+//*http.Transport
+func (r *Request) configureTransport(configure func(transport *http.Transport)) bool {
+	client, ok := r.client.(*http.Client)
+	if !ok {
+		return false
+	}
+	if r.transport.owns(client.Transport) {
+		configure(r.transport.transport)
+		return true
+	}
+
+	var transport *http.Transport
+	switch t := client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return false
+	}
+	configure(transport)
+
+	copied := *client
+	copied.Transport = transport
+	r.client = &copied
+	r.transport = &ownedTransport{transport: transport}
+	return true
+}
+
+//ownedTransport is a transport copied by configureTransport, which may be configured in place until it is shared
+type ownedTransport struct {
+	transport *http.Transport
+	shared    atomic.Bool
+}
+
+//owns reports whether transport is the owned transport and has not been shared yet
+func (o *ownedTransport) owns(transport http.RoundTripper) bool {
+	return o != nil && !o.shared.Load() && transport == o.transport
+}
+
+//share marks the transport as used by another request or by a request in flight
+func (o *ownedTransport) share() {
+	if o != nil {
+		o.shared.Store(true)
+	}
+}
+
+//PinCertificates requires the server to present a certificate whose public key matches one of sha256Pins,
+//given as base64 encoded SHA-256 hashes of the DER encoded SubjectPublicKeyInfo with an optional "sha256/" prefix.
+//The pins are checked in addition to the standard chain verification, which still uses the transport's RootCAs.
+//The handshake fails with a *CertificatePinError listing the observed pins when no certificate matches.
+//Calling it again replaces the pin set. The client and its transport are copied so requests sharing them
+//are not affected. It has no effect when the client is not an *http.Client
+func (r *Request) PinCertificates(sha256Pins ...string) *Request {
+	if r == nil {
+		return nil
+	}
+	pins := make(map[string]bool, len(sha256Pins))
+	for _, pin := range sha256Pins {
+		pins[strings.TrimPrefix(strings.TrimSpace(pin), "sha256/")] = true
+	}
+
+	r.configureTransport(func(transport *http.Transport) {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.VerifyPeerCertificate = verifyPins(pins)
+	})
+	return r
+}
+
+//verifyPins returns a VerifyPeerCertificate callback accepting chains with a certificate matching one of pins.
+//It runs after the standard verification succeeds, or alone when verification is skipped
+func verifyPins(pins map[string]bool) func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		var certs []*x509.Certificate
+		for _, chain := range verifiedChains {
+			certs = append(certs, chain...)
+		}
+		if len(verifiedChains) == 0 {
+			for _, raw := range rawCerts {
+				cert, err := x509.ParseCertificate(raw)
+				if err != nil {
+					return fmt.Errorf("failed to parse certificate: %w", err)
+				}
+				certs = append(certs, cert)
+			}
+		}
+
+		var observed []string
+		seen := make(map[string]bool)
+		for _, cert := range certs {
+			pin := SPKIPin(cert)
+			if pins[pin] {
+				return nil
+			}
+			if !seen[pin] {
+				seen[pin] = true
+				observed = append(observed, pin)
+			}
+		}
+		return &CertificatePinError{Observed: observed}
+	}
+}
+
+//SPKIPin returns the base64 encoded SHA-256 hash of the certificate's SubjectPublicKeyInfo as used by PinCertificates
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,141 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetInsecureSkipVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Run("verified by default", func(t *testing.T) {
+		_, err := New().Get(server.URL).Execute()
+		assert.True(t, IsTLSError(err))
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		var logs bytes.Buffer
+		base := New()
+		result, err := base.New().Get(server.URL).SetInsecureSkipVerify(true).
+			SetLogger(slog.New(slog.NewTextHandler(&logs, nil))).Execute()
+
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Contains(t, logs.String(), "level=WARN msg=\"TLS certificate verification disabled\"")
+
+		//the original client is left untouched
+		_, err = base.Get(server.URL).Execute()
+		assert.True(t, IsTLSError(err))
+	})
+
+	t.Run("warns once", func(t *testing.T) {
+		var logs bytes.Buffer
+		template := New().SetInsecureSkipVerify(true).SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+		for i := 0; i < 3; i++ {
+			_, err := template.New().Get(server.URL).Execute()
+			assert.Nil(t, err)
+		}
+		assert.Equal(t, 1, strings.Count(logs.String(), "TLS certificate verification disabled"))
+	})
+
+	t.Run("warns through debug output", func(t *testing.T) {
+		var out bytes.Buffer
+		template := New().SetInsecureSkipVerify(true).Debug(true, DebugWriter(&out))
+		for i := 0; i < 2; i++ {
+			_, err := template.New().Get(server.URL).Execute()
+			assert.Nil(t, err)
+		}
+		assert.Equal(t, 1, strings.Count(out.String(), "! TLS certificate verification disabled for "+server.URL))
+	})
+
+	t.Run("warns on stderr without logger", func(t *testing.T) {
+		stderr, err := os.CreateTemp(t.TempDir(), "stderr")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func(original *os.File) { os.Stderr = original }(os.Stderr)
+		os.Stderr = stderr
+
+		template := New().SetInsecureSkipVerify(true)
+		for i := 0; i < 2; i++ {
+			_, err := template.New().Get(server.URL).Execute()
+			assert.Nil(t, err)
+		}
+		written, err := os.ReadFile(stderr.Name())
+		assert.Nil(t, err)
+		assert.Equal(t, "request: warning: TLS certificate verification disabled for "+server.URL+"\n", string(written))
+	})
+
+	t.Run("re-enabled", func(t *testing.T) {
+		_, err := New().Get(server.URL).SetInsecureSkipVerify(true).SetInsecureSkipVerify(false).Execute()
+		assert.True(t, IsTLSError(err))
+	})
+}
+
+func TestPinCertificates(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	pin := SPKIPin(server.Certificate())
+
+	//trusts the test server through the transport's RootCAs
+	trusted := func() *Request {
+		r := New()
+		r.client = server.Client()
+		return r
+	}
+
+	t.Run("matching pin", func(t *testing.T) {
+		result, err := trusted().Get(server.URL).PinCertificates("bogus", pin).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+	})
+
+	t.Run("prefixed pin", func(t *testing.T) {
+		_, err := trusted().Get(server.URL).PinCertificates("sha256/" + pin).Execute()
+		assert.Nil(t, err)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		base := trusted()
+		_, err := base.New().Get(server.URL).PinCertificates("AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=").Execute()
+
+		var pinErr *CertificatePinError
+		assert.True(t, errors.As(err, &pinErr))
+		assert.Equal(t, []string{pin}, pinErr.Observed)
+		assert.Contains(t, err.Error(), pin)
+		assert.ErrorIs(t, err, ErrCertificatePinMismatch)
+		assert.True(t, IsTLSError(err))
+
+		//the original client is left untouched
+		_, err = base.Get(server.URL).Execute()
+		assert.Nil(t, err)
+	})
+
+	t.Run("standard verification kept", func(t *testing.T) {
+		_, err := New().Get(server.URL).PinCertificates(pin).Execute()
+		assert.True(t, IsTLSError(err))
+		assert.NotErrorIs(t, err, ErrCertificatePinMismatch)
+	})
+
+	t.Run("with insecure skip verify", func(t *testing.T) {
+		_, err := New().Get(server.URL).SetInsecureSkipVerify(true).PinCertificates(pin).Execute()
+		assert.Nil(t, err)
+
+		_, err = New().Get(server.URL).SetInsecureSkipVerify(true).PinCertificates("bogus").Execute()
+		assert.ErrorIs(t, err, ErrCertificatePinMismatch)
+	})
+}
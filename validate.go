@@ -0,0 +1,56 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+//Validate checks the request can be built before it is sent and returns the first problem found: ErrNilRequest
+//for a nil request, an error wrapping ErrInvalidURL when the last URL set could not be parsed, ErrNoURL when
+//no URL is set, an error wrapping ErrInvalidMethod for a method which is not a valid token, an error wrapping
+//ErrInvalidBody when the body cannot be marshaled to JSON and an error wrapping ErrInvalidQuery when the query
+//cannot be encoded. Nothing is sent and no body is read, so it is safe to call before Execute or in tests
+func (r *Request) Validate() error {
+	if r == nil {
+		return ErrNilRequest
+	}
+	if r.urlErr != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidURL, r.urlErr)
+	}
+	if r.url == "" {
+		return ErrNoURL
+	}
+	if !validMethod(r.method) {
+		return fmt.Errorf("%w: %q", ErrInvalidMethod, r.method)
+	}
+	if err := r.validateBody(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidBody, err)
+	}
+
+	u, err := url.Parse(r.url)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidURL, err)
+	}
+	return r.encodeQuery(u)
+}
+
+//validateBody marshals the body as bodyReader would, without reading readers, files or NDJSON streams
+func (r *Request) validateBody() error {
+	switch r.body.(type) {
+	case nil, io.Reader, fileBody, ndjsonBody:
+		return nil
+	}
+	_, err := json.Marshal(r.body)
+	return err
+}
+
+//validMethod reports whether method is a token as defined by RFC 9110 section 5.6.2.
+//An empty method is sent as GET like net/http does
+func validMethod(method string) bool {
+	return strings.IndexFunc(method, func(c rune) bool {
+		return c > '~' || c <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, c)
+	}) < 0
+}
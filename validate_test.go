@@ -0,0 +1,58 @@
+package request
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		assert.Nil(t, New().Post("http://example.com/users").SetBody(map[string]string{"name": "John"}).
+			SetQuery(&fakeQuery{ID: 1}).Validate())
+		assert.Nil(t, New().Put("http://example.com/users").SetBody(strings.NewReader("raw")).Validate())
+	})
+
+	t.Run("nil request", func(t *testing.T) {
+		var r *Request
+		assert.Equal(t, ErrNilRequest, r.Validate())
+	})
+
+	t.Run("empty URL", func(t *testing.T) {
+		assert.Equal(t, ErrNoURL, New().Validate())
+		assert.Equal(t, ErrNoURL, New().Get("").Validate())
+	})
+
+	t.Run("unparsable URL", func(t *testing.T) {
+		err := New().Get("http://example.com").Path("http://[::1").Validate()
+		assert.True(t, errors.Is(err, ErrInvalidURL))
+
+		assert.Nil(t, New().Get("http://[::1").Get("http://example.com").Validate())
+	})
+
+	t.Run("invalid method", func(t *testing.T) {
+		for _, method := range []string{"GET POST", "GE\nT", "PÖST", "GET/1"} {
+			r := New().Get("http://example.com")
+			r.method = method
+			err := r.Validate()
+			assert.True(t, errors.Is(err, ErrInvalidMethod), method)
+		}
+
+		r := New().Get("http://example.com")
+		r.method = "PROPFIND"
+		assert.Nil(t, r.Validate())
+	})
+
+	t.Run("unmarshalable body", func(t *testing.T) {
+		err := New().Post("http://example.com").SetBody(map[string]interface{}{"callback": func() {}}).Validate()
+		assert.True(t, errors.Is(err, ErrInvalidBody))
+		assert.Contains(t, err.Error(), "unsupported type")
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		err := New().Get("http://example.com").SetQuery(42).Validate()
+		assert.True(t, errors.Is(err, ErrInvalidQuery))
+	})
+}
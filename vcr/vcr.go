@@ -0,0 +1,387 @@
+//Package vcr records the HTTP interactions of tests to cassette files and replays them without the network,
+//so tests against live APIs run fast and deterministically once recorded
+package vcr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	request "github.com/AidenHadisi/go-simple-request"
+)
+
+//Mode selects whether a Recorder records or replays interactions
+type Mode int
+
+const (
+	//ModeReplay serves requests from the cassette and never touches the network
+	ModeReplay Mode = iota
+	//ModeRecord sends requests and saves every interaction to the cassette when the test ends
+	ModeRecord
+)
+
+//ErrNoInteraction is returned in replay mode when no recorded interaction matches a request
+var ErrNoInteraction = errors.New("vcr: no matching interaction in cassette")
+
+//Cassette is the recorded interactions saved as JSON
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+//Interaction is a request and the response received for it
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+//RecordedRequest is a request as saved in a cassette
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+//RecordedResponse is a response as saved in a cassette
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+//Matcher reports whether an incoming request, with its headers scrubbed, matches a recorded one
+type Matcher func(req, recorded RecordedRequest) bool
+
+//MatchMethod matches requests with the same method
+func MatchMethod(req, recorded RecordedRequest) bool {
+	return req.Method == recorded.Method
+}
+
+//MatchURL matches requests with the same URL, including the query
+func MatchURL(req, recorded RecordedRequest) bool {
+	return req.URL == recorded.URL
+}
+
+//MatchBody matches requests with the same body
+func MatchBody(req, recorded RecordedRequest) bool {
+	return req.Body == recorded.Body
+}
+
+//MatchHeaders matches requests with the same values for the named headers
+func MatchHeaders(names ...string) Matcher {
+	return func(req, recorded RecordedRequest) bool {
+		for _, name := range names {
+			if strings.Join(req.Header.Values(name), ",") != strings.Join(recorded.Header.Values(name), ",") {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+//Option configures a Recorder
+type Option func(*Recorder)
+
+//WithMatchers sets how requests are matched against the cassette in replay mode, defaults to MatchMethod and MatchURL
+func WithMatchers(matchers ...Matcher) Option {
+	return func(r *Recorder) {
+		r.matchers = matchers
+	}
+}
+
+//WithScrubbedHeaders sets headers whose values are replaced with request.Redacted before they are saved.
+//Headers redacted by the request, request.DefaultRedactedHeaders and those set with RedactHeaders or SetAPIKey,
+//are always scrubbed
+func WithScrubbedHeaders(names ...string) Option {
+	return func(r *Recorder) {
+		r.scrub = names
+	}
+}
+
+//WithScrubbedQueryParams sets query params whose values are replaced with request.Redacted in recorded URLs.
+//API keys sent as query params with SetAPIKey are always scrubbed
+func WithScrubbedQueryParams(names ...string) Option {
+	return func(r *Recorder) {
+		r.scrubQuery = names
+	}
+}
+
+//WithTransport sets the transport used by RoundTrip and Do in record mode, defaults to http.DefaultTransport
+func WithTransport(transport http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+//Recorder records or replays the interactions of a test. Plug it into a request with
+//Use(recorder.Middleware()), or use it as an http.RoundTripper or a client's Do
+type Recorder struct {
+	t          testing.TB
+	path       string
+	mode       Mode
+	matchers   []Matcher
+	scrub      []string
+	scrubQuery []string
+	transport  http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+//New returns a recorder for the cassette at path. In replay mode the cassette is loaded and the test fails
+//when it cannot be read. In record mode the cassette is written once the test and its subtests complete
+func New(t testing.TB, path string, mode Mode, opts ...Option) *Recorder {
+	t.Helper()
+	r := &Recorder{
+		t:         t,
+		path:      path,
+		mode:      mode,
+		matchers:  []Matcher{MatchMethod, MatchURL},
+		transport: http.DefaultTransport,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == ModeRecord {
+		t.Cleanup(func() {
+			if err := r.save(); err != nil {
+				t.Errorf("vcr: failed to save cassette: %v", err)
+			}
+		})
+		return r
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("vcr: failed to read cassette: %v", err)
+		return r
+	}
+	if err := json.Unmarshal(data, &r.cassette); err != nil {
+		t.Fatalf("vcr: failed to decode cassette %s: %v", path, err)
+		return r
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r
+}
+
+//Middleware returns a request middleware which records the requests passed through it, sending them
+//with the rest of the chain, or replays them
+func (r *Recorder) Middleware() request.Middleware {
+	return func(next request.Endpoint) request.Endpoint {
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			return r.serve(req, func(req *http.Request) (*http.Response, error) {
+				return next(ctx, req)
+			})
+		}
+	}
+}
+
+//RoundTrip records or replays req, sending it with the recorder's transport in record mode
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	return r.serve(req, r.transport.RoundTrip)
+}
+
+//Do records or replays req like RoundTrip
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	return r.RoundTrip(req)
+}
+
+//Cassette returns a copy of the interactions recorded or loaded so far
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+func (r *Recorder) serve(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	body, err := readBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    request.RedactedURL(req, r.scrubQuery...),
+		Header: r.scrubHeader(req, req.Header),
+		Body:   string(body),
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	resp, err := send(req)
+	if err != nil {
+		return resp, err
+	}
+	respBody, err := readBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Header:     r.scrubHeader(req, resp.Header),
+			Body:       string(respBody),
+		},
+	})
+	return resp, nil
+}
+
+//replay returns the response of the first unused matching interaction, or of the last matching one when all
+//were used, failing the test with the differences to the closest interaction when none matches
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	match := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !r.matches(recorded, interaction.Request) {
+			continue
+		}
+		match = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if match < 0 {
+		r.t.Errorf("vcr: no interaction in %s matches %s %s%s", r.path, recorded.Method, recorded.URL, r.closest(recorded))
+		return nil, ErrNoInteraction
+	}
+
+	r.used[match] = true
+	stored := r.cassette.Interactions[match].Response
+	header := stored.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", stored.StatusCode, http.StatusText(stored.StatusCode)),
+		StatusCode:    stored.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(strings.NewReader(stored.Body)),
+		ContentLength: int64(len(stored.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Recorder) matches(req, recorded RecordedRequest) bool {
+	for _, matcher := range r.matchers {
+		if !matcher(req, recorded) {
+			return false
+		}
+	}
+	return true
+}
+
+//closest describes how req differs from the interaction satisfying the most matchers
+func (r *Recorder) closest(req RecordedRequest) string {
+	best, bestScore := -1, -1
+	for i, interaction := range r.cassette.Interactions {
+		score := 0
+		for _, matcher := range r.matchers {
+			if matcher(req, interaction.Request) {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if best < 0 {
+		return ", the cassette is empty"
+	}
+
+	return fmt.Sprintf(", closest is interaction %d:\n%s", best, diff(req, r.cassette.Interactions[best].Request))
+}
+
+//diff lists the fields of got which differ from want
+func diff(got, want RecordedRequest) string {
+	var b strings.Builder
+	field := func(name, got, want string) {
+		if got != want {
+			fmt.Fprintf(&b, "  %s:\n    got:      %q\n    recorded: %q\n", name, got, want)
+		}
+	}
+	field("method", got.Method, want.Method)
+	field("url", got.URL, want.URL)
+
+	names := make(map[string]bool)
+	for name := range got.Header {
+		names[name] = true
+	}
+	for name := range want.Header {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		field("header "+name, strings.Join(got.Header.Values(name), ","), strings.Join(want.Header.Values(name), ","))
+	}
+
+	field("body", got.Body, want.Body)
+	return b.String()
+}
+
+//scrubHeader redacts header, sent with req or received in response to it, as the request does and scrubs the
+//headers set with WithScrubbedHeaders
+func (r *Recorder) scrubHeader(req *http.Request, header http.Header) http.Header {
+	scrubbed := request.RedactedHeader(req, header)
+	for _, name := range r.scrub {
+		if values := scrubbed.Values(name); len(values) > 0 {
+			scrubbed.Set(name, request.Redacted)
+		}
+	}
+	return scrubbed
+}
+
+func (r *Recorder) save() error {
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+//readBody reads the body and replaces it with a reader over the bytes read
+func readBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
@@ -0,0 +1,212 @@
+package vcr
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	request "github.com/AidenHadisi/go-simple-request"
+	"github.com/stretchr/testify/assert"
+)
+
+//fakeT records failures and cleanups instead of failing the test
+type fakeT struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Fatalf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeT) Cleanup(fn func()) {
+	f.cleanups = append(f.cleanups, fn)
+}
+
+func (f *fakeT) finish() {
+	for i := len(f.cleanups) - 1; i >= 0; i-- {
+		f.cleanups[i]()
+	}
+}
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func newServer(calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(calls, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=abc")
+		if len(body) > 0 {
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+			return
+		}
+		fmt.Fprintf(w, `{"id":1,"name":%q}`, r.URL.Path)
+	}))
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var calls int32
+	server := newServer(&calls)
+	path := filepath.Join(t.TempDir(), "cassettes", "users.json")
+
+	recording := &fakeT{}
+	recorder := New(recording, path, ModeRecord)
+	var recorded user
+	result, err := request.New().Get(server.URL+"/users/1").
+		SetHeader("Authorization", "Bearer secret").
+		SetSuccess(&recorded).
+		Use(recorder.Middleware()).
+		Execute()
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, user{ID: 1, Name: "/users/1"}, recorded)
+	recording.finish()
+	assert.Empty(t, recording.errors)
+	server.Close()
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), request.Redacted)
+	assert.NotContains(t, string(data), "secret")
+	assert.NotContains(t, string(data), "session=abc")
+
+	replaying := &fakeT{}
+	replayer := New(replaying, path, ModeReplay)
+	var replayed user
+	result, err = request.New().Get(server.URL + "/users/1").
+		SetSuccess(&replayed).
+		Use(replayer.Middleware()).
+		Execute()
+	assert.Nil(t, err)
+	assert.Empty(t, replaying.errors)
+	assert.Equal(t, 200, result.StatusCode)
+	assert.Equal(t, "application/json", result.Header.Get("Content-Type"))
+	assert.Equal(t, recorded, replayed)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestReplayMatchers(t *testing.T) {
+	var calls int32
+	server := newServer(&calls)
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "bodies.json")
+
+	recording := &fakeT{}
+	recorder := New(recording, path, ModeRecord)
+	client := &http.Client{Transport: recorder}
+	for _, name := range []string{"alice", "bob"} {
+		resp, err := client.Post(server.URL+"/users", "application/json", strings.NewReader(`{"name":"`+name+`"}`))
+		assert.Nil(t, err)
+		resp.Body.Close()
+	}
+	recording.finish()
+
+	replaying := &fakeT{}
+	replayer := New(replaying, path, ModeReplay, WithMatchers(MatchMethod, MatchURL, MatchBody))
+	for _, name := range []string{"bob", "alice"} {
+		req, _ := http.NewRequest("POST", server.URL+"/users", strings.NewReader(`{"name":"`+name+`"}`))
+		resp, err := replayer.Do(req)
+		assert.Nil(t, err)
+		body, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, 201, resp.StatusCode)
+		assert.Equal(t, `{"name":"`+name+`"}`, string(body))
+	}
+	assert.Empty(t, replaying.errors)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	t.Run("headers", func(t *testing.T) {
+		replaying := &fakeT{}
+		replayer := New(replaying, path, ModeReplay, WithMatchers(MatchURL, MatchHeaders("Content-Type")))
+		req, _ := http.NewRequest("POST", server.URL+"/users", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "text/plain")
+		_, err := replayer.Do(req)
+		assert.True(t, errors.Is(err, ErrNoInteraction))
+		assert.Len(t, replaying.errors, 1)
+		assert.Contains(t, replaying.errors[0], "header Content-Type")
+	})
+}
+
+func TestReplayUnmatched(t *testing.T) {
+	var calls int32
+	server := newServer(&calls)
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "unmatched.json")
+
+	recording := &fakeT{}
+	recorder := New(recording, path, ModeRecord)
+	_, err := request.New().Get(server.URL + "/users/1").Use(recorder.Middleware()).Execute()
+	assert.Nil(t, err)
+	recording.finish()
+
+	replaying := &fakeT{}
+	replayer := New(replaying, path, ModeReplay)
+	_, err = request.New().Get(server.URL + "/users/2").Use(replayer.Middleware()).Execute()
+	assert.True(t, errors.Is(err, ErrNoInteraction))
+	assert.Len(t, replaying.errors, 1)
+	assert.Contains(t, replaying.errors[0], "closest is interaction 0")
+	assert.Contains(t, replaying.errors[0], `"`+server.URL+`/users/2"`)
+	assert.Contains(t, replaying.errors[0], `"`+server.URL+`/users/1"`)
+	assert.NotContains(t, replaying.errors[0], "method")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestMissingCassette(t *testing.T) {
+	replaying := &fakeT{}
+	New(replaying, filepath.Join(t.TempDir(), "missing.json"), ModeReplay)
+	assert.Len(t, replaying.errors, 1)
+	assert.Contains(t, replaying.errors[0], "failed to read cassette")
+}
+
+func TestScrubbing(t *testing.T) {
+	var calls int32
+	server := newServer(&calls)
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "scrubbed.json")
+
+	build := func(recorder *Recorder) *request.Request {
+		return request.New().Get(server.URL+"/users/1?page=2&token=t0ken").
+			SetAPIKey("k3y", request.KeyInQuery, "").
+			SetHeader("X-Tenant-Secret", "tenant").
+			SetHeader("X-Trace", "trace").
+			RedactHeaders("X-Tenant-Secret").
+			Use(recorder.Middleware())
+	}
+
+	recording := &fakeT{}
+	recorder := New(recording, path, ModeRecord, WithScrubbedHeaders("X-Trace"), WithScrubbedQueryParams("token"))
+	_, err := build(recorder).Execute()
+	assert.Nil(t, err)
+	recording.finish()
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	for _, secret := range []string{"k3y", "t0ken", "tenant", "trace"} {
+		assert.NotContains(t, string(data), secret)
+	}
+	assert.Contains(t, string(data), "page=2")
+
+	replaying := &fakeT{}
+	replayer := New(replaying, path, ModeReplay, WithScrubbedQueryParams("token"))
+	result, err := build(replayer).Execute()
+	assert.Nil(t, err)
+	assert.Empty(t, replaying.errors)
+	assert.Equal(t, 200, result.StatusCode)
+}
@@ -0,0 +1,26 @@
+package request
+
+import "net/http"
+
+//OnVerifyResponse sets a hook verifying every response, for example its signature, once the body is read
+//and before it is decoded. When the hook returns an error the response is not decoded and Execute returns it
+//along with a *ResponseVerificationError matching ErrResponseVerification, leaving the raw body on the response.
+//DownloadTo buffers the body while a verifier is set so nothing unverified is written
+func (r *Request) OnVerifyResponse(verify func(statusCode int, header http.Header, body []byte) error) *Request {
+	if r == nil {
+		return nil
+	}
+	r.verify = verify
+	return r
+}
+
+//verifyResponse runs the verification hook on resp
+func (r *Request) verifyResponse(resp *Response) error {
+	if r.verify == nil {
+		return nil
+	}
+	if err := r.verify(resp.StatusCode, resp.Header, resp.Body); err != nil {
+		return &ResponseVerificationError{Err: err}
+	}
+	return nil
+}
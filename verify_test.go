@@ -0,0 +1,86 @@
+package request
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errBadSignature = errors.New("signature mismatch")
+
+func verifySignature(statusCode int, header http.Header, body []byte) error {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get("X-Signature"))) {
+		return errBadSignature
+	}
+	return nil
+}
+
+func signedHandler(body string, tamper bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(body))
+		w.Header().Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+		if tamper {
+			body = `{"ID":666,"Name":"Mallory"}`
+		}
+		w.Write([]byte(body))
+	}
+}
+
+func TestOnVerifyResponse(t *testing.T) {
+	t.Run("valid signature", func(t *testing.T) {
+		r := newMockRequest(signedHandler(`{"ID":1,"Name":"Bob"}`, false)).OnVerifyResponse(verifySignature)
+
+		result, err := r.Get("http://example.com").SetSuccess(&fakeSuccess{}).Execute()
+		assert.Nil(t, err)
+		assert.Equal(t, &fakeSuccess{ID: 1, Name: "Bob"}, result.Success)
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		r := newMockRequest(signedHandler(`{"ID":1,"Name":"Bob"}`, true)).OnVerifyResponse(verifySignature)
+
+		success := &fakeSuccess{}
+		result, err := r.Get("http://example.com").SetSuccess(success).Execute()
+
+		var verificationErr *ResponseVerificationError
+		assert.True(t, errors.As(err, &verificationErr))
+		assert.ErrorIs(t, err, ErrResponseVerification)
+		assert.ErrorIs(t, err, errBadSignature)
+		assert.Equal(t, 200, result.StatusCode)
+		assert.Equal(t, `{"ID":666,"Name":"Mallory"}`, string(result.Body))
+		//the untrusted payload is not decoded
+		assert.Nil(t, result.Success)
+		assert.Equal(t, &fakeSuccess{}, success)
+	})
+}
+
+func TestOnVerifyResponseDownload(t *testing.T) {
+	t.Run("valid signature", func(t *testing.T) {
+		r := newMockRequest(signedHandler("file contents", false)).OnVerifyResponse(verifySignature)
+
+		var buf bytes.Buffer
+		result, err := r.Get("http://example.com/file").DownloadTo(&buf, nil)
+		assert.Nil(t, err)
+		assert.Nil(t, result.Body)
+		assert.Equal(t, "file contents", buf.String())
+	})
+
+	t.Run("tampered body", func(t *testing.T) {
+		r := newMockRequest(signedHandler("file contents", true)).OnVerifyResponse(verifySignature)
+
+		var buf bytes.Buffer
+		result, err := r.Get("http://example.com/file").DownloadTo(&buf, nil)
+		assert.ErrorIs(t, err, ErrResponseVerification)
+		assert.Equal(t, 0, buf.Len())
+		assert.NotEmpty(t, result.Body)
+	})
+}